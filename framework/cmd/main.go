@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -478,6 +479,179 @@ Be aware that any TODO requires your attention before your run the final test!
 					},
 				},
 			},
+			{
+				Name:  "load",
+				Usage: "Runs load tests defined in TOML/YAML files",
+				Subcommands: []*cli.Command{
+					{
+						Name:    "run",
+						Aliases: []string{"r"},
+						Usage:   "Runs a WASP load profile from a TOML/YAML file",
+						Description: `Runs all generators defined in a load profile file and waits until they finish.
+
+Usage:
+
+	ctf load run profile.toml
+	ctf load run profile.yaml
+`,
+						ArgsUsage: "[PROFILE_FILE]",
+						Action: func(c *cli.Context) error {
+							if c.Args().Len() == 0 {
+								return fmt.Errorf("profile file argument is required")
+							}
+							profile, err := wasp.LoadProfileFromFile(c.Args().First())
+							if err != nil {
+								return fmt.Errorf("failed to load profile: %w", err)
+							}
+							if _, err := profile.Run(true); err != nil {
+								return fmt.Errorf("failed to run profile: %w", err)
+							}
+							failed := false
+							for _, g := range profile.Generators {
+								lat := g.Stats().Latency.Total()
+								framework.L.Info().
+									Str("Generator", g.Cfg.GenName).
+									Int64("Success", g.Stats().Success.Load()).
+									Int64("Failed", g.Stats().Failed.Load()).
+									Dur("P50", lat.P50).
+									Dur("P95", lat.P95).
+									Dur("P99", lat.P99).
+									Dur("Max", lat.Max).
+									Msg("Generator results")
+								if g.Stats().RunFailed.Load() {
+									failed = true
+								}
+							}
+							if failed {
+								return fmt.Errorf("some generators have failed requests")
+							}
+							return nil
+						},
+					},
+					{
+						Name:    "coordinate",
+						Aliases: []string{"c"},
+						Usage:   "Distributes a WASP load profile between workers and aggregates their results",
+						Description: `Waits for workers started with 'ctf load worker', divides the load of a profile file between them,
+starts them at the same time and prints aggregated results.
+
+Usage:
+
+	ctf load coordinate --workers 3 profile.toml
+	ctf load worker --coordinator http://$COORDINATOR_HOST:7711
+`,
+						ArgsUsage: "--workers $n --listen $addr [PROFILE_FILE]",
+						Flags: []cli.Flag{
+							&cli.IntFlag{
+								Name:     "workers",
+								Aliases:  []string{"w"},
+								Required: true,
+								Usage:    "Amount of workers to wait for",
+							},
+							&cli.StringFlag{
+								Name:    "listen",
+								Aliases: []string{"l"},
+								Value:   wasp.DefaultCoordinatorListenAddr,
+								Usage:   "Coordinator listen address",
+							},
+							&cli.DurationFlag{
+								Name:  "start-delay",
+								Value: wasp.DefaultCoordinatorStartDelay,
+								Usage: "Delay between the last worker registration and the synchronized start",
+							},
+							&cli.DurationFlag{
+								Name:  "timeout",
+								Usage: "Time to wait for all workers to report, defaults to the profile duration plus 5m",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							if c.Args().Len() == 0 {
+								return fmt.Errorf("profile file argument is required")
+							}
+							pf, err := wasp.ParseProfileFile(c.Args().First())
+							if err != nil {
+								return fmt.Errorf("failed to load profile: %w", err)
+							}
+							coord, err := wasp.NewCoordinator(&wasp.CoordinatorConfig{
+								ListenAddr: c.String("listen"),
+								Workers:    c.Int("workers"),
+								Payload:    pf,
+								StartDelay: c.Duration("start-delay"),
+							})
+							if err != nil {
+								return err
+							}
+							timeout := c.Duration("timeout")
+							if timeout == 0 {
+								d, err := pf.Duration()
+								if err != nil {
+									return fmt.Errorf("failed to load profile: %w", err)
+								}
+								timeout = d + wasp.DefaultCoordinatorTimeoutMargin
+							}
+							ctx, cancel := context.WithTimeout(c.Context, timeout)
+							defer cancel()
+							res, err := coord.Run(ctx)
+							if err != nil {
+								return fmt.Errorf("failed to run distributed profile: %w", err)
+							}
+							for _, w := range res.Workers {
+								if w.Error != "" {
+									framework.L.Error().Str("Worker", w.Name).Str("Err", w.Error).Msg("Worker failed")
+								}
+							}
+							for _, g := range res.Generators {
+								framework.L.Info().
+									Str("Generator", g.Name).
+									Int64("Success", g.Success).
+									Int64("Failed", g.Failed).
+									Dur("P50", g.Latency.Percentile(50)).
+									Dur("P95", g.Latency.Percentile(95)).
+									Dur("P99", g.Latency.Percentile(99)).
+									Dur("Max", g.Latency.Max()).
+									Msg("Generator results")
+							}
+							if res.Failed() {
+								return fmt.Errorf("some workers or generators have failed")
+							}
+							return nil
+						},
+					},
+					{
+						Name:    "worker",
+						Aliases: []string{"w"},
+						Usage:   "Runs a part of a WASP load profile received from a coordinator",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:     "coordinator",
+								Aliases:  []string{"c"},
+								Required: true,
+								Usage:    "Coordinator URL, ex.: http://10.0.0.1:7711",
+							},
+							&cli.StringFlag{
+								Name:    "name",
+								Aliases: []string{"n"},
+								Usage:   "Worker name, defaults to hostname",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							name := c.String("name")
+							if name == "" {
+								name, _ = os.Hostname()
+							}
+							w, err := wasp.NewWorker(&wasp.WorkerConfig{
+								CoordinatorURL: c.String("coordinator"),
+								Name:           name,
+							})
+							if err != nil {
+								return err
+							}
+							_, err = w.Run(c.Context, wasp.ProfileFileFactory(nil))
+							return err
+						},
+					},
+				},
+			},
 			{
 				Name:  "config",
 				Usage: "Shapes your test config, removes outputs, formatting ,etc",
@@ -0,0 +1,105 @@
+package examples
+
+import (
+	"context"
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/framework"
+	"github.com/smartcontractkit/chainlink-testing-framework/framework/components/blockchain"
+	"github.com/smartcontractkit/chainlink-testing-framework/wasp"
+)
+
+type CfgEVMRPCLoad struct {
+	BlockchainA *blockchain.Input `toml:"blockchain_a" validate:"required"`
+}
+
+// anvilLoadKeys are default anvil accounts used to pre-sign transactions
+var anvilLoadKeys = []string{
+	blockchain.DefaultAnvilPrivateKey,
+	"59c6995e998f97a5a0044966f0945389dc9e86dae88c7a8412f4603b6b78690d",
+}
+
+// preSignTransfers signs txsPerKey self transfers for every key, starting from the pending nonce
+func preSignTransfers(t *testing.T, url string, chainID int64, txsPerKey int) [][]string {
+	c, err := ethclient.Dial(url)
+	require.NoError(t, err)
+	defer c.Close()
+	gasPrice, err := c.SuggestGasPrice(context.Background())
+	require.NoError(t, err)
+	signer := types.LatestSignerForChainID(big.NewInt(chainID))
+	txsByKey := make([][]string, 0, len(anvilLoadKeys))
+	for _, k := range anvilLoadKeys {
+		pk, err := crypto.HexToECDSA(k)
+		require.NoError(t, err)
+		from := crypto.PubkeyToAddress(pk.PublicKey)
+		nonce, err := c.PendingNonceAt(context.Background(), from)
+		require.NoError(t, err)
+		txs := make([]string, 0, txsPerKey)
+		for i := 0; i < txsPerKey; i++ {
+			tx, err := types.SignNewTx(pk, signer, &types.LegacyTx{
+				Nonce:    nonce + uint64(i),
+				To:       &from,
+				Value:    big.NewInt(1),
+				Gas:      21_000,
+				GasPrice: gasPrice,
+			})
+			require.NoError(t, err)
+			raw, err := tx.MarshalBinary()
+			require.NoError(t, err)
+			txs = append(txs, hexutil.Encode(raw))
+		}
+		txsByKey = append(txsByKey, txs)
+	}
+	return txsByKey
+}
+
+func TestEVMRPCLoad(t *testing.T) {
+	in, err := framework.Load[CfgEVMRPCLoad](t)
+	require.NoError(t, err)
+	bc, err := blockchain.NewBlockchainNetwork(in.BlockchainA)
+	require.NoError(t, err)
+	url := bc.Nodes[0].ExternalHTTPUrl
+	chainID, err := strconv.ParseInt(bc.ChainID, 10, 64)
+	require.NoError(t, err)
+
+	rps, duration := int64(10), 30*time.Second
+	pool := wasp.NewPreSignedTxPool(preSignTransfers(t, url, chainID, int(rps*int64(duration.Seconds()))))
+
+	gun, err := wasp.NewJSONRPCGun(&wasp.JSONRPCGunConfig{
+		URL: url,
+		Methods: []*wasp.JSONRPCMethod{
+			{Method: "eth_call", Params: `[{"to": "0x0000000000000000000000000000000000000000", "data": "0x"}, "latest"]`, Weight: 5},
+			{Method: "eth_getLogs", Params: `[{"fromBlock": "earliest", "toBlock": "latest"}]`, Weight: 2},
+			{Method: wasp.MethodEthSendRawTransaction, Weight: 3},
+		},
+		TxSource: pool,
+	})
+	require.NoError(t, err)
+
+	t.Run("EVM RPC methods mix", func(t *testing.T) {
+		gen, err := wasp.NewGenerator(&wasp.Config{
+			T:        t,
+			LoadType: wasp.RPS,
+			GenName:  "evm_rpc",
+			Schedule: wasp.Plain(rps, duration),
+			Gun:      gun,
+		})
+		require.NoError(t, err)
+		gen.Run(true)
+		require.Empty(t, gen.Errors())
+		groups := make(map[string]int)
+		for _, r := range gen.GetData().OKResponses.Data {
+			groups[r.Group]++
+		}
+		require.Len(t, groups, 3)
+	})
+}
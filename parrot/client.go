@@ -96,3 +96,48 @@ func (c *Client) Recorders() ([]string, error) {
 	}
 	return recorders, nil
 }
+
+// Calls returns all route calls journaled by the server, oldest first
+func (c *Client) Calls() ([]*RouteCall, error) {
+	calls := []*RouteCall{}
+	resp, err := c.restyClient.R().SetResult(&calls).Get(JournalRoute)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("failed to get calls, got %d status code: %s", resp.StatusCode(), string(resp.Body()))
+	}
+	return calls, nil
+}
+
+// ResetCalls clears the journal of route calls on the server
+func (c *Client) ResetCalls() error {
+	resp, err := c.restyClient.R().Delete(JournalRoute)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != http.StatusNoContent {
+		return fmt.Errorf("failed to reset calls, got %d status code: %s", resp.StatusCode(), string(resp.Body()))
+	}
+	return nil
+}
+
+// Verify returns the calls of the route matching the matcher and an error if their number is not the expected one,
+// use AtLeastOnce to expect any number of calls
+func (c *Client) Verify(route *Route, match *RequestMatcher, times int) ([]*RouteCall, error) {
+	res := &VerifyResult{}
+	resp, err := c.restyClient.R().
+		SetBody(&VerifyRequest{Route: route, Match: match, Times: times}).
+		SetResult(res).
+		Post(JournalVerifyRoute)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("failed to verify calls, got %d status code: %s", resp.StatusCode(), string(resp.Body()))
+	}
+	if !res.Verified {
+		return res.Calls, newDynamicError(ErrVerificationFailed, res.Message)
+	}
+	return res.Calls, nil
+}
@@ -24,6 +24,7 @@ const (
 	envJSON      = "PARROT_JSON"
 	envRecorders = "PARROT_RECORDERS"
 	envHost      = "PARROT_HOST"
+	envProxy     = "PARROT_PROXY_UPSTREAM"
 )
 
 func main() {
@@ -36,6 +37,12 @@ func main() {
 		json      bool
 		recorders []string
 		host      string
+
+		proxyUpstream        string
+		proxyRecord          bool
+		proxyMatchHeaders    []string
+		proxyRedactHeaders   []string
+		proxyRedactJSONPaths []string
 	)
 
 	preRun := func(cmd *cobra.Command, args []string) {
@@ -66,6 +73,9 @@ func main() {
 				recorders = strings.Split(r, ",")
 			}
 		}
+		if !cmd.Flags().Changed("proxy") {
+			proxyUpstream = os.Getenv(envProxy)
+		}
 	}
 
 	rootCmd := &cobra.Command{
@@ -96,6 +106,15 @@ func main() {
 				options = append(options, parrot.WithJSONLogs())
 			}
 			options = append(options, parrot.WithRecorders(recorders...))
+			if proxyUpstream != "" {
+				options = append(options, parrot.WithProxy(&parrot.ProxyConfig{
+					Upstream:        proxyUpstream,
+					Record:          proxyRecord,
+					MatchHeaders:    proxyMatchHeaders,
+					RedactHeaders:   proxyRedactHeaders,
+					RedactJSONPaths: proxyRedactJSONPaths,
+				}))
+			}
 
 			ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 			defer cancel()
@@ -124,6 +143,11 @@ func main() {
 	rootCmd.Flags().BoolVarP(&json, "json", "j", false, fmt.Sprintf("Output logs in JSON format (env: %s)", envJSON))
 	rootCmd.Flags().StringSliceVarP(&recorders, "recorders", "r", nil, fmt.Sprintf("Existing recorders to use (env: %s)", envRecorders))
 	rootCmd.Flags().StringVar(&host, "host", "localhost", fmt.Sprintf("Host to run the parrot on. (env: %s)", envHost))
+	rootCmd.Flags().StringVar(&proxyUpstream, "proxy", "", fmt.Sprintf("Upstream URL to forward requests no route responds to (env: %s)", envProxy))
+	rootCmd.Flags().BoolVar(&proxyRecord, "proxy-record", false, "Record proxied requests as routes")
+	rootCmd.Flags().StringSliceVar(&proxyMatchHeaders, "proxy-match-headers", nil, "Request headers recorded routes should match")
+	rootCmd.Flags().StringSliceVar(&proxyRedactHeaders, "proxy-redact-headers", nil, "Request headers recorded routes require without saving their values")
+	rootCmd.Flags().StringSliceVar(&proxyRedactJSONPaths, "proxy-redact-json-paths", nil, "JSON body values to redact in recorded routes, ex.: $.api_key")
 
 	healthCheckCmd := &cobra.Command{
 		Use:    "health",
@@ -14,6 +14,14 @@ var (
 	ErrResponseMarshal = errors.New("unable to marshal response body to JSON")
 	ErrRouteNotFound   = errors.New("route not found")
 	ErrWildcardPath    = fmt.Errorf("path can only contain one wildcard '*' and it must be the final value")
+	ErrInvalidMatcher  = errors.New("invalid request matcher")
+	ErrInvalidMaxCalls = errors.New("max calls can't be negative")
+	ErrNoMatchingRoute = errors.New("no route matches the request")
+	ErrInvalidTemplate = errors.New("invalid response template")
+	ErrInvalidFault    = errors.New("invalid route fault")
+
+	ErrVerificationFailed = errors.New("route calls verification failed")
+	ErrInvalidJournalSize = errors.New("journal size should be positive")
 
 	ErrNoRecorderURL      = errors.New("no recorder URL specified")
 	ErrInvalidRecorderURL = errors.New("invalid recorder URL")
@@ -0,0 +1,146 @@
+package parrot
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+)
+
+// DefaultFaultStatusCode is returned by routes with a fault error rate if no status code is set
+const DefaultFaultStatusCode = http.StatusInternalServerError
+
+// RouteFault simulates a misbehaving upstream for a route, rates are probabilities from 0 to 1 checked on every call
+type RouteFault struct {
+	// LatencyMs delays every response
+	LatencyMs int `json:"latency_ms,omitempty"`
+	// JitterMs adds a random delay up to its value to the latency
+	JitterMs int `json:"jitter_ms,omitempty"`
+	// ErrorRate is the probability of responding with ErrorStatusCode instead of the route response
+	ErrorRate float64 `json:"error_rate,omitempty"`
+	// ErrorStatusCode is the status of error responses, defaults to DefaultFaultStatusCode
+	ErrorStatusCode int `json:"error_status_code,omitempty"`
+	// ErrorBody is the body of error responses, defaults to the status text
+	ErrorBody string `json:"error_body,omitempty"`
+	// ResetRate is the probability of resetting the connection without a response
+	ResetRate float64 `json:"reset_rate,omitempty"`
+	// TruncateRate is the probability of cutting the response body in half, ex.: to send malformed JSON
+	TruncateRate float64 `json:"truncate_rate,omitempty"`
+	// SlowBodyChunkBytes streams the body in chunks of this size, every chunk is delayed by SlowBodyChunkDelayMs
+	SlowBodyChunkBytes int `json:"slow_body_chunk_bytes,omitempty"`
+	// SlowBodyChunkDelayMs is the delay before every chunk of a slow body
+	SlowBodyChunkDelayMs int `json:"slow_body_chunk_delay_ms,omitempty"`
+}
+
+// validate checks that delays are not negative and rates are probabilities
+func (f *RouteFault) validate() error {
+	if f.LatencyMs < 0 || f.JitterMs < 0 || f.SlowBodyChunkBytes < 0 || f.SlowBodyChunkDelayMs < 0 {
+		return newDynamicError(ErrInvalidFault, "latency, jitter and slow body settings can't be negative")
+	}
+	for name, rate := range map[string]float64{"error_rate": f.ErrorRate, "reset_rate": f.ResetRate, "truncate_rate": f.TruncateRate} {
+		if rate < 0 || rate > 1 {
+			return newDynamicError(ErrInvalidFault, fmt.Sprintf("%s should be from 0 to 1, got %f", name, rate))
+		}
+	}
+	if f.ErrorStatusCode != 0 && (f.ErrorStatusCode < 100 || f.ErrorStatusCode > 599) {
+		return newDynamicError(ErrInvalidFault, fmt.Sprintf("invalid error status code %d", f.ErrorStatusCode))
+	}
+	return nil
+}
+
+// happens returns true with the given probability
+func happens(rate float64) bool {
+	return rate > 0 && rand.Float64() < rate //nolint:gosec
+}
+
+// wait sleeps for d or until the request is cancelled
+func wait(r *http.Request, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(d):
+		return nil
+	case <-r.Context().Done():
+		return r.Context().Err()
+	}
+}
+
+// inject writes the response with faults applied, the returned error describes the fault or why the response was not completed
+func (f *RouteFault) inject(w http.ResponseWriter, r *http.Request, statusCode int, contentType string, body []byte) error {
+	delay := time.Duration(f.LatencyMs) * time.Millisecond
+	if f.JitterMs > 0 {
+		delay += time.Duration(rand.IntN(f.JitterMs+1)) * time.Millisecond //nolint:gosec
+	}
+	if err := wait(r, delay); err != nil {
+		return err
+	}
+
+	if happens(f.ResetRate) {
+		return resetConnection(w)
+	}
+
+	if happens(f.ErrorRate) {
+		code := f.ErrorStatusCode
+		if code == 0 {
+			code = DefaultFaultStatusCode
+		}
+		errBody := f.ErrorBody
+		if errBody == "" {
+			errBody = http.StatusText(code)
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(code)
+		_, _ = w.Write([]byte(errBody))
+		return fmt.Errorf("responded with error status %d", code)
+	}
+
+	var injected error
+	if happens(f.TruncateRate) {
+		body = body[:len(body)/2]
+		injected = fmt.Errorf("truncated body to %d bytes", len(body))
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	if f.SlowBodyChunkBytes == 0 {
+		w.WriteHeader(statusCode)
+		if _, err := w.Write(body); err != nil {
+			return err
+		}
+		return injected
+	}
+	// chunked transfer, so the client can't tell the body length in advance
+	w.WriteHeader(statusCode)
+	flusher, _ := w.(http.Flusher)
+	for start := 0; start < len(body); start += f.SlowBodyChunkBytes {
+		if err := wait(r, time.Duration(f.SlowBodyChunkDelayMs)*time.Millisecond); err != nil {
+			return err
+		}
+		end := min(start+f.SlowBodyChunkBytes, len(body))
+		if _, err := w.Write(body[start:end]); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	return injected
+}
+
+// resetConnection closes the connection without a response, TCP connections are reset instead of being closed gracefully
+func resetConnection(w http.ResponseWriter) error {
+	h, ok := w.(http.Hijacker)
+	if !ok {
+		return fmt.Errorf("can't reset connection, response writer does not support hijacking")
+	}
+	conn, _, err := h.Hijack()
+	if err != nil {
+		return fmt.Errorf("can't reset connection: %w", err)
+	}
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		_ = tcpConn.SetLinger(0)
+	}
+	_ = conn.Close()
+	return fmt.Errorf("connection reset")
+}
@@ -0,0 +1,181 @@
+package parrot
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteFaults(t *testing.T) {
+	t.Parallel()
+
+	p := newParrot(t)
+
+	testCases := []struct {
+		name  string
+		route *Route
+		check func(t *testing.T, resp *resty.Response, err error, duration time.Duration)
+	}{
+		{
+			name: "latency",
+			route: &Route{
+				Method:             http.MethodGet,
+				Path:               "/latency",
+				RawResponseBody:    "Squawk",
+				ResponseStatusCode: http.StatusOK,
+				Fault:              &RouteFault{LatencyMs: 200, JitterMs: 50},
+			},
+			check: func(t *testing.T, resp *resty.Response, err error, duration time.Duration) {
+				require.NoError(t, err)
+				assert.Equal(t, "Squawk", string(resp.Body()))
+				assert.GreaterOrEqual(t, duration, 200*time.Millisecond)
+			},
+		},
+		{
+			name: "error status",
+			route: &Route{
+				Method:             http.MethodGet,
+				Path:               "/error",
+				RawResponseBody:    "Squawk",
+				ResponseStatusCode: http.StatusOK,
+				Fault:              &RouteFault{ErrorRate: 1, ErrorStatusCode: http.StatusBadGateway},
+			},
+			check: func(t *testing.T, resp *resty.Response, err error, _ time.Duration) {
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusBadGateway, resp.StatusCode())
+				assert.Equal(t, http.StatusText(http.StatusBadGateway), string(resp.Body()))
+			},
+		},
+		{
+			name: "connection reset",
+			route: &Route{
+				Method:             http.MethodGet,
+				Path:               "/reset",
+				RawResponseBody:    "Squawk",
+				ResponseStatusCode: http.StatusOK,
+				Fault:              &RouteFault{ResetRate: 1},
+			},
+			check: func(t *testing.T, _ *resty.Response, err error, _ time.Duration) {
+				require.Error(t, err, "connection should be dropped")
+			},
+		},
+		{
+			name: "truncated body",
+			route: &Route{
+				Method:             http.MethodGet,
+				Path:               "/truncated",
+				ResponseBody:       map[string]any{"result": "0123456789"},
+				ResponseStatusCode: http.StatusOK,
+				Fault:              &RouteFault{TruncateRate: 1},
+			},
+			check: func(t *testing.T, resp *resty.Response, err error, _ time.Duration) {
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusOK, resp.StatusCode())
+				assert.False(t, json.Valid(resp.Body()), "body should be malformed JSON")
+				assert.Equal(t, `{"result":"0`, string(resp.Body()))
+			},
+		},
+		{
+			name: "slow body",
+			route: &Route{
+				Method:             http.MethodGet,
+				Path:               "/slow",
+				RawResponseBody:    "Squawk Squawk",
+				ResponseStatusCode: http.StatusOK,
+				Fault:              &RouteFault{SlowBodyChunkBytes: 5, SlowBodyChunkDelayMs: 100},
+			},
+			check: func(t *testing.T, resp *resty.Response, err error, duration time.Duration) {
+				require.NoError(t, err)
+				assert.Equal(t, "Squawk Squawk", string(resp.Body()))
+				assert.GreaterOrEqual(t, duration, 300*time.Millisecond, "3 chunks should be delayed")
+			},
+		},
+		{
+			name: "no faults happen with zero rates",
+			route: &Route{
+				Method:             http.MethodGet,
+				Path:               "/steady",
+				RawResponseBody:    "Squawk",
+				ResponseStatusCode: http.StatusCreated,
+				Fault:              &RouteFault{},
+			},
+			check: func(t *testing.T, resp *resty.Response, err error, _ time.Duration) {
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusCreated, resp.StatusCode())
+				assert.Equal(t, "Squawk", string(resp.Body()))
+			},
+		},
+	}
+
+	// routes are registered before calls, the router can't be changed while requests are handled
+	for _, tc := range testCases {
+		require.NoError(t, p.Register(tc.route), "error registering route")
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			start := time.Now()
+			resp, err := p.Call(tc.route.Method, tc.route.Path)
+			tc.check(t, resp, err, time.Since(start))
+		})
+	}
+}
+
+func TestRouteFaultsOverAPI(t *testing.T) {
+	t.Parallel()
+
+	p := newParrot(t)
+	client := NewClient("http://" + p.Address())
+
+	route := &Route{
+		Method:             http.MethodGet,
+		Path:               "/flaky",
+		RawResponseBody:    "Squawk",
+		ResponseStatusCode: http.StatusOK,
+		Fault:              &RouteFault{ErrorRate: 0.5, ErrorStatusCode: http.StatusServiceUnavailable, LatencyMs: 10},
+	}
+	require.NoError(t, client.RegisterRoute(route), "error registering route")
+
+	routes, err := client.Routes()
+	require.NoError(t, err)
+	require.Len(t, routes, 1)
+	assert.Equal(t, route.Fault, routes[0].Fault)
+
+	statuses := map[int]int{}
+	for i := 0; i < 100; i++ {
+		resp, err := client.CallRoute(route.Method, route.Path)
+		require.NoError(t, err)
+		statuses[resp.StatusCode()]++
+	}
+	assert.Len(t, statuses, 2, "both successful and error responses are expected")
+	assert.Greater(t, statuses[http.StatusServiceUnavailable], 0)
+}
+
+func TestBadRouteFaults(t *testing.T) {
+	t.Parallel()
+
+	p := newParrot(t)
+
+	for _, fault := range []*RouteFault{
+		{LatencyMs: -1},
+		{ErrorRate: 1.5},
+		{ResetRate: -0.1},
+		{ErrorRate: 1, ErrorStatusCode: 42},
+	} {
+		err := p.Register(&Route{
+			Method:             http.MethodGet,
+			Path:               "/bad",
+			RawResponseBody:    "Squawk",
+			ResponseStatusCode: http.StatusOK,
+			Fault:              fault,
+		})
+		require.ErrorIs(t, err, ErrInvalidFault)
+	}
+}
@@ -0,0 +1,197 @@
+package parrot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// DefaultJournalSize is the number of the latest route calls the server keeps
+	DefaultJournalSize = 10000
+	// AtLeastOnce verifies that a route was called any number of times, but at least once
+	AtLeastOnce = -1
+)
+
+// VerifyRequest describes expected calls of a route
+type VerifyRequest struct {
+	// Route is the route that should be called, only its method, path and matcher are used
+	Route *Route `json:"route"`
+	// Match filters calls of the route, ex.: by body, all calls are counted if not set
+	Match *RequestMatcher `json:"match,omitempty"`
+	// Times is the expected number of matching calls, AtLeastOnce or any other negative value expects at least one
+	Times int `json:"times"`
+}
+
+// VerifyResult holds the calls matching a VerifyRequest
+type VerifyResult struct {
+	// Calls are the matching calls, oldest first
+	Calls []*RouteCall `json:"calls"`
+	// Verified is true if the number of calls is as expected
+	Verified bool `json:"verified"`
+	// Message describes the verification failure
+	Message string `json:"message,omitempty"`
+}
+
+// routeCallKey is the request context key of the RouteCall being recorded
+type routeCallKey struct{}
+
+// withRouteCall adds the recorded call to the request context, so the handler can set the ID of the route that responded
+func withRouteCall(r *http.Request, routeCall *RouteCall) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), routeCallKey{}, routeCall))
+}
+
+// setRouteCallID sets the ID of the route that responded to the request
+func setRouteCallID(r *http.Request, routeID string) {
+	if routeCall, ok := r.Context().Value(routeCallKey{}).(*RouteCall); ok {
+		routeCall.RouteID = routeID
+	}
+}
+
+// journalCall stores the route call, the oldest calls are dropped when the journal is full
+func (p *Server) journalCall(routeCall *RouteCall) {
+	p.journalMu.Lock()
+	defer p.journalMu.Unlock()
+	if len(p.journal) < p.journalSize {
+		p.journal = append(p.journal, routeCall)
+		return
+	}
+	p.journal[p.journalHead] = routeCall
+	p.journalHead = (p.journalHead + 1) % len(p.journal)
+}
+
+// Calls returns all journaled route calls, oldest first
+func (p *Server) Calls() []*RouteCall {
+	p.journalMu.RLock()
+	defer p.journalMu.RUnlock()
+	calls := make([]*RouteCall, 0, len(p.journal))
+	calls = append(calls, p.journal[p.journalHead:]...)
+	return append(calls, p.journal[:p.journalHead]...)
+}
+
+// ResetCalls clears the journal
+func (p *Server) ResetCalls() {
+	p.journalMu.Lock()
+	defer p.journalMu.Unlock()
+	p.journal = nil
+	p.journalHead = 0
+	p.log.Info().Msg("Reset route calls journal")
+}
+
+// Verify returns the calls of the route matching the matcher and an error if their number is not the expected one,
+// ex.: p.Verify(route, &RequestMatcher{JSONBody: body}, 3)
+func (p *Server) Verify(route *Route, match *RequestMatcher, times int) ([]*RouteCall, error) {
+	res, err := p.verify(&VerifyRequest{Route: route, Match: match, Times: times})
+	if err != nil {
+		return nil, err
+	}
+	if !res.Verified {
+		return res.Calls, newDynamicError(ErrVerificationFailed, res.Message)
+	}
+	return res.Calls, nil
+}
+
+func (p *Server) verify(req *VerifyRequest) (*VerifyResult, error) {
+	if req == nil || req.Route == nil {
+		return nil, ErrNilRoute
+	}
+	matcher, err := newRequestMatcher(req.Match)
+	if err != nil {
+		return nil, err
+	}
+	route := *req.Route
+	if !strings.HasPrefix(route.Path, "/") {
+		route.Path = "/" + route.Path
+	}
+	routeID := route.ID()
+
+	res := &VerifyResult{Calls: []*RouteCall{}}
+	for _, call := range p.Calls() {
+		if call.RouteID != routeID || !matcher.match(call.Request.httpRequest()) {
+			continue
+		}
+		res.Calls = append(res.Calls, call)
+	}
+	switch {
+	case req.Times < 0:
+		res.Verified = len(res.Calls) > 0
+		res.Message = fmt.Sprintf("expected '%s' to be called at least once, but it was not", routeID)
+	default:
+		res.Verified = len(res.Calls) == req.Times
+		res.Message = fmt.Sprintf("expected '%s' to be called %d times, but it was called %d times", routeID, req.Times, len(res.Calls))
+	}
+	if res.Verified {
+		res.Message = ""
+	}
+	return res, nil
+}
+
+// httpRequest rebuilds the recorded request to match it
+func (r *RouteCallRequest) httpRequest() *http.Request {
+	req := &http.Request{
+		Method: r.Method,
+		URL:    r.URL,
+		Header: r.Header,
+		Body:   io.NopCloser(bytes.NewReader(r.Body)),
+	}
+	if req.URL == nil {
+		req.URL = &url.URL{}
+	}
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	return req
+}
+
+// journalHandlerGET returns all journaled route calls
+// GET /journal
+func (p *Server) journalHandlerGET(w http.ResponseWriter, r *http.Request) {
+	journalLogger := zerolog.Ctx(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(p.Calls()); err != nil {
+		http.Error(w, "Failed to write response", http.StatusInternalServerError)
+		journalLogger.Error().Err(err).Msg("Failed to write response")
+	}
+}
+
+// journalHandlerDELETE clears the journal
+// DELETE /journal
+func (p *Server) journalHandlerDELETE(w http.ResponseWriter, _ *http.Request) {
+	p.ResetCalls()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// journalVerifyHandlerPOST verifies calls of a route
+// POST /journal/verify
+func (p *Server) journalVerifyHandlerPOST(w http.ResponseWriter, r *http.Request) {
+	journalLogger := zerolog.Ctx(r.Context())
+
+	var req *VerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		journalLogger.Debug().Err(err).Msg("Failed to decode request body")
+		return
+	}
+	defer r.Body.Close()
+
+	res, err := p.verify(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		journalLogger.Debug().Err(err).Msg("Failed to verify route calls")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		http.Error(w, "Failed to write response", http.StatusInternalServerError)
+		journalLogger.Error().Err(err).Msg("Failed to write response")
+	}
+}
@@ -0,0 +1,143 @@
+package parrot
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	p := newParrot(t)
+	client := resty.New().SetBaseURL("http://" + p.Address())
+
+	price := &Route{
+		Method:             http.MethodPost,
+		Path:               "/v1/price",
+		RawResponseBody:    "42",
+		ResponseStatusCode: http.StatusOK,
+	}
+	ping := &Route{
+		Method:             http.MethodGet,
+		Path:               "/ping",
+		RawResponseBody:    "pong",
+		ResponseStatusCode: http.StatusOK,
+		Match:              &RequestMatcher{Query: map[string]ValueMatcher{"id": {Regex: ".+"}}},
+	}
+	require.NoError(t, p.Register(price))
+	require.NoError(t, p.Register(ping))
+
+	for _, body := range []string{`{"pair": "LINK/USD"}`, `{"pair": "LINK/USD"}`, `{"pair": "ETH/USD"}`} {
+		_, err := client.R().SetBody(body).Post(price.Path)
+		require.NoError(t, err)
+	}
+	_, err := client.R().SetQueryParam("id", "1").Get(ping.Path)
+	require.NoError(t, err)
+
+	calls, err := p.Verify(price, nil, 3)
+	require.NoError(t, err)
+	require.Len(t, calls, 3)
+	assert.Equal(t, price.ID(), calls[0].RouteID)
+	assert.Equal(t, `{"pair": "LINK/USD"}`, string(calls[0].Request.Body))
+	assert.Equal(t, "42", string(calls[0].Response.Body))
+
+	calls, err = p.Verify(price, &RequestMatcher{JSONBody: map[string]any{"pair": "LINK/USD"}}, 2)
+	require.NoError(t, err)
+	require.Len(t, calls, 2)
+
+	calls, err = p.Verify(price, &RequestMatcher{Body: []BodyMatcher{{JSONPath: "$.pair", ValueMatcher: ValueMatcher{Equals: "BTC/USD"}}}}, 1)
+	require.ErrorIs(t, err, ErrVerificationFailed)
+	require.ErrorContains(t, err, "expected 'POST:/v1/price' to be called 1 times, but it was called 0 times")
+	require.Empty(t, calls)
+
+	calls, err = p.Verify(ping, nil, AtLeastOnce)
+	require.NoError(t, err, "route with a matcher should be verified by its own ID")
+	require.Len(t, calls, 1)
+
+	_, err = p.Verify(&Route{Method: http.MethodGet, Path: "/never"}, nil, AtLeastOnce)
+	require.ErrorContains(t, err, "to be called at least once")
+
+	_, err = p.Verify(price, &RequestMatcher{Query: map[string]ValueMatcher{"a": {}}}, 1)
+	require.ErrorIs(t, err, ErrInvalidMatcher)
+
+	require.Len(t, p.Calls(), 4)
+	p.ResetCalls()
+	require.Empty(t, p.Calls())
+	_, err = p.Verify(price, nil, 0)
+	require.NoError(t, err)
+}
+
+func TestVerifyClient(t *testing.T) {
+	t.Parallel()
+
+	p := newParrot(t)
+	client := NewClient("http://" + p.Address())
+
+	route := &Route{
+		Method:             http.MethodPost,
+		Path:               "/v1/price",
+		RawResponseBody:    "42",
+		ResponseStatusCode: http.StatusOK,
+	}
+	require.NoError(t, client.RegisterRoute(route))
+
+	for i := 0; i < 3; i++ {
+		_, err := client.restyClient.R().SetBody(`{"pair": "LINK/USD"}`).Post(route.Path)
+		require.NoError(t, err)
+	}
+
+	calls, err := client.Verify(route, &RequestMatcher{Body: []BodyMatcher{{JSONPath: "$.pair", ValueMatcher: ValueMatcher{Equals: "LINK/USD"}}}}, 3)
+	require.NoError(t, err)
+	require.Len(t, calls, 3)
+	assert.Equal(t, "/v1/price", calls[0].Request.URL.Path)
+
+	calls, err = client.Verify(route, nil, 2)
+	require.ErrorIs(t, err, ErrVerificationFailed)
+	require.Len(t, calls, 3, "calls should be returned with a failed verification")
+
+	_, err = client.Verify(route, &RequestMatcher{Headers: map[string]ValueMatcher{"A": {Regex: "("}}}, 1)
+	require.ErrorContains(t, err, "invalid request matcher")
+
+	journal, err := client.Calls()
+	require.NoError(t, err)
+	require.Len(t, journal, 3, "calls of the parrot API should not be journaled")
+
+	require.NoError(t, client.ResetCalls())
+	_, err = client.Verify(route, nil, AtLeastOnce)
+	require.ErrorIs(t, err, ErrVerificationFailed)
+}
+
+func TestJournalSize(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewServer(WithJournalSize(0))
+	require.ErrorIs(t, err, ErrInvalidJournalSize)
+
+	p := newParrot(t)
+	p.journalSize = 2
+	for _, id := range []string{"1", "2", "3"} {
+		p.journalCall(&RouteCall{ID: id})
+	}
+	calls := p.Calls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, "2", calls[0].ID)
+	assert.Equal(t, "3", calls[1].ID)
+
+	for _, id := range []string{"4", "5", "6"} {
+		p.journalCall(&RouteCall{ID: id})
+	}
+	calls = p.Calls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, "5", calls[0].ID)
+	assert.Equal(t, "6", calls[1].ID)
+
+	p.ResetCalls()
+	p.journalCall(&RouteCall{ID: "7"})
+	calls = p.Calls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, "7", calls[0].ID)
+}
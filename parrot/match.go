@@ -0,0 +1,275 @@
+package parrot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ValueMatcher matches a single request value either exactly or by a regular expression
+type ValueMatcher struct {
+	// Equals is the exact expected value
+	Equals string `json:"equals,omitempty"`
+	// Regex is a regular expression the value should match, ex.: "^0x[0-9a-f]+$"
+	Regex string `json:"regex,omitempty"`
+}
+
+// BodyMatcher matches a value of a JSON request body
+type BodyMatcher struct {
+	// JSONPath is a dot separated path to the value, ex.: "$.params.0.to", numeric path elements index arrays
+	JSONPath string `json:"json_path"`
+	ValueMatcher
+}
+
+// RequestMatcher restricts a route to requests with matching query parameters, headers and body,
+// all the conditions should match
+type RequestMatcher struct {
+	// Query matches query parameters by name, any of the parameter values can match
+	Query map[string]ValueMatcher `json:"query,omitempty"`
+	// Headers matches headers by name, any of the header values can match
+	Headers map[string]ValueMatcher `json:"headers,omitempty"`
+	// JSONBody matches a request body that is JSON equal to it
+	JSONBody any `json:"json_body,omitempty"`
+	// Body matches values of a JSON request body
+	Body []BodyMatcher `json:"body,omitempty"`
+}
+
+// RouteResponse is one of the responses of a route returned in sequence
+type RouteResponse struct {
+	// RawResponseBody is the static, raw string response to return when called
+	RawResponseBody string `json:"raw_response_body,omitempty"`
+	// ResponseBody will be marshalled to JSON and returned when called
+	ResponseBody any `json:"response_body,omitempty"`
+	// ResponseStatusCode is the HTTP status code to return when called
+	ResponseStatusCode int `json:"response_status_code"`
+}
+
+// valueMatcher is a validated ValueMatcher
+type valueMatcher struct {
+	equals string
+	regex  *regexp.Regexp
+}
+
+func newValueMatcher(m ValueMatcher) (*valueMatcher, error) {
+	if m.Equals != "" && m.Regex != "" {
+		return nil, fmt.Errorf("only one of equals and regex can be set")
+	}
+	if m.Equals == "" && m.Regex == "" {
+		return nil, fmt.Errorf("one of equals and regex is required")
+	}
+	if m.Regex == "" {
+		return &valueMatcher{equals: m.Equals}, nil
+	}
+	re, err := regexp.Compile(m.Regex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex '%s': %w", m.Regex, err)
+	}
+	return &valueMatcher{regex: re}, nil
+}
+
+func (m *valueMatcher) match(v string) bool {
+	if m.regex != nil {
+		return m.regex.MatchString(v)
+	}
+	return m.equals == v
+}
+
+func (m *valueMatcher) matchAny(values []string) bool {
+	for _, v := range values {
+		if m.match(v) {
+			return true
+		}
+	}
+	return false
+}
+
+type bodyMatcher struct {
+	jsonPath string
+	*valueMatcher
+}
+
+// requestMatcher is a validated RequestMatcher
+type requestMatcher struct {
+	query    map[string]*valueMatcher
+	headers  map[string]*valueMatcher
+	jsonBody any
+	body     []bodyMatcher
+}
+
+// newRequestMatcher validates the matcher and compiles its regular expressions, nil matcher matches any request
+func newRequestMatcher(m *RequestMatcher) (*requestMatcher, error) {
+	if m == nil {
+		return nil, nil
+	}
+	rm := &requestMatcher{
+		query:   make(map[string]*valueMatcher, len(m.Query)),
+		headers: make(map[string]*valueMatcher, len(m.Headers)),
+	}
+	for name, vm := range m.Query {
+		matcher, err := newValueMatcher(vm)
+		if err != nil {
+			return nil, newDynamicError(ErrInvalidMatcher, fmt.Sprintf("query '%s': %s", name, err.Error()))
+		}
+		rm.query[name] = matcher
+	}
+	for name, vm := range m.Headers {
+		matcher, err := newValueMatcher(vm)
+		if err != nil {
+			return nil, newDynamicError(ErrInvalidMatcher, fmt.Sprintf("header '%s': %s", name, err.Error()))
+		}
+		rm.headers[name] = matcher
+	}
+	if m.JSONBody != nil {
+		// normalize to the same types a decoded request body has
+		raw, err := json.Marshal(m.JSONBody)
+		if err != nil {
+			return nil, newDynamicError(ErrInvalidMatcher, fmt.Sprintf("json body: %s", err.Error()))
+		}
+		if err := json.Unmarshal(raw, &rm.jsonBody); err != nil {
+			return nil, newDynamicError(ErrInvalidMatcher, fmt.Sprintf("json body: %s", err.Error()))
+		}
+	}
+	for _, bm := range m.Body {
+		matcher, err := newValueMatcher(bm.ValueMatcher)
+		if err != nil {
+			return nil, newDynamicError(ErrInvalidMatcher, fmt.Sprintf("body '%s': %s", bm.JSONPath, err.Error()))
+		}
+		rm.body = append(rm.body, bodyMatcher{jsonPath: bm.JSONPath, valueMatcher: matcher})
+	}
+	return rm, nil
+}
+
+// match checks if the request matches, the request body is read only if it's matched
+func (m *requestMatcher) match(r *http.Request) bool {
+	if m == nil {
+		return true
+	}
+	query := r.URL.Query()
+	for name, vm := range m.query {
+		if !vm.matchAny(query[name]) {
+			return false
+		}
+	}
+	for name, vm := range m.headers {
+		if !vm.matchAny(r.Header.Values(name)) {
+			return false
+		}
+	}
+	if m.jsonBody == nil && len(m.body) == 0 {
+		return true
+	}
+	body, ok := decodeJSONBody(r)
+	if !ok {
+		return false
+	}
+	if m.jsonBody != nil && !reflect.DeepEqual(m.jsonBody, body) {
+		return false
+	}
+	for _, bm := range m.body {
+		v, ok := jsonPathLookup(body, bm.jsonPath)
+		if !ok || !bm.match(jsonValueString(v)) {
+			return false
+		}
+	}
+	return true
+}
+
+// bufferBody reads the request body into memory and restores it for later readers
+func bufferBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// decodeJSONBody decodes the request body and restores it for later readers
+func decodeJSONBody(r *http.Request) (any, bool) {
+	data, err := bufferBody(r)
+	if err != nil || data == nil {
+		return nil, false
+	}
+	var body any
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, false
+	}
+	return body, true
+}
+
+// jsonPathLookup returns a value from decoded JSON by a dot separated path, ex.: "$.data.items.0.id",
+// numeric path elements index arrays
+func jsonPathLookup(v any, path string) (any, bool) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return v, true
+	}
+	for _, p := range strings.Split(path, ".") {
+		switch cur := v.(type) {
+		case map[string]any:
+			next, ok := cur[p]
+			if !ok {
+				return nil, false
+			}
+			v = next
+		case []any:
+			i, err := strconv.Atoi(p)
+			if err != nil || i < 0 || i >= len(cur) {
+				return nil, false
+			}
+			v = cur[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// jsonValueString returns strings as they are and other JSON values in their compact JSON form, ex.: 1, true, null
+func jsonValueString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// matchID returns a short stable identifier of the matcher to tell apart routes with the same method and path
+func matchID(m *RequestMatcher) string {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return ""
+	}
+	h := fnv.New32a()
+	_, _ = h.Write(data)
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// validateResponse checks that the response has exactly one valid body
+func validateResponse(raw string, body any) error {
+	if body == nil && raw == "" {
+		return ErrNoResponse
+	}
+	if body != nil && raw != "" {
+		return ErrOnlyOneResponse
+	}
+	if body != nil {
+		if _, err := json.Marshal(body); err != nil {
+			return newDynamicError(ErrResponseMarshal, err.Error())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,347 @@
+package parrot
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteMatchers(t *testing.T) {
+	t.Parallel()
+
+	p := newParrot(t)
+	client := resty.New().SetBaseURL("http://" + p.Address())
+
+	routes := []*Route{
+		{
+			Method:             http.MethodPost,
+			Path:               "/adapter",
+			RawResponseBody:    "default",
+			ResponseStatusCode: http.StatusOK,
+		},
+		{
+			Method:             http.MethodPost,
+			Path:               "/adapter",
+			RawResponseBody:    "eth",
+			ResponseStatusCode: http.StatusOK,
+			Match: &RequestMatcher{
+				Query: map[string]ValueMatcher{"asset": {Equals: "ETH"}},
+			},
+		},
+		{
+			Method:             http.MethodPost,
+			Path:               "/adapter",
+			RawResponseBody:    "authorized",
+			ResponseStatusCode: http.StatusOK,
+			Match: &RequestMatcher{
+				Headers: map[string]ValueMatcher{"Authorization": {Regex: "^Bearer .+"}},
+			},
+		},
+		{
+			Method:             http.MethodPost,
+			Path:               "/adapter",
+			ResponseBody:       map[string]any{"result": 42},
+			ResponseStatusCode: http.StatusOK,
+			Match: &RequestMatcher{
+				Body: []BodyMatcher{
+					{JSONPath: "$.data.from", ValueMatcher: ValueMatcher{Equals: "LINK"}},
+					{JSONPath: "$.data.amounts.1", ValueMatcher: ValueMatcher{Regex: "^[0-9]+$"}},
+				},
+			},
+		},
+		{
+			Method:             http.MethodPost,
+			Path:               "/adapter",
+			RawResponseBody:    "exact",
+			ResponseStatusCode: http.StatusOK,
+			Match: &RequestMatcher{
+				JSONBody: map[string]any{"id": 1, "method": "ping"},
+			},
+		},
+	}
+	for _, route := range routes {
+		require.NoError(t, p.Register(route), "error registering route")
+	}
+	require.Len(t, p.Routes(), len(routes), "routes with the same method and path but different matchers should not replace each other")
+
+	testCases := []struct {
+		name     string
+		request  *resty.Request
+		expected string
+	}{
+		{
+			name:     "no matcher matches",
+			request:  client.R(),
+			expected: "default",
+		},
+		{
+			name:     "query",
+			request:  client.R().SetQueryParam("asset", "ETH"),
+			expected: "eth",
+		},
+		{
+			name:     "query mismatch",
+			request:  client.R().SetQueryParam("asset", "BTC"),
+			expected: "default",
+		},
+		{
+			name:     "header regex",
+			request:  client.R().SetHeader("Authorization", "Bearer token"),
+			expected: "authorized",
+		},
+		{
+			name:     "json path",
+			request:  client.R().SetBody(`{"data": {"from": "LINK", "amounts": [1, 25]}}`),
+			expected: "{\"result\":42}\n",
+		},
+		{
+			name:     "json path mismatch",
+			request:  client.R().SetBody(`{"data": {"from": "LINK", "amounts": [1]}}`),
+			expected: "default",
+		},
+		{
+			name:     "exact json body",
+			request:  client.R().SetBody(`{"method": "ping", "id": 1}`),
+			expected: "exact",
+		},
+		{
+			name:     "invalid json body",
+			request:  client.R().SetBody(`{"method": "ping"`),
+			expected: "default",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := tc.request.Post("/adapter")
+			require.NoError(t, err, "error calling parrot")
+			assert.Equal(t, http.StatusOK, resp.StatusCode())
+			assert.Equal(t, tc.expected, string(resp.Body()))
+		})
+	}
+}
+
+func TestRouteResponseSequence(t *testing.T) {
+	t.Parallel()
+
+	p := newParrot(t)
+
+	route := &Route{
+		Method: http.MethodGet,
+		Path:   "/flaky",
+		Responses: []*RouteResponse{
+			{RawResponseBody: "down", ResponseStatusCode: http.StatusInternalServerError},
+			{RawResponseBody: "busy", ResponseStatusCode: http.StatusServiceUnavailable},
+			{ResponseBody: map[string]any{"status": "ok"}, ResponseStatusCode: http.StatusOK},
+		},
+	}
+	require.NoError(t, p.Register(route), "error registering route")
+
+	for _, expected := range []int{http.StatusInternalServerError, http.StatusServiceUnavailable, http.StatusOK, http.StatusOK} {
+		resp, err := p.Call(route.Method, route.Path)
+		require.NoError(t, err, "error calling parrot")
+		assert.Equal(t, expected, resp.StatusCode())
+	}
+
+	// registering the route again starts the sequence over
+	require.NoError(t, p.Register(route), "error registering route")
+	resp, err := p.Call(route.Method, route.Path)
+	require.NoError(t, err, "error calling parrot")
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode())
+}
+
+func TestRouteMaxCalls(t *testing.T) {
+	t.Parallel()
+
+	p := newParrot(t)
+
+	limited := &Route{
+		Method:             http.MethodGet,
+		Path:               "/limited",
+		RawResponseBody:    "first",
+		ResponseStatusCode: http.StatusOK,
+		MaxCalls:           2,
+	}
+	require.NoError(t, p.Register(limited), "error registering route")
+
+	for i := 0; i < 2; i++ {
+		resp, err := p.Call(limited.Method, limited.Path)
+		require.NoError(t, err, "error calling parrot")
+		assert.Equal(t, "first", string(resp.Body()))
+	}
+	resp, err := p.Call(limited.Method, limited.Path)
+	require.NoError(t, err, "error calling parrot")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode(), "exhausted route should not respond")
+
+	// a route with a matcher can take the first calls before falling back to the default one
+	retry := &Route{
+		Method:             http.MethodGet,
+		Path:               "/retry",
+		RawResponseBody:    "error",
+		ResponseStatusCode: http.StatusBadGateway,
+		MaxCalls:           1,
+		Match:              &RequestMatcher{Headers: map[string]ValueMatcher{"X-Request-Id": {Regex: ".*"}}},
+	}
+	fallback := &Route{
+		Method:             http.MethodGet,
+		Path:               "/retry",
+		RawResponseBody:    "ok",
+		ResponseStatusCode: http.StatusOK,
+	}
+	require.NoError(t, p.Register(fallback), "error registering route")
+	require.NoError(t, p.Register(retry), "error registering route")
+
+	client := resty.New().SetBaseURL("http://" + p.Address())
+	resp, err = client.R().SetHeader("X-Request-Id", "1").Get("/retry")
+	require.NoError(t, err, "error calling parrot")
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode())
+	resp, err = client.R().SetHeader("X-Request-Id", "1").Get("/retry")
+	require.NoError(t, err, "error calling parrot")
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+
+	p.Delete(retry)
+	require.Len(t, p.Routes(), 2)
+	resp, err = p.Call(fallback.Method, fallback.Path)
+	require.NoError(t, err, "error calling parrot")
+	assert.Equal(t, "ok", string(resp.Body()), "deleting a route should keep the others with the same path")
+}
+
+func TestSlowBodyDoesNotBlockRouting(t *testing.T) {
+	t.Parallel()
+
+	p := newParrot(t)
+
+	bodyRoute := &Route{
+		Method:             http.MethodPost,
+		Path:               "/upload",
+		RawResponseBody:    "uploaded",
+		ResponseStatusCode: http.StatusOK,
+		Match:              &RequestMatcher{Body: []BodyMatcher{{JSONPath: "$.id", ValueMatcher: ValueMatcher{Equals: "1"}}}},
+	}
+	fastRoute := &Route{
+		Method:             http.MethodGet,
+		Path:               "/fast",
+		RawResponseBody:    "fast",
+		ResponseStatusCode: http.StatusOK,
+	}
+	require.NoError(t, p.Register(bodyRoute), "error registering route")
+	require.NoError(t, p.Register(fastRoute), "error registering route")
+
+	// the body is still being uploaded while the route is matched
+	body, uploader := io.Pipe()
+	matched := make(chan *routeState, 1)
+	go func() {
+		state, _ := p.matchRoute(bodyRoute.Method+":"+bodyRoute.Path, httptest.NewRequest(bodyRoute.Method, bodyRoute.Path, body))
+		matched <- state
+	}()
+	_, err := uploader.Write([]byte(`{"id":`))
+	require.NoError(t, err)
+
+	fastMatched := make(chan *routeState, 1)
+	go func() {
+		state, _ := p.matchRoute(fastRoute.Method+":"+fastRoute.Path, httptest.NewRequest(fastRoute.Method, fastRoute.Path, nil))
+		fastMatched <- state
+	}()
+	select {
+	case state := <-fastMatched:
+		require.NotNil(t, state)
+		assert.Equal(t, fastRoute.ID(), state.route.ID())
+	case <-time.After(5 * time.Second):
+		t.Fatal("other routes should be matched while the body is uploaded")
+	}
+
+	_, err = uploader.Write([]byte(`"1"}`))
+	require.NoError(t, err)
+	require.NoError(t, uploader.Close())
+	state := <-matched
+	require.NotNil(t, state)
+	assert.Equal(t, bodyRoute.ID(), state.route.ID())
+}
+
+func TestBadRouteMatchers(t *testing.T) {
+	t.Parallel()
+
+	p := newParrot(t)
+
+	testCases := []struct {
+		name  string
+		err   error
+		route *Route
+	}{
+		{
+			name: "invalid regex",
+			err:  ErrInvalidMatcher,
+			route: &Route{
+				Method:          http.MethodGet,
+				Path:            "/hello",
+				RawResponseBody: "Squawk",
+				Match:           &RequestMatcher{Query: map[string]ValueMatcher{"q": {Regex: "("}}},
+			},
+		},
+		{
+			name: "empty value matcher",
+			err:  ErrInvalidMatcher,
+			route: &Route{
+				Method:          http.MethodGet,
+				Path:            "/hello",
+				RawResponseBody: "Squawk",
+				Match:           &RequestMatcher{Body: []BodyMatcher{{JSONPath: "$.id"}}},
+			},
+		},
+		{
+			name: "equals and regex",
+			err:  ErrInvalidMatcher,
+			route: &Route{
+				Method:          http.MethodGet,
+				Path:            "/hello",
+				RawResponseBody: "Squawk",
+				Match:           &RequestMatcher{Headers: map[string]ValueMatcher{"A": {Equals: "a", Regex: "a"}}},
+			},
+		},
+		{
+			name: "responses and a single response",
+			err:  ErrOnlyOneResponse,
+			route: &Route{
+				Method:          http.MethodGet,
+				Path:            "/hello",
+				RawResponseBody: "Squawk",
+				Responses:       []*RouteResponse{{RawResponseBody: "Squawk"}},
+			},
+		},
+		{
+			name: "empty response in sequence",
+			err:  ErrNoResponse,
+			route: &Route{
+				Method:    http.MethodGet,
+				Path:      "/hello",
+				Responses: []*RouteResponse{{RawResponseBody: "Squawk"}, {ResponseStatusCode: http.StatusOK}},
+			},
+		},
+		{
+			name: "negative max calls",
+			err:  ErrInvalidMaxCalls,
+			route: &Route{
+				Method:          http.MethodGet,
+				Path:            "/hello",
+				RawResponseBody: "Squawk",
+				MaxCalls:        -1,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := p.Register(tc.route)
+			require.Error(t, err, "expected error registering route")
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package parrot
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -26,9 +27,11 @@ import (
 )
 
 const (
-	HealthRoute   = "/health"
-	RoutesRoute   = "/routes"
-	RecorderRoute = "/recorder"
+	HealthRoute        = "/health"
+	RoutesRoute        = "/routes"
+	RecorderRoute      = "/recorder"
+	JournalRoute       = "/journal"
+	JournalVerifyRoute = JournalRoute + "/verify"
 
 	// MethodAny is a wildcard for any HTTP method
 	MethodAny = "ANY"
@@ -60,13 +63,51 @@ type Route struct {
 	ResponseBody any `json:"response_body"`
 	// ResponseStatusCode is the HTTP status code to return when called
 	ResponseStatusCode int `json:"response_status_code"`
-}
-
-// ID returns the unique identifier for the route
+	// Responses are returned one by one on consecutive calls instead of the single response above, the last one repeats
+	Responses []*RouteResponse `json:"responses,omitempty"`
+	// Match restricts the route to matching requests. Routes with the same method and path are tried in registration order,
+	// a route without Match responds only if none of them matches
+	Match *RequestMatcher `json:"match,omitempty"`
+	// MaxCalls is the number of calls the route responds to, 0 is unlimited. Later calls are left to other routes
+	MaxCalls int `json:"max_calls,omitempty"`
+	// Fault simulates a misbehaving upstream, ex.: latency, error statuses or dropped connections
+	Fault *RouteFault `json:"fault,omitempty"`
+	// Template renders the raw response body and string values of the JSON response body as Go text/template
+	// with TemplateData, ex.: {"id": "{{ .Body.id }}"}
+	Template bool `json:"template,omitempty"`
+}
+
+// ID returns the unique identifier for the route, routes with a matcher have its hash appended, ex.: "GET:/price#1a2b3c4d"
 func (r *Route) ID() string {
+	if r.Match != nil {
+		return r.Method + ":" + r.Path + "#" + matchID(r.Match)
+	}
 	return r.Method + ":" + r.Path
 }
 
+// response returns the response to the call with the given number, starting from 1
+func (r *Route) response(call int) *RouteResponse {
+	if len(r.Responses) == 0 {
+		return &RouteResponse{
+			RawResponseBody:    r.RawResponseBody,
+			ResponseBody:       r.ResponseBody,
+			ResponseStatusCode: r.ResponseStatusCode,
+		}
+	}
+	if call > len(r.Responses) {
+		return r.Responses[len(r.Responses)-1]
+	}
+	return r.Responses[call-1]
+}
+
+// routeState is a registered route with its validated matcher, parsed templates and the number of calls it responded to
+type routeState struct {
+	route     *Route
+	matcher   *requestMatcher
+	templates responseTemplates
+	calls     int
+}
+
 // Server is a mock HTTP server that can register and respond to dynamic routes
 type Server struct {
 	port    int
@@ -77,10 +118,16 @@ type Server struct {
 	server *http.Server
 	client *resty.Client
 
-	routes        map[string]*Route // Store routes for saving and retrieving
+	routes        map[string]*Route        // Store routes for saving and retrieving
+	routeGroups   map[string][]*routeState // Routes sharing the same method and path, in registration order
 	routesMu      sync.RWMutex
 	recorderHooks map[string]struct{} // Store recorders based on URL keys to avoid duplicates
 	recordersMu   sync.RWMutex
+	proxy         *proxy       // Forwards unmatched requests upstream if set
+	journal       []*RouteCall // Latest route calls for verification, a ring buffer once it's full
+	journalHead   int          // Index of the oldest call in a full journal
+	journalSize   int
+	journalMu     sync.RWMutex
 
 	// Save and shutdown
 	shutDown     atomic.Bool
@@ -110,10 +157,12 @@ func NewServer(options ...ServerOption) (*Server, error) {
 		saveFileName: "parrot_save.json",
 		logLevel:     zerolog.InfoLevel,
 		logFileName:  "parrot.log",
+		journalSize:  DefaultJournalSize,
 
-		routes: make(map[string]*Route),
-		router: chi.NewRouter(),
-		client: resty.New(),
+		routes:      make(map[string]*Route),
+		routeGroups: make(map[string][]*routeState),
+		router:      chi.NewRouter(),
+		client:      resty.New(),
 
 		shutDownChan: make(chan struct{}),
 
@@ -183,6 +232,16 @@ func NewServer(options ...ServerOption) (*Server, error) {
 	p.router.Get(RecorderRoute, p.recorderHandlerGET)
 	p.router.Post(RecorderRoute, p.recorderHandlerPOST)
 
+	p.router.Get(JournalRoute, p.journalHandlerGET)
+	p.router.Delete(JournalRoute, p.journalHandlerDELETE)
+	p.router.Post(JournalVerifyRoute, p.journalVerifyHandlerPOST)
+
+	if p.proxy != nil {
+		proxyHandler := routeRecordingMiddleware(p, http.HandlerFunc(p.proxyHandler))
+		p.router.NotFound(proxyHandler)
+		p.router.MethodNotAllowed(proxyHandler)
+	}
+
 	p.server = &http.Server{
 		ReadHeaderTimeout: 5 * time.Second,
 		Addr:              listener.Addr().String(),
@@ -236,37 +295,109 @@ func (p *Server) run(listener net.Listener) {
 	}
 }
 
-// routeCallHandler handles incoming requests to the parrot server routes
-func (p *Server) routeCallHandler(route *Route) http.HandlerFunc {
+// routeCallHandler handles incoming requests to the parrot server routes registered with the same method and path
+func (p *Server) routeCallHandler(groupKey string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		routeCallLogger := zerolog.Ctx(r.Context())
+
+		state, call := p.matchRoute(groupKey, r)
+		if state == nil {
+			if p.proxy != nil {
+				p.proxyHandler(w, r)
+				return
+			}
+			routeCallLogger.Debug().Msg("No route matches the request")
+			http.Error(w, ErrNoMatchingRoute.Error(), http.StatusNotFound)
+			return
+		}
 		routeCallLogger.UpdateContext(func(c zerolog.Context) zerolog.Context {
-			return c.Str("Route ID", route.ID())
+			return c.Str("Route ID", state.route.ID())
 		})
+		setRouteCallID(r, state.route.ID())
+
+		response := state.route.response(call)
+		if state.templates != nil {
+			var err error
+			response, err = state.templates.renderResponse(response, newTemplateData(r, call))
+			if err != nil {
+				routeCallLogger.Error().Err(err).Msg("Failed to render response template")
+				http.Error(w, fmt.Sprintf("Failed to render response template: %s", err.Error()), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		statusCode := response.ResponseStatusCode
+		if statusCode == 0 {
+			statusCode = http.StatusOK
+		}
 
-		if route.RawResponseBody != "" {
-			w.Header().Set("Content-Type", "text/plain")
-			w.WriteHeader(route.ResponseStatusCode)
-			if _, err := w.Write([]byte(route.RawResponseBody)); err != nil {
+		var (
+			contentType string
+			body        []byte
+		)
+		switch {
+		case response.RawResponseBody != "":
+			contentType = "text/plain"
+			body = []byte(response.RawResponseBody)
+		case response.ResponseBody != nil:
+			contentType = "application/json"
+			var b bytes.Buffer
+			if err := json.NewEncoder(&b).Encode(response.ResponseBody); err != nil {
 				routeCallLogger.Error().Err(err).Msg("Failed to write response")
 				http.Error(w, "Failed to write response", http.StatusInternalServerError)
+				return
 			}
+			body = b.Bytes()
+		default:
+			routeCallLogger.Error().Msg("No response provided")
+			http.Error(w, "No response provided", http.StatusInternalServerError)
 			return
 		}
 
-		if route.ResponseBody != nil {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(route.ResponseStatusCode)
-			if err := json.NewEncoder(w).Encode(route.ResponseBody); err != nil {
-				routeCallLogger.Error().Err(err).Msg("Failed to write response")
-				http.Error(w, "Failed to write response", http.StatusInternalServerError)
+		if state.route.Fault != nil {
+			if err := state.route.Fault.inject(w, r, statusCode, contentType, body); err != nil {
+				routeCallLogger.Debug().Err(err).Msg("Injected fault")
 			}
 			return
 		}
 
-		routeCallLogger.Error().Msg("No response provided")
-		http.Error(w, "No response provided", http.StatusInternalServerError)
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(statusCode)
+		if _, err := w.Write(body); err != nil {
+			routeCallLogger.Error().Err(err).Msg("Failed to write response")
+		}
+	}
+}
+
+// matchRoute finds the route responding to the request and counts the call, routes with a matcher are tried first.
+// It returns the route state and the call number, starting from 1
+func (p *Server) matchRoute(groupKey string, r *http.Request) (*routeState, int) {
+	// body matchers read the body under the lock, it's buffered first so a slow upload doesn't block other routes
+	if _, err := bufferBody(r); err != nil {
+		zerolog.Ctx(r.Context()).Debug().Err(err).Msg("Failed to read request body")
+	}
+	p.routesMu.Lock()
+	defer p.routesMu.Unlock()
+
+	var fallback *routeState
+	for _, state := range p.routeGroups[groupKey] {
+		if state.route.MaxCalls > 0 && state.calls >= state.route.MaxCalls {
+			continue
+		}
+		if state.matcher == nil {
+			fallback = state
+			continue
+		}
+		if state.matcher.match(r) {
+			state.calls++
+			return state, state.calls
+		}
+	}
+	if fallback == nil {
+		return nil, 0
 	}
+	fallback.calls++
+	return fallback, fallback.calls
 }
 
 // Healthy checks if the parrot server is healthy
@@ -364,15 +495,37 @@ func (p *Server) Register(route *Route) error {
 	if !isValidMethod(route.Method) {
 		return newDynamicError(ErrInvalidMethod, fmt.Sprintf("'%s'", route.Method))
 	}
-	if route.ResponseBody == nil && route.RawResponseBody == "" {
-		return ErrNoResponse
+	if len(route.Responses) == 0 {
+		if err := validateResponse(route.RawResponseBody, route.ResponseBody); err != nil {
+			return err
+		}
+	} else if route.ResponseBody != nil || route.RawResponseBody != "" {
+		return newDynamicError(ErrOnlyOneResponse, "use either responses or a single response")
 	}
-	if route.ResponseBody != nil && route.RawResponseBody != "" {
-		return ErrOnlyOneResponse
+	for i, response := range route.Responses {
+		if response == nil {
+			return newDynamicError(ErrNoResponse, fmt.Sprintf("response %d", i))
+		}
+		if err := validateResponse(response.RawResponseBody, response.ResponseBody); err != nil {
+			return fmt.Errorf("response %d: %w", i, err)
+		}
+	}
+	if route.Fault != nil {
+		if err := route.Fault.validate(); err != nil {
+			return err
+		}
+	}
+	if route.MaxCalls < 0 {
+		return newDynamicError(ErrInvalidMaxCalls, fmt.Sprintf("%d", route.MaxCalls))
 	}
-	if route.ResponseBody != nil {
-		if _, err := json.Marshal(route.ResponseBody); err != nil {
-			return newDynamicError(ErrResponseMarshal, err.Error())
+	matcher, err := newRequestMatcher(route.Match)
+	if err != nil {
+		return err
+	}
+	var templates responseTemplates
+	if route.Template {
+		if templates, err = parseResponseTemplates(route); err != nil {
+			return err
 		}
 	}
 	numWildcards := strings.Count(route.Path, "*")
@@ -383,15 +536,29 @@ func (p *Server) Register(route *Route) error {
 		return newDynamicError(ErrWildcardPath, fmt.Sprintf("wildcard not at end '%s'", route.Path))
 	}
 
+	groupKey := route.Method + ":" + route.Path
 	if route.Method == MethodAny {
-		p.router.Handle(route.Path, routeRecordingMiddleware(p, p.routeCallHandler(route)))
+		p.router.Handle(route.Path, routeRecordingMiddleware(p, p.routeCallHandler(groupKey)))
 	} else {
-		p.router.MethodFunc(route.Method, route.Path, routeRecordingMiddleware(p, p.routeCallHandler(route)))
+		p.router.MethodFunc(route.Method, route.Path, routeRecordingMiddleware(p, p.routeCallHandler(groupKey)))
 	}
 
 	p.routesMu.Lock()
 	defer p.routesMu.Unlock()
 	p.routes[route.ID()] = route
+	state := &routeState{route: route, matcher: matcher, templates: templates}
+	group := p.routeGroups[groupKey]
+	replaced := false
+	for i, existing := range group {
+		if existing.route.ID() == route.ID() {
+			group[i] = state
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		p.routeGroups[groupKey] = append(group, state)
+	}
 	p.log.Info().
 		Str("Route ID", route.ID()).
 		Msg("Registered route")
@@ -517,15 +684,36 @@ func (p *Server) recorderHandlerGET(w http.ResponseWriter, r *http.Request) {
 
 // Delete removes a route from the parrot
 func (p *Server) Delete(route *Route) {
-	p.router.Method(route.Method, route.Path, http.NotFoundHandler())
 	p.routesMu.Lock()
 	defer p.routesMu.Unlock()
+	groupKey := route.Method + ":" + route.Path
+	group := p.routeGroups[groupKey]
+	for i, existing := range group {
+		if existing.route.ID() == route.ID() {
+			group = append(group[:i:i], group[i+1:]...)
+			break
+		}
+	}
+	if len(group) == 0 {
+		delete(p.routeGroups, groupKey)
+		p.router.Method(route.Method, route.Path, p.notFoundHandler())
+	} else {
+		p.routeGroups[groupKey] = group
+	}
 	delete(p.routes, route.ID())
 	p.log.Info().
 		Str("Route ID", route.ID()).
 		Msg("Route deleted")
 }
 
+// notFoundHandler handles requests to deleted routes, they are proxied if a proxy is set
+func (p *Server) notFoundHandler() http.Handler {
+	if p.proxy != nil {
+		return routeRecordingMiddleware(p, http.HandlerFunc(p.proxyHandler))
+	}
+	return http.NotFoundHandler()
+}
+
 // routesHandlerDELETE handles deleting a route
 // DELETE /routes
 func (p *Server) routesHandlerDELETE(w http.ResponseWriter, r *http.Request) {
@@ -713,7 +901,10 @@ func (p *Server) loggingMiddleware(next http.Handler) http.Handler {
 	return h(accessHandler(next))
 }
 
-var pathRegex = regexp.MustCompile(`^\/[a-zA-Z0-9\-._~%!$&'()*+,;=:@\/]*$`)
+var (
+	pathRegex      = regexp.MustCompile(`^\/[a-zA-Z0-9\-._~%!$&'()*+,;=:@\/]*$`)
+	pathParamRegex = regexp.MustCompile(`^\{[a-zA-Z_][a-zA-Z0-9_]*\}$`)
+)
 
 // isValidPath checks if the path is a valid URL path
 func isValidPath(path string) bool {
@@ -735,7 +926,26 @@ func isValidPath(path string) bool {
 	if strings.HasPrefix(path, RoutesRoute) {
 		return false
 	}
-	return pathRegex.MatchString(path)
+	if strings.HasPrefix(path, JournalRoute) {
+		return false
+	}
+	// path params are whole segments with unique names, ex.: /users/{id}
+	params := map[string]struct{}{}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if !strings.ContainsAny(segment, "{}") {
+			continue
+		}
+		if !pathParamRegex.MatchString(segment) {
+			return false
+		}
+		if _, ok := params[segment]; ok {
+			return false
+		}
+		params[segment] = struct{}{}
+		segments[i] = "param"
+	}
+	return pathRegex.MatchString(strings.Join(segments, "/"))
 }
 
 // isValidMethod checks if the method is a valid HTTP method, in loose terms
@@ -97,3 +97,26 @@ func WithRecorders(recorders ...string) ServerOption {
 		return nil
 	}
 }
+
+// WithProxy forwards requests that no route responds to to an upstream and optionally records them as routes
+func WithProxy(cfg *ProxyConfig) ServerOption {
+	return func(s *Server) error {
+		px, err := newProxy(cfg)
+		if err != nil {
+			return err
+		}
+		s.proxy = px
+		return nil
+	}
+}
+
+// WithJournalSize sets the number of the latest route calls kept for verification, defaults to DefaultJournalSize
+func WithJournalSize(size int) ServerOption {
+	return func(s *Server) error {
+		if size <= 0 {
+			return newDynamicError(ErrInvalidJournalSize, fmt.Sprintf("%d", size))
+		}
+		s.journalSize = size
+		return nil
+	}
+}
@@ -156,6 +156,50 @@ func TestRegisterRoutes(t *testing.T) {
 	}
 }
 
+func TestRouteResponseHeaders(t *testing.T) {
+	t.Parallel()
+
+	p := newParrot(t)
+
+	testCases := []struct {
+		name                string
+		route               *Route
+		expectedContentType string
+	}{
+		{
+			name: "raw body",
+			route: &Route{
+				Method:             http.MethodGet,
+				Path:               "/raw",
+				RawResponseBody:    `{"message": "Squawk"}`,
+				ResponseStatusCode: http.StatusOK,
+			},
+			expectedContentType: "text/plain",
+		},
+		{
+			name: "json body",
+			route: &Route{
+				Method:             http.MethodGet,
+				Path:               "/json",
+				ResponseBody:       map[string]any{"message": "Squawk"},
+				ResponseStatusCode: http.StatusCreated,
+			},
+			expectedContentType: "application/json",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, p.Register(tc.route), "error registering route")
+
+			resp, err := p.Call(tc.route.Method, tc.route.Path)
+			require.NoError(t, err, "error calling parrot")
+			assert.Equal(t, tc.route.ResponseStatusCode, resp.StatusCode())
+			assert.Equal(t, tc.expectedContentType, resp.Header().Get("Content-Type"), "headers set by the route should be sent to the client")
+		})
+	}
+}
+
 func TestGetRoutes(t *testing.T) {
 	t.Parallel()
 
@@ -198,6 +242,15 @@ func TestIsValidPath(t *testing.T) {
 			paths: []string{"/hello"},
 			valid: true,
 		},
+		{
+			name:  "path params",
+			paths: []string{"/users/{id}", "/users/{id}/orders/{order_id}", "/{name}/*"},
+			valid: true,
+		},
+		{
+			name:  "invalid path params",
+			paths: []string{"/users/{id", "/users/id}", "/users/{}", "/users/{id}{name}", "/users/x{id}", "/{id}/{id}", "/{1id}"},
+		},
 		{
 			name:  "no protected paths",
 			paths: []string{HealthRoute, RoutesRoute, RecorderRoute, fmt.Sprintf("%s/%s", RoutesRoute, "route-id"), fmt.Sprintf("%s/%s", HealthRoute, "recorder-id"), fmt.Sprintf("%s/%s", RecorderRoute, "recorder-id")},
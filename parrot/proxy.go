@@ -0,0 +1,286 @@
+package parrot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// RedactedValue replaces redacted values in recorded routes
+	RedactedValue = "[REDACTED]"
+	// DefaultProxyTimeout is the timeout of requests to the upstream
+	DefaultProxyTimeout = 30 * time.Second
+)
+
+// hopHeaders are connection specific headers that are not forwarded
+var hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// ProxyConfig forwards requests that no route responds to to an upstream, ex.: to capture a real API once and replay it offline
+type ProxyConfig struct {
+	// Upstream is the base URL requests are forwarded to, ex.: "https://api.example.com/v1"
+	Upstream string
+	// Record registers every proxied request with its response as a route, so the next matching request is served by parrot.
+	// Recorded routes match the query and the JSON body of the request and are saved like any other route
+	Record bool
+	// MatchHeaders are request headers recorded routes should match exactly, ex.: "X-Api-Version"
+	MatchHeaders []string
+	// RedactHeaders are request headers recorded routes require without saving their values, ex.: "Authorization"
+	RedactHeaders []string
+	// RedactJSONPaths are values of JSON request and response bodies replaced with RedactedValue in recorded routes,
+	// request body values under them are not matched, ex.: "$.api_key"
+	RedactJSONPaths []string
+	// Timeout of requests to the upstream, defaults to DefaultProxyTimeout
+	Timeout time.Duration
+}
+
+// proxy forwards requests to the upstream and records them as routes
+type proxy struct {
+	cfg      *ProxyConfig
+	upstream *url.URL
+	client   *http.Client
+}
+
+func newProxy(cfg *ProxyConfig) (*proxy, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("proxy config is nil")
+	}
+	upstream, err := url.Parse(cfg.Upstream)
+	if err != nil || upstream.Scheme == "" || upstream.Host == "" {
+		return nil, fmt.Errorf("invalid proxy upstream URL '%s'", cfg.Upstream)
+	}
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = DefaultProxyTimeout
+	}
+	return &proxy{
+		cfg:      cfg,
+		upstream: upstream,
+		client:   &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// proxyHandler forwards the request to the upstream and records it as a route if recording is enabled
+func (p *Server) proxyHandler(w http.ResponseWriter, r *http.Request) {
+	proxyLogger := zerolog.Ctx(r.Context())
+
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		proxyLogger.Error().Err(err).Msg("Failed to read request body")
+		http.Error(w, "Failed to read request body", http.StatusInternalServerError)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewBuffer(reqBody))
+
+	target := p.proxy.upstream.JoinPath(r.URL.Path)
+	target.RawQuery = r.URL.RawQuery
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, target.String(), bytes.NewReader(reqBody))
+	if err != nil {
+		proxyLogger.Error().Err(err).Msg("Failed to create upstream request")
+		http.Error(w, "Failed to create upstream request", http.StatusInternalServerError)
+		return
+	}
+	req.Header = r.Header.Clone()
+	for _, h := range hopHeaders {
+		req.Header.Del(h)
+	}
+	// let the transport decompress responses, so recorded bodies are readable
+	req.Header.Del("Accept-Encoding")
+
+	resp, err := p.proxy.client.Do(req)
+	if err != nil {
+		proxyLogger.Error().Err(err).Str("Upstream", target.String()).Msg("Failed to call upstream")
+		http.Error(w, fmt.Sprintf("Failed to call upstream: %s", err.Error()), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		proxyLogger.Error().Err(err).Str("Upstream", target.String()).Msg("Failed to read upstream response")
+		http.Error(w, fmt.Sprintf("Failed to read upstream response: %s", err.Error()), http.StatusBadGateway)
+		return
+	}
+
+	for k, values := range resp.Header {
+		for _, v := range values {
+			w.Header().Add(k, v)
+		}
+	}
+	for _, h := range hopHeaders {
+		w.Header().Del(h)
+	}
+	w.Header().Del("Content-Length")
+	w.WriteHeader(resp.StatusCode)
+	if _, err := w.Write(respBody); err != nil {
+		proxyLogger.Error().Err(err).Msg("Failed to write response")
+	}
+	proxyLogger.Debug().Str("Upstream", target.String()).Int("Status Code", resp.StatusCode).Msg("Proxied request")
+
+	if !p.proxy.cfg.Record {
+		return
+	}
+	route, err := p.proxy.recordRoute(r, reqBody, resp, respBody)
+	if err == nil {
+		err = p.Register(route)
+	}
+	if err != nil {
+		proxyLogger.Warn().Err(err).Str("Upstream", target.String()).Msg("Failed to record route")
+		return
+	}
+	proxyLogger.Info().Str("Route ID", route.ID()).Msg("Recorded route")
+}
+
+// recordRoute creates a route replaying the response to requests like the recorded one
+func (px *proxy) recordRoute(r *http.Request, reqBody []byte, resp *http.Response, respBody []byte) (*Route, error) {
+	route := &Route{
+		Method:             r.Method,
+		Path:               r.URL.Path,
+		ResponseStatusCode: resp.StatusCode,
+	}
+
+	match := &RequestMatcher{}
+	query := r.URL.Query()
+	if len(query) > 0 {
+		match.Query = make(map[string]ValueMatcher, len(query))
+		for k, v := range query {
+			match.Query[k] = exactValueMatcher(v[0])
+		}
+	}
+	for _, h := range px.cfg.MatchHeaders {
+		if v := r.Header.Get(h); v != "" {
+			if match.Headers == nil {
+				match.Headers = map[string]ValueMatcher{}
+			}
+			match.Headers[h] = exactValueMatcher(v)
+		}
+	}
+	for _, h := range px.cfg.RedactHeaders {
+		if r.Header.Get(h) != "" {
+			if match.Headers == nil {
+				match.Headers = map[string]ValueMatcher{}
+			}
+			match.Headers[h] = ValueMatcher{Regex: ".+"}
+		}
+	}
+	var body any
+	if len(reqBody) > 0 && json.Unmarshal(reqBody, &body) == nil && body != nil {
+		if redacted := px.redact(body); redacted {
+			// exact body match would require the secret, so only values outside of redacted paths are matched
+			match.Body = bodyLeafMatchers(body, px.cfg.RedactJSONPaths)
+		} else {
+			match.JSONBody = body
+		}
+	}
+	if match.Query != nil || match.Headers != nil || match.JSONBody != nil || len(match.Body) > 0 {
+		route.Match = match
+	}
+
+	if len(respBody) == 0 {
+		return nil, fmt.Errorf("upstream responded with an empty body, it can't be replayed")
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	var respJSON any
+	if strings.HasSuffix(mediaType, "json") && json.Unmarshal(respBody, &respJSON) == nil && respJSON != nil {
+		px.redact(respJSON)
+		route.ResponseBody = respJSON
+	} else {
+		route.RawResponseBody = string(respBody)
+	}
+	return route, nil
+}
+
+// redact replaces values of decoded JSON at redacted paths and reports if any of them was replaced
+func (px *proxy) redact(v any) bool {
+	redacted := false
+	for _, path := range px.cfg.RedactJSONPaths {
+		if jsonPathSet(v, path, RedactedValue) {
+			redacted = true
+		}
+	}
+	return redacted
+}
+
+// jsonPathSet replaces an existing value of decoded JSON by a dot separated path
+func jsonPathSet(v any, path, value string) bool {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return false
+	}
+	elements := strings.Split(path, ".")
+	parent, ok := jsonPathLookup(v, strings.Join(elements[:len(elements)-1], "."))
+	if !ok {
+		return false
+	}
+	last := elements[len(elements)-1]
+	switch cur := parent.(type) {
+	case map[string]any:
+		if _, ok := cur[last]; ok {
+			cur[last] = value
+			return true
+		}
+	case []any:
+		if i, err := strconv.Atoi(last); err == nil && i >= 0 && i < len(cur) {
+			cur[i] = value
+			return true
+		}
+	}
+	return false
+}
+
+// bodyLeafMatchers matches every scalar value of decoded JSON exactly, except values under the skipped paths
+func bodyLeafMatchers(v any, skip []string) []BodyMatcher {
+	skipped := make(map[string]struct{}, len(skip))
+	for _, s := range skip {
+		skipped["$."+strings.TrimPrefix(strings.TrimPrefix(s, "$"), ".")] = struct{}{}
+	}
+	var matchers []BodyMatcher
+	var walk func(v any, path string)
+	walk = func(v any, path string) {
+		if _, ok := skipped[path]; ok {
+			return
+		}
+		switch cur := v.(type) {
+		case map[string]any:
+			for k, item := range cur {
+				walk(item, path+"."+k)
+			}
+		case []any:
+			for i, item := range cur {
+				walk(item, fmt.Sprintf("%s.%d", path, i))
+			}
+		default:
+			matchers = append(matchers, BodyMatcher{JSONPath: path, ValueMatcher: exactValueMatcher(jsonValueString(cur))})
+		}
+	}
+	walk(v, "$")
+	sort.Slice(matchers, func(i, j int) bool { return matchers[i].JSONPath < matchers[j].JSONPath })
+	return matchers
+}
+
+// exactValueMatcher matches the value exactly, including an empty one
+func exactValueMatcher(v string) ValueMatcher {
+	if v == "" {
+		return ValueMatcher{Regex: "^$"}
+	}
+	return ValueMatcher{Equals: v}
+}
@@ -0,0 +1,158 @@
+package parrot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newUpstream responds with the request query, body and authorization and counts calls
+func newUpstream(t *testing.T, calls *atomic.Int32) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		var body any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if r.URL.Path == "/text" {
+			_, _ = w.Write([]byte("plain " + r.URL.Query().Get("q")))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Upstream", "yes")
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"path":    r.URL.Path,
+			"asset":   r.URL.Query().Get("asset"),
+			"request": body,
+			"token":   r.Header.Get("Authorization"),
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newProxyParrot(t *testing.T, cfg *ProxyConfig) *Server {
+	saveFile, logFile := t.Name()+".json", t.Name()+".log"
+	p, err := NewServer(WithSaveFile(saveFile), WithLogFile(logFile), WithLogLevel(testLogLevel), WithProxy(cfg))
+	require.NoError(t, err, "error waking parrot")
+	t.Cleanup(func() {
+		err := p.Shutdown(context.Background())
+		assert.NoError(t, err, "error shutting down parrot")
+		p.WaitShutdown()
+		os.Remove(saveFile)
+		os.Remove(logFile)
+	})
+	return p
+}
+
+func TestProxy(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	upstream := newUpstream(t, &calls)
+	p := newProxyParrot(t, &ProxyConfig{Upstream: upstream.URL + "/api"})
+	client := resty.New().SetBaseURL("http://" + p.Address())
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.R().SetQueryParam("asset", "ETH").Get("/price")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode())
+		assert.Equal(t, "yes", resp.Header().Get("X-Upstream"))
+		assert.Contains(t, string(resp.Body()), `"path":"/api/price"`)
+		assert.Contains(t, string(resp.Body()), `"asset":"ETH"`)
+	}
+	assert.Equal(t, int32(2), calls.Load(), "requests should be forwarded every time without recording")
+	assert.Empty(t, p.Routes())
+
+	// registered routes are served by parrot
+	require.NoError(t, p.Register(&Route{Method: http.MethodGet, Path: "/price", RawResponseBody: "mocked", ResponseStatusCode: http.StatusOK}))
+	resp, err := client.R().Get("/price")
+	require.NoError(t, err)
+	assert.Equal(t, "mocked", string(resp.Body()))
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestProxyRecord(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	upstream := newUpstream(t, &calls)
+	p := newProxyParrot(t, &ProxyConfig{
+		Upstream:        upstream.URL,
+		Record:          true,
+		RedactHeaders:   []string{"Authorization"},
+		RedactJSONPaths: []string{"$.api_key", "$.token", "$.request.api_key"},
+	})
+	client := resty.New().SetBaseURL("http://" + p.Address())
+
+	// the first call is recorded, the second one is replayed
+	for i := 0; i < 2; i++ {
+		resp, err := client.R().SetQueryParam("asset", "ETH").Get("/price")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode())
+		assert.Contains(t, string(resp.Body()), `"asset":"ETH"`)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+
+	// a different query is forwarded and recorded separately
+	resp, err := client.R().SetQueryParam("asset", "BTC").Get("/price")
+	require.NoError(t, err)
+	assert.Contains(t, string(resp.Body()), `"asset":"BTC"`)
+	assert.Equal(t, int32(2), calls.Load())
+
+	// secrets are not recorded, but replayed requests still have to send them
+	for _, key := range []string{"secret-1", "secret-2"} {
+		resp, err = client.R().
+			SetHeader("Authorization", "Bearer "+key).
+			SetBody(`{"pair": "LINK/USD", "api_key": "` + key + `"}`).
+			Post("/convert")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode())
+	}
+	assert.Equal(t, int32(3), calls.Load(), "request with a different secret should be replayed")
+	assert.Contains(t, string(resp.Body()), RedactedValue)
+
+	resp, err = client.R().SetBody(`{"pair": "LINK/ETH", "api_key": "secret-1"}`).SetHeader("Authorization", "Bearer x").Post("/convert")
+	require.NoError(t, err)
+	assert.Contains(t, string(resp.Body()), "LINK/ETH", "request with a different body should be forwarded")
+	assert.Equal(t, int32(4), calls.Load())
+
+	resp, err = client.R().SetQueryParam("q", "hello").Get("/text")
+	require.NoError(t, err)
+	assert.Equal(t, "plain hello", string(resp.Body()))
+
+	routes := p.Routes()
+	require.Len(t, routes, 5)
+	require.NoError(t, p.save())
+	saved, err := os.ReadFile(t.Name() + ".json")
+	require.NoError(t, err)
+	assert.Contains(t, string(saved), `"Path":"/convert"`, "recorded routes should be saved")
+	assert.NotContains(t, string(saved), "secret-1")
+	for _, route := range routes {
+		if route.Path == "/text" {
+			assert.Equal(t, "plain hello", route.RawResponseBody)
+		}
+	}
+}
+
+func TestProxyErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewServer(WithProxy(&ProxyConfig{Upstream: "not a url"}))
+	require.ErrorContains(t, err, "invalid proxy upstream URL")
+
+	upstream := httptest.NewServer(http.NotFoundHandler())
+	upstream.Close()
+	p := newProxyParrot(t, &ProxyConfig{Upstream: upstream.URL, Record: true})
+	resp, err := p.Call(http.MethodGet, "/down")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode())
+	assert.Empty(t, p.Routes(), "failed calls should not be recorded")
+}
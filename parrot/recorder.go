@@ -1,6 +1,7 @@
 package parrot
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"fmt"
@@ -29,7 +30,7 @@ type Recorder struct {
 type RouteCall struct {
 	// ID is a unique identifier for the route call for help with debugging
 	ID string `json:"id"`
-	// RouteID is the identifier of the route that was called
+	// RouteID is the identifier of the route that responded, ex.: "GET:/hello", or the method and URL if none of them did
 	RouteID string `json:"route_id"`
 	// Request is the request made to the route
 	Request *RouteCallRequest `json:"request"`
@@ -139,7 +140,7 @@ func (r *Recorder) defaultRecordHandler() http.HandlerFunc {
 
 // httpResponseRecorder is a wrapper around http.ResponseWriter that records the response
 // for later inspection while still writing to the original writer.
-// WARNING: If you mutate after calling Header(), the changes will not be reflected in the recorded response.
+// Headers are shared with the original writer, changes made after WriteHeader are recorded but not sent to the client.
 type responseWriterRecorder struct {
 	originalWriter http.ResponseWriter
 	record         *httptest.ResponseRecorder
@@ -168,8 +169,25 @@ func (rr *responseWriterRecorder) Write(data []byte) (int, error) {
 	return rr.originalWriter.Write(data)
 }
 
+// Flush sends buffered data to the client if the original writer supports it
+func (rr *responseWriterRecorder) Flush() {
+	if f, ok := rr.originalWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets the caller take over the connection of the original writer, ex.: to drop it
+func (rr *responseWriterRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := rr.originalWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	return h.Hijack()
+}
+
+// Header returns the headers of the original writer, so they are sent to the client, Result copies them to the record
 func (rr *responseWriterRecorder) Header() http.Header {
-	return rr.record.Header()
+	return rr.originalWriter.Header()
 }
 
 func (rr *responseWriterRecorder) Result() *http.Response {
@@ -208,7 +226,7 @@ func routeRecordingMiddleware(p *Server, next http.Handler) http.HandlerFunc {
 		}
 
 		rr := newResponseWriterRecorder(w)
-		next.ServeHTTP(rr, r)
+		next.ServeHTTP(rr, withRouteCall(r, routeCall))
 
 		resp := rr.Result()
 		routeCall.Response = &RouteCallResponse{
@@ -217,6 +235,7 @@ func routeRecordingMiddleware(p *Server, next http.Handler) http.HandlerFunc {
 			Body:       rr.record.Body.Bytes(),
 		}
 
+		p.journalCall(routeCall)
 		p.sendToRecorders(routeCall)
 	})
 }
@@ -45,7 +45,7 @@ func TestResponseWriterRecorder(t *testing.T) {
 			expectedRespCode: http.StatusInternalServerError,
 			expectedRespBody: "Squawk\n", // http.Error adds a newline
 			expectedRespHeader: http.Header{
-				"Content-Type": []string{"text/plain"},
+				"Content-Type": []string{"text/plain; charset=utf-8"},
 			},
 		},
 	}
@@ -77,6 +77,10 @@ func TestResponseWriterRecorder(t *testing.T) {
 			assert.Equal(t, tc.expectedRespCode, recordedResp.StatusCode, "recorded response has unexpected status code")
 			assert.Equal(t, tc.expectedRespBody, string(actualBody), "actual response has unexpected body")
 			assert.Equal(t, tc.expectedRespBody, string(recordedBody), "recorded response has unexpected body")
+			for header := range tc.expectedRespHeader {
+				assert.Equal(t, tc.expectedRespHeader.Get(header), actualResp.Header.Get(header), "actual response has unexpected %s header", header)
+				assert.Equal(t, tc.expectedRespHeader.Get(header), recordedResp.Header.Get(header), "recorded response has unexpected %s header", header)
+			}
 		})
 	}
 }
@@ -0,0 +1,262 @@
+package parrot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"text/template"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+)
+
+// TemplateData is available in templated route responses, ex.: {"id": "{{ .Body.id }}", "call": {{ .Call }}}
+type TemplateData struct {
+	// Method is the request method
+	Method string
+	// Path is the request path
+	Path string
+	// PathParams are values of route path params by name, ex.: "id" for "/users/{id}", the wildcard value is "*"
+	PathParams map[string]string
+	// Query holds the first value of every query parameter
+	Query map[string]string
+	// Headers holds the first value of every header by its canonical name, ex.: {{ index .Headers "X-Request-Id" }}
+	Headers map[string]string
+	// Body is the decoded JSON request body, nil if the body isn't JSON
+	Body any
+	// RawBody is the request body
+	RawBody string
+	// Call is the number of calls the route responded to, including this one
+	Call int
+}
+
+// templateFuncs are helpers available in templated responses
+var templateFuncs = template.FuncMap{
+	"uuid": func() string { return uuid.NewString() },
+	"randInt": func(min, max int) (int, error) {
+		if max < min {
+			return 0, fmt.Errorf("randInt max %d is less than min %d", max, min)
+		}
+		return min + rand.IntN(max-min+1), nil //nolint:gosec
+	},
+	"randFloat": func(min, max float64) float64 { return min + rand.Float64()*(max-min) }, //nolint:gosec
+	"now":       time.Now,
+	"unixMs":    func() int64 { return time.Now().UnixMilli() },
+	"jsonPath": func(v any, path string) any {
+		res, _ := jsonPathLookup(v, path)
+		return res
+	},
+	"toJSON": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"add": func(a, b any) (float64, error) { return floatOp(a, b, func(x, y float64) float64 { return x + y }) },
+	"sub": func(a, b any) (float64, error) { return floatOp(a, b, func(x, y float64) float64 { return x - y }) },
+	"mul": func(a, b any) (float64, error) { return floatOp(a, b, func(x, y float64) float64 { return x * y }) },
+	"div": func(a, b any) (float64, error) { return floatOp(a, b, func(x, y float64) float64 { return x / y }) },
+}
+
+// floatOp converts numbers, JSON numbers and numeric strings to float64 and applies op
+func floatOp(a, b any, op func(x, y float64) float64) (float64, error) {
+	x, err := toFloat(a)
+	if err != nil {
+		return 0, err
+	}
+	y, err := toFloat(b)
+	if err != nil {
+		return 0, err
+	}
+	return op(x, y), nil
+}
+
+func toFloat(v any) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case float32:
+		return float64(n), nil
+	case int:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case json.Number:
+		return n.Float64()
+	case string:
+		return strconv.ParseFloat(n, 64)
+	}
+	return 0, fmt.Errorf("can't use %v of type %T as a number", v, v)
+}
+
+// responseTemplates holds parsed templates of route responses by their text
+type responseTemplates map[string]*template.Template
+
+// parseResponseTemplates parses raw bodies and all string values of JSON bodies of the route responses
+func parseResponseTemplates(route *Route) (responseTemplates, error) {
+	templates := responseTemplates{}
+	responses := route.Responses
+	if len(responses) == 0 {
+		responses = []*RouteResponse{route.response(1)}
+	}
+	for i, response := range responses {
+		if response.RawResponseBody != "" {
+			if err := templates.parse(response.RawResponseBody); err != nil {
+				return nil, newDynamicError(ErrInvalidTemplate, fmt.Sprintf("response %d: %s", i, err.Error()))
+			}
+		}
+		if response.ResponseBody != nil {
+			body, err := normalizeJSON(response.ResponseBody)
+			if err != nil {
+				return nil, newDynamicError(ErrResponseMarshal, err.Error())
+			}
+			if err := walkJSONStrings(body, templates.parse); err != nil {
+				return nil, newDynamicError(ErrInvalidTemplate, fmt.Sprintf("response %d: %s", i, err.Error()))
+			}
+		}
+	}
+	return templates, nil
+}
+
+func (t responseTemplates) parse(text string) error {
+	if _, ok := t[text]; ok {
+		return nil
+	}
+	tmpl, err := template.New("response").Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return err
+	}
+	t[text] = tmpl
+	return nil
+}
+
+func (t responseTemplates) render(text string, data *TemplateData) (string, error) {
+	tmpl, ok := t[text]
+	if !ok {
+		return text, nil
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// renderResponse returns a copy of the response with the raw body or JSON body string values rendered
+func (t responseTemplates) renderResponse(response *RouteResponse, data *TemplateData) (*RouteResponse, error) {
+	rendered := &RouteResponse{ResponseStatusCode: response.ResponseStatusCode}
+	if response.RawResponseBody != "" {
+		raw, err := t.render(response.RawResponseBody, data)
+		if err != nil {
+			return nil, err
+		}
+		rendered.RawResponseBody = raw
+	}
+	if response.ResponseBody != nil {
+		body, err := normalizeJSON(response.ResponseBody)
+		if err != nil {
+			return nil, err
+		}
+		rendered.ResponseBody, err = t.renderJSON(body, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rendered, nil
+}
+
+func (t responseTemplates) renderJSON(v any, data *TemplateData) (any, error) {
+	switch val := v.(type) {
+	case string:
+		return t.render(val, data)
+	case map[string]any:
+		for k, item := range val {
+			rendered, err := t.renderJSON(item, data)
+			if err != nil {
+				return nil, err
+			}
+			val[k] = rendered
+		}
+	case []any:
+		for i, item := range val {
+			rendered, err := t.renderJSON(item, data)
+			if err != nil {
+				return nil, err
+			}
+			val[i] = rendered
+		}
+	}
+	return v, nil
+}
+
+// walkJSONStrings calls fn for every string value of decoded JSON
+func walkJSONStrings(v any, fn func(string) error) error {
+	switch val := v.(type) {
+	case string:
+		return fn(val)
+	case map[string]any:
+		for _, item := range val {
+			if err := walkJSONStrings(item, fn); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range val {
+			if err := walkJSONStrings(item, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// normalizeJSON returns a copy of v with the types of decoded JSON
+func normalizeJSON(v any) (any, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var res any
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// newTemplateData collects request data for templated responses
+func newTemplateData(r *http.Request, call int) *TemplateData {
+	data := &TemplateData{
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		PathParams: map[string]string{},
+		Query:      map[string]string{},
+		Headers:    map[string]string{},
+		Call:       call,
+	}
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		for i, key := range rctx.URLParams.Keys {
+			data.PathParams[key] = rctx.URLParams.Values[i]
+		}
+	}
+	for k, v := range r.URL.Query() {
+		if len(v) > 0 {
+			data.Query[k] = v[0]
+		}
+	}
+	for k, v := range r.Header {
+		if len(v) > 0 {
+			data.Headers[k] = v[0]
+		}
+	}
+	if r.Body != nil {
+		if raw, err := io.ReadAll(r.Body); err == nil {
+			r.Body = io.NopCloser(bytes.NewBuffer(raw))
+			data.RawBody = string(raw)
+			_ = json.Unmarshal(raw, &data.Body)
+		}
+	}
+	return data
+}
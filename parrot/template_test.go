@@ -0,0 +1,135 @@
+package parrot
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplatedResponses(t *testing.T) {
+	t.Parallel()
+
+	p := newParrot(t)
+	client := resty.New().SetBaseURL("http://" + p.Address())
+
+	routes := []*Route{
+		{
+			Method:             http.MethodPost,
+			Path:               "/price/{base}/{quote}",
+			ResponseStatusCode: http.StatusOK,
+			Template:           true,
+			ResponseBody: map[string]any{
+				"jobRunID": "{{ .Body.id }}",
+				"data": map[string]any{
+					"pair":   "{{ .PathParams.base }}/{{ .PathParams.quote }}",
+					"result": "{{ mul .Body.data.amount 2.5 }}",
+				},
+				"call":       "{{ .Call }}",
+				"requestId":  `{{ index .Headers "X-Request-Id" }}`,
+				"statusCode": 200,
+			},
+		},
+		{
+			Method:             http.MethodGet,
+			Path:               "/echo/*",
+			ResponseStatusCode: http.StatusOK,
+			Template:           true,
+			RawResponseBody:    `{{ .Method }} {{ index .PathParams "*" }} {{ .Query.q }} {{ if gt .Call 1 }}again{{ else }}first{{ end }}`,
+		},
+		{
+			Method:             http.MethodGet,
+			Path:               "/static",
+			ResponseStatusCode: http.StatusOK,
+			RawResponseBody:    "{{ .Method }}",
+		},
+	}
+	for _, route := range routes {
+		require.NoError(t, p.Register(route), "error registering route")
+	}
+
+	var price struct {
+		JobRunID string `json:"jobRunID"`
+		Data     struct {
+			Pair   string `json:"pair"`
+			Result string `json:"result"`
+		} `json:"data"`
+		Call       string `json:"call"`
+		RequestID  string `json:"requestId"`
+		StatusCode int    `json:"statusCode"`
+	}
+	resp, err := client.R().
+		SetHeader("X-Request-Id", "abc").
+		SetBody(`{"id": "run-1", "data": {"amount": 4}}`).
+		Post("/price/LINK/USD")
+	require.NoError(t, err, "error calling parrot")
+	require.Equal(t, http.StatusOK, resp.StatusCode(), string(resp.Body()))
+	require.NoError(t, json.Unmarshal(resp.Body(), &price))
+	assert.Equal(t, "run-1", price.JobRunID)
+	assert.Equal(t, "LINK/USD", price.Data.Pair)
+	assert.Equal(t, "10", price.Data.Result)
+	assert.Equal(t, "1", price.Call)
+	assert.Equal(t, "abc", price.RequestID)
+	assert.Equal(t, 200, price.StatusCode)
+
+	for _, expected := range []string{"GET a/b x first", "GET a/b x again"} {
+		resp, err = client.R().SetQueryParam("q", "x").Get("/echo/a/b")
+		require.NoError(t, err, "error calling parrot")
+		assert.Equal(t, expected, string(resp.Body()))
+	}
+
+	resp, err = p.Call(http.MethodGet, "/static")
+	require.NoError(t, err, "error calling parrot")
+	assert.Equal(t, "{{ .Method }}", string(resp.Body()), "routes without template should not be rendered")
+}
+
+func TestTemplateErrors(t *testing.T) {
+	t.Parallel()
+
+	p := newParrot(t)
+
+	err := p.Register(&Route{
+		Method:          http.MethodGet,
+		Path:            "/broken",
+		RawResponseBody: "{{ .Method ",
+		Template:        true,
+	})
+	require.ErrorIs(t, err, ErrInvalidTemplate)
+
+	err = p.Register(&Route{
+		Method:    http.MethodGet,
+		Path:      "/broken",
+		Template:  true,
+		Responses: []*RouteResponse{{ResponseBody: map[string]any{"a": []any{"{{ end }}"}}}},
+	})
+	require.ErrorIs(t, err, ErrInvalidTemplate)
+
+	// environment variables of the server aren't exposed to anyone who can register routes
+	err = p.Register(&Route{
+		Method:          http.MethodGet,
+		Path:            "/env",
+		RawResponseBody: `{{ env "HOME" }}`,
+		Template:        true,
+	})
+	require.ErrorIs(t, err, ErrInvalidTemplate)
+
+	route := &Route{
+		Method:             http.MethodGet,
+		Path:               "/math",
+		RawResponseBody:    `{{ add .Query.a 1 }}`,
+		ResponseStatusCode: http.StatusOK,
+		Template:           true,
+	}
+	require.NoError(t, p.Register(route), "error registering route")
+	resp, err := p.Call(route.Method, route.Path+"?a=1.5")
+	require.NoError(t, err, "error calling parrot")
+	assert.Equal(t, "2.5", string(resp.Body()))
+
+	resp, err = p.Call(route.Method, route.Path+"?a=x")
+	require.NoError(t, err, "error calling parrot")
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode())
+	assert.Contains(t, string(resp.Body()), "Failed to render response template")
+}
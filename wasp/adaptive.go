@@ -0,0 +1,227 @@
+package wasp
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+/* Closed-loop adaptive load, searches for the max sustainable RPS */
+
+const (
+	DefaultAdaptiveStepDuration      = 10 * time.Second
+	DefaultAdaptiveLatencyPercentile = 95
+)
+
+var (
+	ErrAdaptiveNotRPS       = errors.New("adaptive load can only be used with wasp.RPS load type")
+	ErrAdaptiveWithSchedule = errors.New("adaptive load controls the schedule, Schedule must be empty")
+	ErrAdaptiveNoDuration   = errors.New("adaptive load MaxDuration must be > 0")
+	ErrAdaptiveNoSLO        = errors.New("adaptive load requires MaxErrorRate or MaxLatency to be set")
+)
+
+// AdaptiveConfig configures a closed-loop schedule that increases RPS by StepRPS every StepDuration until the SLO
+// is violated, then bisects between the last sustainable and the first violating rate until they differ by at most Precision
+type AdaptiveConfig struct {
+	// StartRPS is the first probed rate, defaults to 1
+	StartRPS int64 `json:"start_rps"`
+	// StepRPS is a rate increase per step until the first violation, defaults to StartRPS
+	StepRPS int64 `json:"step_rps"`
+	// MaxRPS is the upper bound of the search, 0 means unbounded
+	MaxRPS int64 `json:"max_rps"`
+	// StepDuration is how long every rate is held, responses finished during a step form its SLO window
+	StepDuration time.Duration `json:"step_duration"`
+	// Precision is the search resolution in RPS, defaults to 1
+	Precision int64 `json:"precision"`
+	// MaxErrorRate is the max allowed ratio of failed and timed out calls in a window, 0..1, 0 disables the check
+	MaxErrorRate float64 `json:"max_error_rate"`
+	// MaxLatency is the max allowed LatencyPercentile of a window, 0 disables the check
+	MaxLatency time.Duration `json:"max_latency"`
+	// LatencyPercentile is checked against MaxLatency, defaults to 95
+	LatencyPercentile float64 `json:"latency_percentile"`
+	// HoldDuration keeps the found max sustainable rate after the search, 0 ends the run right away
+	HoldDuration time.Duration `json:"hold_duration"`
+	// MaxDuration limits the whole run, the best rate found so far is reported if the search didn't finish
+	MaxDuration time.Duration `json:"max_duration"`
+}
+
+// Validate checks required fields and sets defaults
+func (c *AdaptiveConfig) Validate() error {
+	if c.MaxDuration <= 0 {
+		return ErrAdaptiveNoDuration
+	}
+	if c.MaxErrorRate <= 0 && c.MaxLatency <= 0 {
+		return ErrAdaptiveNoSLO
+	}
+	if c.StartRPS <= 0 {
+		c.StartRPS = 1
+	}
+	if c.StepRPS <= 0 {
+		c.StepRPS = c.StartRPS
+	}
+	if c.StepDuration <= 0 {
+		c.StepDuration = DefaultAdaptiveStepDuration
+	}
+	if c.Precision <= 0 {
+		c.Precision = 1
+	}
+	if c.LatencyPercentile <= 0 {
+		c.LatencyPercentile = DefaultAdaptiveLatencyPercentile
+	}
+	return nil
+}
+
+// AdaptiveStep is a result of one probed rate
+type AdaptiveStep struct {
+	RPS       int64         `json:"rps"`
+	Calls     int64         `json:"calls"`
+	ErrorRate float64       `json:"error_rate"`
+	Latency   time.Duration `json:"latency"`
+	OK        bool          `json:"ok"`
+}
+
+// adaptiveRun collects responses finished during the current step and results of all steps
+type adaptiveRun struct {
+	mu     *sync.Mutex
+	calls  int64
+	failed int64
+	hist   *LatencyHistogram
+	steps  []AdaptiveStep
+}
+
+func newAdaptiveRun() *adaptiveRun {
+	return &adaptiveRun{mu: &sync.Mutex{}, hist: NewLatencyHistogram()}
+}
+
+func (a *adaptiveRun) record(r *Response) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.calls++
+	if r.Failed || r.Timeout {
+		a.failed++
+	}
+	a.hist.Record(r.Duration)
+}
+
+// reset returns the collected window and starts a new one
+func (a *adaptiveRun) reset() (calls, failed int64, hist *LatencyHistogram) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	calls, failed, hist = a.calls, a.failed, a.hist
+	a.calls, a.failed, a.hist = 0, 0, NewLatencyHistogram()
+	return calls, failed, hist
+}
+
+func (a *adaptiveRun) addStep(s AdaptiveStep) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.steps = append(a.steps, s)
+}
+
+// evaluate checks the window collected at rps against the SLO
+func (c *AdaptiveConfig) evaluate(rps, calls, failed int64, hist *LatencyHistogram) AdaptiveStep {
+	s := AdaptiveStep{RPS: rps, Calls: calls, OK: true}
+	if calls == 0 {
+		s.OK = false
+		return s
+	}
+	s.ErrorRate = float64(failed) / float64(calls)
+	s.Latency = hist.Percentile(c.LatencyPercentile)
+	if c.MaxErrorRate > 0 && s.ErrorRate > c.MaxErrorRate {
+		s.OK = false
+	}
+	if c.MaxLatency > 0 && s.Latency > c.MaxLatency {
+		s.OK = false
+	}
+	return s
+}
+
+// next returns the next rate to probe given the highest sustainable (lo) and the lowest violating (hi) rates,
+// hi is 0 until the first violation
+func (c *AdaptiveConfig) next(rps, lo, hi int64) (int64, bool) {
+	if hi == 0 {
+		if c.MaxRPS > 0 && rps >= c.MaxRPS {
+			return 0, true
+		}
+		next := rps + c.StepRPS
+		if c.MaxRPS > 0 && next > c.MaxRPS {
+			next = c.MaxRPS
+		}
+		return next, false
+	}
+	if hi-lo <= c.Precision {
+		return 0, true
+	}
+	return lo + (hi-lo)/2, false
+}
+
+// runAdaptiveLoop replaces the schedule loop, every probed rate is a new plain segment.
+// Wait returns after the loop, so segments in Cfg.Schedule have their end time set
+func (g *Generator) runAdaptiveLoop() {
+	cfg := g.Cfg.Adaptive
+	g.ResponsesWaitGroup.Add(1)
+	go func() {
+		defer g.ResponsesWaitGroup.Done()
+		defer g.responsesCancel()
+		rps, lo, hi := cfg.StartRPS, int64(0), int64(0)
+		for {
+			if !g.runAdaptiveSegment(rps, cfg.StepDuration) {
+				return
+			}
+			calls, failed, hist := g.adaptive.reset()
+			step := cfg.evaluate(rps, calls, failed, hist)
+			g.adaptive.addStep(step)
+			g.Log.Info().
+				Int64("RPS", step.RPS).
+				Int64("Calls", step.Calls).
+				Float64("ErrorRate", step.ErrorRate).
+				Dur("Latency", step.Latency).
+				Bool("OK", step.OK).
+				Msg("Adaptive step")
+			if step.OK {
+				lo = rps
+				g.stats.MaxSustainableRPS.Store(lo)
+			} else {
+				hi = rps
+			}
+			var done bool
+			if rps, done = cfg.next(rps, lo, hi); done {
+				break
+			}
+		}
+		g.Log.Info().Int64("MaxSustainableRPS", lo).Msg("Adaptive search finished")
+		if cfg.HoldDuration > 0 && lo > 0 {
+			g.runAdaptiveSegment(lo, cfg.HoldDuration)
+		}
+	}()
+}
+
+// runAdaptiveSegment holds rps for d, returns false if the run has ended.
+// Executed segments are recorded in Cfg.Schedule, so reports can find the run time range as for a static schedule
+func (g *Generator) runAdaptiveSegment(rps int64, d time.Duration) bool {
+	seg := &Segment{Type: SegmentType_Plain, From: rps, Duration: d}
+	g.scheduleSegments = append(g.scheduleSegments, seg)
+	g.Cfg.Schedule = g.scheduleSegments
+	g.stats.LastSegment.Store(int64(len(g.scheduleSegments)))
+	g.processSegment()
+	// responses of the previous rate are not counted
+	g.adaptive.reset()
+	select {
+	case <-g.ResponsesCtx.Done():
+		seg.EndTime = time.Now()
+		return false
+	case <-time.After(d):
+		seg.EndTime = time.Now()
+		return true
+	}
+}
+
+// AdaptiveSteps returns results of all probed rates, only available when Config.Adaptive is set
+func (g *Generator) AdaptiveSteps() []AdaptiveStep {
+	if g.adaptive == nil {
+		return nil
+	}
+	g.adaptive.mu.Lock()
+	defer g.adaptive.mu.Unlock()
+	return append([]AdaptiveStep{}, g.adaptive.steps...)
+}
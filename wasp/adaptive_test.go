@@ -0,0 +1,114 @@
+package wasp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// capacityGun fails every call when the current rate is above capacity
+type capacityGun struct {
+	capacity int64
+}
+
+func (m *capacityGun) Call(l *Generator) *Response {
+	if l.Stats().CurrentRPS.Load() > m.capacity {
+		return &Response{Failed: true, Error: "overloaded"}
+	}
+	return &Response{Data: "ok"}
+}
+
+func TestAdaptiveNext(t *testing.T) {
+	t.Parallel()
+	cfg := &AdaptiveConfig{StartRPS: 10, StepRPS: 10, MaxRPS: 25, Precision: 2}
+	next, done := cfg.next(10, 10, 0)
+	require.False(t, done)
+	require.Equal(t, int64(20), next)
+	next, done = cfg.next(20, 20, 0)
+	require.False(t, done)
+	require.Equal(t, int64(25), next)
+	_, done = cfg.next(25, 25, 0)
+	require.True(t, done)
+	next, done = cfg.next(20, 10, 20)
+	require.False(t, done)
+	require.Equal(t, int64(15), next)
+	_, done = cfg.next(12, 10, 12)
+	require.True(t, done)
+}
+
+func TestAdaptiveValidation(t *testing.T) {
+	t.Parallel()
+	gun := &capacityGun{capacity: 10}
+	_, err := NewGenerator(&Config{LoadType: RPS, Gun: gun, Adaptive: &AdaptiveConfig{MaxErrorRate: 0.1}})
+	require.ErrorIs(t, err, ErrAdaptiveNoDuration)
+	_, err = NewGenerator(&Config{LoadType: RPS, Gun: gun, Adaptive: &AdaptiveConfig{MaxDuration: time.Second}})
+	require.ErrorIs(t, err, ErrAdaptiveNoSLO)
+	_, err = NewGenerator(&Config{
+		LoadType: RPS,
+		Gun:      gun,
+		Schedule: Plain(1, time.Second),
+		Adaptive: &AdaptiveConfig{MaxDuration: time.Second, MaxErrorRate: 0.1},
+	})
+	require.ErrorIs(t, err, ErrAdaptiveWithSchedule)
+	_, err = NewGenerator(&Config{
+		LoadType: VU,
+		VU:       NewMockVU(&MockVirtualUserConfig{}),
+		Adaptive: &AdaptiveConfig{MaxDuration: time.Second, MaxErrorRate: 0.1},
+	})
+	require.ErrorIs(t, err, ErrAdaptiveNotRPS)
+}
+
+func TestSmokeAdaptiveMaxSustainableRPS(t *testing.T) {
+	t.Parallel()
+	gen, err := NewGenerator(&Config{
+		T:        t,
+		LoadType: RPS,
+		Gun:      &capacityGun{capacity: 35},
+		Adaptive: &AdaptiveConfig{
+			StartRPS:     10,
+			StepRPS:      10,
+			StepDuration: 1 * time.Second,
+			Precision:    2,
+			MaxErrorRate: 0.1,
+			MaxDuration:  30 * time.Second,
+		},
+	})
+	require.NoError(t, err)
+	gen.Run(true)
+	require.Equal(t, int64(35), gen.Stats().MaxSustainableRPS.Load())
+	steps := gen.AdaptiveSteps()
+	rates := make([]int64, 0, len(steps))
+	for _, s := range steps {
+		rates = append(rates, s.RPS)
+	}
+	require.Equal(t, []int64{10, 20, 30, 40, 35, 37}, rates)
+	require.False(t, steps[3].OK)
+	require.True(t, steps[4].OK)
+	require.Equal(t, int64(35), gen.StatsJSON()["max_sustainable_rps"])
+}
+
+func TestSmokeAdaptiveMaxDuration(t *testing.T) {
+	t.Parallel()
+	gen, err := NewGenerator(&Config{
+		T:        t,
+		LoadType: RPS,
+		Gun:      &capacityGun{capacity: 1000},
+		Adaptive: &AdaptiveConfig{
+			StartRPS:     5,
+			StepDuration: 500 * time.Millisecond,
+			MaxErrorRate: 0.1,
+			MaxDuration:  2200 * time.Millisecond,
+		},
+	})
+	require.NoError(t, err)
+	gen.Run(true)
+	require.Len(t, gen.AdaptiveSteps(), 4)
+	require.Equal(t, int64(20), gen.Stats().MaxSustainableRPS.Load())
+	// the last segment is cut by MaxDuration
+	require.Len(t, gen.Cfg.Schedule, 5)
+	for _, s := range gen.Cfg.Schedule {
+		require.False(t, s.StartTime.IsZero())
+		require.False(t, s.EndTime.IsZero())
+	}
+}
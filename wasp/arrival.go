@@ -0,0 +1,164 @@
+package wasp
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+/* Open-model arrival limiters used by Poisson and Curve schedule segments */
+
+const (
+	// DefaultArrivalIdleTick is how long the arrival limiter waits before re-evaluating a curve that yields zero rate
+	DefaultArrivalIdleTick = 10 * time.Millisecond
+)
+
+// RateCurve returns the desired rate, in requests per RateLimitUnitDuration, for the time elapsed since the segment start
+type RateCurve func(elapsed time.Duration) float64
+
+// arrivalLimiter implements ratelimit.Limiter and spaces calls according to a RateCurve,
+// either with deterministic intervals or with exponentially distributed (Poisson) inter-arrival times.
+// The rate is integrated over time, so curves starting at zero or changing quickly are followed precisely.
+type arrivalLimiter struct {
+	ctx        context.Context
+	cancel     context.CancelFunc
+	curve      RateCurve
+	poisson    bool
+	unit       time.Duration
+	start      time.Time
+	cursor     time.Time
+	due        time.Time
+	acc        float64
+	target     float64
+	rnd        *rand.Rand
+	currentRPS *atomic.Int64
+}
+
+// newArrivalLimiter creates a limiter for a Poisson or Curve segment starting at the segment's StartTime.
+// currentRPS is updated on every Take so Stats reflect the instantaneous rate of the curve.
+// The limiter stops blocking when ctx is done or when it is replaced and stopped by the next segment.
+func newArrivalLimiter(ctx context.Context, seg *Segment, unit time.Duration, currentRPS *atomic.Int64) *arrivalLimiter {
+	curve := seg.Curve
+	if curve == nil {
+		rate := float64(seg.From)
+		curve = func(_ time.Duration) float64 { return rate }
+	}
+	lctx, cancel := context.WithCancel(ctx)
+	a := &arrivalLimiter{
+		ctx:     lctx,
+		cancel:  cancel,
+		curve:   curve,
+		poisson: seg.Type == SegmentType_Poisson,
+		unit:    unit,
+		start:   seg.StartTime,
+		cursor:  seg.StartTime,
+		due:     seg.StartTime,
+		//nolint
+		rnd:        rand.New(rand.NewSource(time.Now().UnixNano())),
+		currentRPS: currentRPS,
+	}
+	a.target = a.nextTarget()
+	return a
+}
+
+// Take blocks until the next arrival is due and returns its time
+func (a *arrivalLimiter) Take() time.Time {
+	for {
+		now := time.Now()
+		rate := a.curve(now.Sub(a.start))
+		a.currentRPS.Store(int64(math.Round(rate)))
+		a.acc += rate * float64(now.Sub(a.cursor)) / float64(a.unit)
+		a.cursor = now
+		if a.acc >= a.target {
+			a.advanceDue(now)
+			// no slack: carry at most one pending arrival if we are late
+			a.acc -= a.target
+			a.target = a.nextTarget()
+			a.acc = math.Min(a.acc, a.target)
+			return now
+		}
+		wait := DefaultArrivalIdleTick
+		if rate > 0 {
+			remaining := time.Duration((a.target - a.acc) / rate * float64(a.unit))
+			wait = min(wait, remaining)
+		}
+		select {
+		case <-a.ctx.Done():
+			return now
+		case <-time.After(wait):
+		}
+	}
+}
+
+// advanceDue moves the ideal, unclamped arrival time forward by the current target.
+// Unlike Take it never drops lag, so it is used as intended start time for coordinated omission correction.
+func (a *arrivalLimiter) advanceDue(now time.Time) {
+	t := a.due
+	remaining := a.target
+	for t.Before(now) {
+		rate := a.curve(t.Sub(a.start))
+		if rate > 0 {
+			need := time.Duration(remaining / rate * float64(a.unit))
+			if need <= DefaultArrivalIdleTick {
+				t = t.Add(need)
+				break
+			}
+			remaining -= rate * float64(DefaultArrivalIdleTick) / float64(a.unit)
+		}
+		t = t.Add(DefaultArrivalIdleTick)
+	}
+	if t.After(now) {
+		t = now
+	}
+	a.due = t
+}
+
+// intendedStart returns the ideal time of the last arrival returned by Take
+func (a *arrivalLimiter) intendedStart() time.Time {
+	return a.due
+}
+
+// stop releases a Take call blocked on a zero rate curve
+func (a *arrivalLimiter) stop() {
+	a.cancel()
+}
+
+// nextTarget returns the amount of accumulated rate required for the next arrival,
+// exponentially distributed for a Poisson process and constant otherwise
+func (a *arrivalLimiter) nextTarget() float64 {
+	if a.poisson {
+		return a.rnd.ExpFloat64()
+	}
+	return 1
+}
+
+// LinearCurve returns a RateCurve that linearly moves from `from` to `to` over `duration`
+func LinearCurve(from, to int64, duration time.Duration) RateCurve {
+	return func(elapsed time.Duration) float64 {
+		if elapsed >= duration {
+			return float64(to)
+		}
+		progress := float64(elapsed) / float64(duration)
+		return float64(from) + float64(to-from)*progress
+	}
+}
+
+// SineCurve returns a RateCurve oscillating around `base` with `amplitude` and `period`, never going below 0
+func SineCurve(base, amplitude int64, period time.Duration) RateCurve {
+	return func(elapsed time.Duration) float64 {
+		v := float64(base) + float64(amplitude)*math.Sin(2*math.Pi*float64(elapsed)/float64(period))
+		return math.Max(v, 0)
+	}
+}
+
+// SpikeCurve returns a RateCurve holding `base` rate except for the window [spikeAt, spikeAt+spikeDuration) where it holds `peak`
+func SpikeCurve(base, peak int64, spikeAt, spikeDuration time.Duration) RateCurve {
+	return func(elapsed time.Duration) float64 {
+		if elapsed >= spikeAt && elapsed < spikeAt+spikeDuration {
+			return float64(peak)
+		}
+		return float64(base)
+	}
+}
@@ -80,23 +80,15 @@ func (b *BasicData) FillStartEndTimes() error {
 	var latestTime time.Time
 
 	for _, cfg := range b.GeneratorConfigs {
-		if len(cfg.Schedule) == 0 {
-			return fmt.Errorf("schedule is empty for generator %s", cfg.GenName)
+		start, end, err := scheduleTimeRange(cfg)
+		if err != nil {
+			return err
 		}
-
-		for _, segment := range cfg.Schedule {
-			if segment.StartTime.IsZero() {
-				return fmt.Errorf("start time is missing in one of the segments belonging to generator %s. Did that generator run?", cfg.GenName)
-			}
-			if segment.StartTime.Before(earliestTime) {
-				earliestTime = segment.StartTime
-			}
-			if segment.EndTime.IsZero() {
-				return fmt.Errorf("end time is missing in one of the segments belonging to generator %s. Did that generator finish running?", cfg.GenName)
-			}
-			if segment.EndTime.After(latestTime) {
-				latestTime = segment.EndTime
-			}
+		if start.Before(earliestTime) {
+			earliestTime = start
+		}
+		if end.After(latestTime) {
+			latestTime = end
 		}
 	}
 
@@ -110,6 +102,40 @@ func (b *BasicData) FillStartEndTimes() error {
 	return nil
 }
 
+// GeneratorTimeRange returns the earliest start time and the latest end time of the generator's schedule.
+// Generators of one report can run with different schedules, so per-generator queries should use this range instead of the report's one.
+func (b *BasicData) GeneratorTimeRange(generatorName string) (time.Time, time.Time, error) {
+	cfg, ok := b.GeneratorConfigs[generatorName]
+	if !ok {
+		return time.Time{}, time.Time{}, fmt.Errorf("generator %s is not part of the report", generatorName)
+	}
+	return scheduleTimeRange(cfg)
+}
+
+func scheduleTimeRange(cfg *wasp.Config) (time.Time, time.Time, error) {
+	if len(cfg.Schedule) == 0 {
+		return time.Time{}, time.Time{}, fmt.Errorf("schedule is empty for generator %s", cfg.GenName)
+	}
+
+	var start, end time.Time
+	for _, segment := range cfg.Schedule {
+		if segment.StartTime.IsZero() {
+			return time.Time{}, time.Time{}, fmt.Errorf("start time is missing in one of the segments belonging to generator %s. Did that generator run?", cfg.GenName)
+		}
+		if start.IsZero() || segment.StartTime.Before(start) {
+			start = segment.StartTime
+		}
+		if segment.EndTime.IsZero() {
+			return time.Time{}, time.Time{}, fmt.Errorf("end time is missing in one of the segments belonging to generator %s. Did that generator finish running?", cfg.GenName)
+		}
+		if segment.EndTime.After(end) {
+			end = segment.EndTime
+		}
+	}
+
+	return start, end, nil
+}
+
 // Validate checks the integrity of the BasicData fields, ensuring that the test start and end times are set,
 // and that at least one generator configuration is provided. It returns an error if any of these conditions are not met.
 func (b *BasicData) Validate() error {
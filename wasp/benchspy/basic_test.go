@@ -237,6 +237,35 @@ func TestBenchSpy_BasicData_FillStartEndTimes_MultipleGenerators(t *testing.T) {
 	}
 }
 
+func TestBenchSpy_BasicData_GeneratorTimeRange(t *testing.T) {
+	baseTime := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	bd := &BasicData{GeneratorConfigs: map[string]*wasp.Config{
+		"gen1": {GenName: "gen1", Schedule: []*wasp.Segment{
+			{StartTime: baseTime.Add(time.Hour), EndTime: baseTime.Add(2 * time.Hour)},
+			{StartTime: baseTime, EndTime: baseTime.Add(time.Hour)},
+		}},
+		"gen2": {GenName: "gen2", Schedule: []*wasp.Segment{
+			{StartTime: baseTime.Add(time.Hour), EndTime: baseTime.Add(3 * time.Hour)},
+		}},
+		"not_started": {GenName: "not_started", Schedule: []*wasp.Segment{{}}},
+	}}
+
+	start, end, err := bd.GeneratorTimeRange("gen1")
+	require.NoError(t, err)
+	require.Equal(t, baseTime, start)
+	require.Equal(t, baseTime.Add(2*time.Hour), end)
+
+	start, end, err = bd.GeneratorTimeRange("gen2")
+	require.NoError(t, err)
+	require.Equal(t, baseTime.Add(time.Hour), start)
+	require.Equal(t, baseTime.Add(3*time.Hour), end)
+
+	_, _, err = bd.GeneratorTimeRange("not_started")
+	require.ErrorContains(t, err, "start time is missing")
+	_, _, err = bd.GeneratorTimeRange("unknown")
+	require.ErrorContains(t, err, "is not part of the report")
+}
+
 func TestBenchSpy_TestBasicData_Validate(t *testing.T) {
 	gen := &wasp.Generator{
 		Cfg: &wasp.Config{
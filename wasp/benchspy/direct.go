@@ -20,6 +20,32 @@ type DirectQueryExecutor struct {
 	Generator    *wasp.Generator          `json:"generator_config"`
 	Queries      map[string]DirectQueryFn `json:"queries"`
 	QueryResults map[string]interface{}   `json:"query_results"`
+	// Samples is the latency distribution of executed responses, it is stored with the report for statistical comparison
+	Samples *DirectSamples `json:"samples,omitempty"`
+	// Windows are time ranges for which queries are executed separately, they are set by the report, see WithTimeWindows
+	Windows []wasp.TimeWindow `json:"-"`
+	// WindowResults are results of queries executed on calls that finished within each window, keyed by window name
+	WindowResults map[string]map[string]float64 `json:"window_results,omitempty"`
+}
+
+// DirectSamples keeps the latency histogram (in the same precision as wasp.LatencyHistogram) and call counts of a generator,
+// so reports loaded from storage can be compared with CompareDirectStatistically
+type DirectSamples struct {
+	Latency *wasp.LatencyHistogram `json:"latency"`
+	Calls   int64                  `json:"calls"`
+	Failed  int64                  `json:"failed"`
+}
+
+func newDirectSamples(responses []*wasp.Response) *DirectSamples {
+	s := &DirectSamples{Latency: wasp.NewLatencyHistogram()}
+	for _, r := range responses {
+		s.Latency.Record(r.Duration)
+		s.Calls++
+		if r.Failed || r.Timeout {
+			s.Failed++
+		}
+	}
+	return s
 }
 
 // NewStandardDirectQueryExecutor creates a new DirectQueryExecutor configured for standard queries.
@@ -34,9 +60,21 @@ func NewStandardDirectQueryExecutor(generator *wasp.Generator) (*DirectQueryExec
 		return nil, err
 	}
 
+	if generator != nil && generator.Cfg != nil && generator.Cfg.Adaptive != nil {
+		queries[string(MaxSustainableRPS)] = MaxSustainableRPSQuery(generator)
+	}
+
 	return NewDirectQueryExecutor(generator, queries)
 }
 
+// MaxSustainableRPSQuery returns a query reporting the max sustainable RPS found by an adaptive load generator.
+// NewStandardDirectQueryExecutor adds it automatically for generators with wasp.AdaptiveConfig.
+func MaxSustainableRPSQuery(generator *wasp.Generator) DirectQueryFn {
+	return func(_ *wasp.SliceBuffer[*wasp.Response]) (float64, error) {
+		return float64(generator.Stats().MaxSustainableRPS.Load()), nil
+	}
+}
+
 // NewDirectQueryExecutor creates a new DirectQueryExecutor with the specified generator and query functions.
 // It initializes the executor with a kind name and prepares a map for query results, enabling efficient query execution.
 func NewDirectQueryExecutor(generator *wasp.Generator, queries map[string]DirectQueryFn) (*DirectQueryExecutor, error) {
@@ -193,6 +231,14 @@ func (dqe *DirectQueryExecutor) Execute(_ context.Context) error {
 			Msg("Direct query executed successfully")
 	}
 
+	if data := dqe.Generator.GetData(); data != nil {
+		responses := append(append([]*wasp.Response{}, data.OKResponses.Data...), data.FailResponses.Data...)
+		dqe.Samples = newDirectSamples(responses)
+		if err := dqe.executeWindows(responses); err != nil {
+			return err
+		}
+	}
+
 	L.Info().
 		Str("Generator", dqe.Generator.Cfg.GenName).
 		Int("Queries", len(dqe.Queries)).
@@ -201,6 +247,44 @@ func (dqe *DirectQueryExecutor) Execute(_ context.Context) error {
 	return nil
 }
 
+// executeWindows executes queries for every window on calls that finished within it, windows without calls are skipped
+func (dqe *DirectQueryExecutor) executeWindows(responses []*wasp.Response) error {
+	if len(dqe.Windows) == 0 {
+		return nil
+	}
+	dqe.WindowResults = make(map[string]map[string]float64)
+	for _, window := range dqe.Windows {
+		inWindow := wasp.NewSliceBuffer[*wasp.Response](len(responses))
+		for _, response := range responses {
+			ts := response.FinishedAt
+			if ts == nil {
+				ts = response.StartedAt
+			}
+			if ts != nil && window.Contains(*ts) {
+				inWindow.Append(response)
+			}
+		}
+		if len(inWindow.Data) == 0 {
+			L.Warn().
+				Str("Generator", dqe.Generator.Cfg.GenName).
+				Str("Window", window.Name).
+				Msg("No responses found within the window, skipping it")
+			continue
+		}
+
+		results := make(map[string]float64)
+		for queryName, queryFunction := range dqe.Queries {
+			result, queryErr := queryFunction(inWindow)
+			if queryErr != nil {
+				return errors.Wrapf(queryErr, "failed to execute query %s for window %s", queryName, window.Name)
+			}
+			results[queryName] = result
+		}
+		dqe.WindowResults[window.Name] = results
+	}
+	return nil
+}
+
 // TimeRange ensures that the query executor operates within the specified time range.
 // It is a no-op for executors that already have responses stored in the correct time range.
 func (dqe *DirectQueryExecutor) TimeRange(_, _ time.Time) {
@@ -292,10 +376,12 @@ func (dqe *DirectQueryExecutor) standardQuery(standardMetric StandardLoadMetric)
 func (dqe *DirectQueryExecutor) MarshalJSON() ([]byte, error) {
 	// we need custom marshalling to only include query names, since the functions are not serializable
 	type QueryExecutor struct {
-		Kind         string                 `json:"kind"`
-		Generator    interface{}            `json:"generator_config"`
-		Queries      []string               `json:"queries"`
-		QueryResults map[string]interface{} `json:"query_results"`
+		Kind          string                        `json:"kind"`
+		Generator     interface{}                   `json:"generator_config"`
+		Queries       []string                      `json:"queries"`
+		QueryResults  map[string]interface{}        `json:"query_results"`
+		Samples       *DirectSamples                `json:"samples,omitempty"`
+		WindowResults map[string]map[string]float64 `json:"window_results,omitempty"`
 	}
 
 	return json.Marshal(&QueryExecutor{
@@ -313,7 +399,9 @@ func (dqe *DirectQueryExecutor) MarshalJSON() ([]byte, error) {
 			}
 			return keys
 		}(),
-		QueryResults: dqe.QueryResults,
+		QueryResults:  dqe.QueryResults,
+		Samples:       dqe.Samples,
+		WindowResults: dqe.WindowResults,
 	})
 }
 
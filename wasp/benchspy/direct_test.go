@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -390,3 +391,70 @@ func TestBenchSpy_DirectQueryExecutor_TimeRange(t *testing.T) {
 		executor.TimeRange(start, end)
 	})
 }
+
+type capacityGun struct {
+	capacity int64
+}
+
+func (m *capacityGun) Call(l *wasp.Generator) *wasp.Response {
+	if l.Stats().CurrentRPS.Load() > m.capacity {
+		return &wasp.Response{Failed: true, Error: "overloaded"}
+	}
+	return &wasp.Response{Data: "ok"}
+}
+
+func TestBenchSpy_DirectQueryExecutor_MaxSustainableRPS(t *testing.T) {
+	gen, err := wasp.NewGenerator(&wasp.Config{
+		T:        t,
+		GenName:  "adaptive",
+		LoadType: wasp.RPS,
+		Gun:      &capacityGun{capacity: 15},
+		Adaptive: &wasp.AdaptiveConfig{
+			StartRPS:     10,
+			StepRPS:      10,
+			StepDuration: time.Second,
+			Precision:    5,
+			MaxErrorRate: 0.1,
+			MaxDuration:  10 * time.Second,
+		},
+	})
+	require.NoError(t, err)
+	gen.Run(true)
+
+	executor, err := NewStandardDirectQueryExecutor(gen)
+	require.NoError(t, err)
+	require.Contains(t, executor.Queries, string(MaxSustainableRPS))
+	require.NoError(t, executor.Execute(context.Background()))
+
+	results, err := ResultsAs(0.0, executor, string(MaxSustainableRPS))
+	require.NoError(t, err)
+	require.Equal(t, 15.0, results[string(MaxSustainableRPS)])
+}
+
+func TestBenchSpy_DirectQueryExecutor_RecordedRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.jsonl.gz")
+	gen, err := wasp.NewGenerator(&wasp.Config{
+		T:        t,
+		GenName:  "recorded",
+		LoadType: wasp.RPS,
+		Schedule: wasp.Plain(10, 2*time.Second),
+		Gun:      wasp.NewMockGun(&wasp.MockGunConfig{CallSleep: 20 * time.Millisecond}),
+		Recorder: &wasp.RecorderConfig{Path: path},
+	})
+	require.NoError(t, err)
+	gen.Run(true)
+
+	run, err := wasp.LoadRun(path)
+	require.NoError(t, err)
+	executor, err := NewStandardDirectQueryExecutor(run.Generator())
+	require.NoError(t, err)
+	require.NoError(t, executor.Execute(context.Background()))
+	results, err := ResultsAs(0.0, executor, string(MedianLatency), string(ErrorRate))
+	require.NoError(t, err)
+	require.InDelta(t, 20.0, results[string(MedianLatency)], 5.0)
+	require.Equal(t, 0.0, results[string(ErrorRate)])
+
+	live, err := NewStandardDirectQueryExecutor(gen)
+	require.NoError(t, err)
+	require.NoError(t, executor.IsComparable(live))
+}
@@ -0,0 +1,564 @@
+package benchspy
+
+import (
+	"context"
+	"encoding/json"
+	goerrors "errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+	"github.com/montanaflynn/stats"
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+)
+
+const DEFAULT_DOCKER_DISCOVERY_INTERVAL = time.Second
+
+// DefaultDockerLabels select all containers started by the framework
+var DefaultDockerLabels = map[string]string{"framework": "ctf"}
+
+// DockerConfig selects containers sampled by DockerQueryExecutor
+type DockerConfig struct {
+	// Labels containers must have, defaults to DefaultDockerLabels
+	Labels map[string]string
+	// NameRegexPattern additionally filters containers by name, optional
+	NameRegexPattern string
+	// DiscoveryInterval is how often containers started during the test are looked for, defaults to 1s
+	DiscoveryInterval time.Duration
+}
+
+// DockerStatsSample is resource usage of a container at one point in time, network and block IO are counters since the container start
+type DockerStatsSample struct {
+	Time time.Time `json:"time"`
+	// CPUPercent is usage in percent of a single CPU, like in "docker stats"
+	CPUPercent      float64 `json:"cpu_percent"`
+	MemoryBytes     uint64  `json:"memory_bytes"`
+	NetworkRxBytes  uint64  `json:"network_rx_bytes"`
+	NetworkTxBytes  uint64  `json:"network_tx_bytes"`
+	BlockReadBytes  uint64  `json:"block_read_bytes"`
+	BlockWriteBytes uint64  `json:"block_write_bytes"`
+}
+
+// dockerStatsClient is the part of Docker API used by DockerQueryExecutor
+type dockerStatsClient interface {
+	ContainerList(ctx context.Context, options client.ContainerListOptions) (client.ContainerListResult, error)
+	ContainerStats(ctx context.Context, containerID string, options client.ContainerStatsOptions) (client.ContainerStatsResult, error)
+	Close() error
+}
+
+// DockerQueryExecutor samples Docker stats of selected containers while the test is running, so resource usage
+// can be compared without Prometheus and cAdvisor. Start it before the load and pass it to the report with WithQueryExecutors,
+// sampling stops when the report fetches data. Time series are stored with the report, results are standard resource metrics
+// computed from samples within the test time range and keyed by container name.
+type DockerQueryExecutor struct {
+	KindName         string            `json:"kind"`
+	StartTime        time.Time         `json:"start_time"`
+	EndTime          time.Time         `json:"end_time"`
+	Labels           map[string]string `json:"labels"`
+	NameRegexPattern string            `json:"name_regex_pattern,omitempty"`
+	// Samples are time series of every sampled container keyed by container name
+	Samples map[string][]DockerStatsSample `json:"samples"`
+	// QueryResults are keyed by metric name, every value is a map[string]float64 keyed by container name
+	QueryResults map[string]interface{} `json:"query_results"`
+
+	discoveryInterval time.Duration
+	nameRegex         *regexp.Regexp
+	client            dockerStatsClient
+	mu                *sync.Mutex
+	wg                *sync.WaitGroup
+	cancel            context.CancelFunc
+	streamed          map[string]bool
+}
+
+// NewDockerQueryExecutor creates an executor sampling containers selected by the config, nil config selects all framework containers
+func NewDockerQueryExecutor(cfg *DockerConfig) (*DockerQueryExecutor, error) {
+	if cfg == nil {
+		cfg = &DockerConfig{}
+	}
+	labels := cfg.Labels
+	if len(labels) == 0 {
+		labels = DefaultDockerLabels
+	}
+	interval := cfg.DiscoveryInterval
+	if interval <= 0 {
+		interval = DEFAULT_DOCKER_DISCOVERY_INTERVAL
+	}
+
+	d := &DockerQueryExecutor{
+		KindName:          string(StandardQueryExecutor_Docker),
+		Labels:            labels,
+		NameRegexPattern:  cfg.NameRegexPattern,
+		Samples:           make(map[string][]DockerStatsSample),
+		QueryResults:      make(map[string]interface{}),
+		discoveryInterval: interval,
+		mu:                &sync.Mutex{},
+		wg:                &sync.WaitGroup{},
+		streamed:          make(map[string]bool),
+	}
+	if cfg.NameRegexPattern != "" {
+		re, err := regexp.Compile(cfg.NameRegexPattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to compile container name pattern %s", cfg.NameRegexPattern)
+		}
+		d.nameRegex = re
+	}
+	return d, nil
+}
+
+// Start connects to Docker using environment settings and starts sampling stats of selected containers in the background
+func (d *DockerQueryExecutor) Start(ctx context.Context) error {
+	if d.client == nil {
+		c, err := client.New(client.FromEnv)
+		if err != nil {
+			return errors.Wrap(err, "failed to create Docker client")
+		}
+		d.client = c
+	}
+	return d.start(ctx)
+}
+
+func (d *DockerQueryExecutor) start(ctx context.Context) error {
+	d.mu.Lock()
+	if d.cancel != nil {
+		d.mu.Unlock()
+		return errors.New("Docker stats sampling is already running")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	d.cancel = cancel
+	d.mu.Unlock()
+
+	// fail fast if Docker isn't reachable
+	if err := d.discover(ctx); err != nil {
+		d.Stop()
+		return err
+	}
+
+	L.Info().
+		Interface("Labels", d.Labels).
+		Str("Name pattern", d.NameRegexPattern).
+		Msg("Started sampling Docker stats")
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		ticker := time.NewTicker(d.discoveryInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := d.discover(ctx); err != nil && ctx.Err() == nil {
+					L.Warn().Err(err).Msg("Failed to look for new containers")
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stops sampling, it's called by Execute and is safe to call multiple times
+func (d *DockerQueryExecutor) Stop() {
+	d.mu.Lock()
+	cancel := d.cancel
+	d.mu.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel()
+	d.wg.Wait()
+}
+
+// discover starts streaming stats of selected containers that aren't streamed yet
+func (d *DockerQueryExecutor) discover(ctx context.Context) error {
+	filters := make(client.Filters)
+	for k, v := range d.Labels {
+		filters.Add("label", k+"="+v)
+	}
+	list, err := d.client.ContainerList(ctx, client.ContainerListOptions{Filters: filters})
+	if err != nil {
+		return errors.Wrap(err, "failed to list Docker containers")
+	}
+
+	for _, c := range list.Items {
+		name := containerName(c)
+		if d.nameRegex != nil && !d.nameRegex.MatchString(name) {
+			continue
+		}
+		d.mu.Lock()
+		if d.streamed[c.ID] {
+			d.mu.Unlock()
+			continue
+		}
+		d.streamed[c.ID] = true
+		d.mu.Unlock()
+
+		L.Debug().
+			Str("Container", name).
+			Msg("Streaming Docker stats")
+
+		d.wg.Add(1)
+		go func(id, name string) {
+			defer d.wg.Done()
+			d.stream(ctx, id, name)
+		}(c.ID, name)
+	}
+	return nil
+}
+
+func containerName(c container.Summary) string {
+	if len(c.Names) == 0 {
+		return c.ID
+	}
+	return strings.TrimPrefix(c.Names[0], "/")
+}
+
+// stream records stats of the container until it stops or sampling is stopped, Docker sends them every second
+func (d *DockerQueryExecutor) stream(ctx context.Context, id, name string) {
+	res, err := d.client.ContainerStats(ctx, id, client.ContainerStatsOptions{Stream: true})
+	if err != nil {
+		if ctx.Err() == nil {
+			L.Warn().Err(err).Str("Container", name).Msg("Failed to stream Docker stats")
+		}
+		return
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	decoder := json.NewDecoder(res.Body)
+	for {
+		var s container.StatsResponse
+		if err := decoder.Decode(&s); err != nil {
+			return
+		}
+		sample, ok := newDockerStatsSample(&s)
+		if !ok {
+			continue
+		}
+		d.mu.Lock()
+		d.Samples[name] = append(d.Samples[name], sample)
+		d.mu.Unlock()
+	}
+}
+
+// newDockerStatsSample converts Docker stats, the first streamed stats have no previous CPU usage and are skipped
+func newDockerStatsSample(s *container.StatsResponse) (DockerStatsSample, bool) {
+	if s.Read.IsZero() || s.PreCPUStats.SystemUsage == 0 {
+		return DockerStatsSample{}, false
+	}
+	sample := DockerStatsSample{Time: s.Read}
+
+	cpuDelta := float64(s.CPUStats.CPUUsage.TotalUsage) - float64(s.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(s.CPUStats.SystemUsage) - float64(s.PreCPUStats.SystemUsage)
+	onlineCPUs := float64(s.CPUStats.OnlineCPUs)
+	if onlineCPUs == 0 {
+		onlineCPUs = float64(len(s.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		sample.CPUPercent = cpuDelta / systemDelta * onlineCPUs * 100
+	}
+
+	// page cache isn't counted, like in "docker stats"
+	sample.MemoryBytes = s.MemoryStats.Usage
+	cache, ok := s.MemoryStats.Stats["inactive_file"] // cgroup v2
+	if !ok {
+		cache = s.MemoryStats.Stats["total_inactive_file"] // cgroup v1
+	}
+	if cache < sample.MemoryBytes {
+		sample.MemoryBytes -= cache
+	}
+
+	for _, n := range s.Networks {
+		sample.NetworkRxBytes += n.RxBytes
+		sample.NetworkTxBytes += n.TxBytes
+	}
+	for _, entry := range s.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			sample.BlockReadBytes += entry.Value
+		case "write":
+			sample.BlockWriteBytes += entry.Value
+		}
+	}
+	return sample, true
+}
+
+// Kind returns the type of the query executor as a string.
+func (d *DockerQueryExecutor) Kind() string {
+	return d.KindName
+}
+
+// Results returns resource metrics keyed by metric name, values are map[string]float64 keyed by container name
+func (d *DockerQueryExecutor) Results() map[string]interface{} {
+	return d.QueryResults
+}
+
+// TimeRange sets the range of samples used for results
+func (d *DockerQueryExecutor) TimeRange(startTime, endTime time.Time) {
+	d.StartTime = startTime
+	d.EndTime = endTime
+}
+
+// Validate checks that there are samples, ex.: that Start was called before the test
+func (d *DockerQueryExecutor) Validate() error {
+	if len(d.Labels) == 0 {
+		return errors.New("no container labels provided")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.Samples) == 0 && d.cancel == nil {
+		return errors.New("no Docker stats were sampled, start the executor before the test")
+	}
+	return nil
+}
+
+// IsComparable checks that the other executor samples the same containers
+func (d *DockerQueryExecutor) IsComparable(other QueryExecutor) error {
+	otherType := reflect.TypeOf(other)
+	if otherType != reflect.TypeOf(d) {
+		return fmt.Errorf("expected type %s, got %s", reflect.TypeOf(d), otherType)
+	}
+	asDocker := other.(*DockerQueryExecutor)
+
+	if !reflect.DeepEqual(d.Labels, asDocker.Labels) {
+		return fmt.Errorf("container labels are different. Expected %v, got %v", d.Labels, asDocker.Labels)
+	}
+	if d.NameRegexPattern != asDocker.NameRegexPattern {
+		return fmt.Errorf("container name patterns are different. Expected %s, got %s", d.NameRegexPattern, asDocker.NameRegexPattern)
+	}
+	return nil
+}
+
+// Execute stops sampling and computes resource metrics of every container from samples within the time range
+func (d *DockerQueryExecutor) Execute(_ context.Context) error {
+	d.Stop()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	L.Info().
+		Int("Containers", len(d.Samples)).
+		Msg("Computing Docker resource metrics")
+
+	results := make(map[string]map[string]float64)
+	for _, metric := range DockerResourceMetrics {
+		results[string(metric)] = make(map[string]float64)
+	}
+
+	for name, samples := range d.Samples {
+		inRange := make([]DockerStatsSample, 0, len(samples))
+		for _, s := range samples {
+			if (d.StartTime.IsZero() || !s.Time.Before(d.StartTime)) && (d.EndTime.IsZero() || !s.Time.After(d.EndTime)) {
+				inRange = append(inRange, s)
+			}
+		}
+		if len(inRange) == 0 {
+			L.Warn().Str("Container", name).Msg("No Docker stats samples in the test time range")
+			continue
+		}
+		sort.Slice(inRange, func(i, j int) bool { return inRange[i].Time.Before(inRange[j].Time) })
+
+		cpu := make([]float64, 0, len(inRange))
+		mem := make([]float64, 0, len(inRange))
+		for _, s := range inRange {
+			cpu = append(cpu, s.CPUPercent)
+			mem = append(mem, float64(s.MemoryBytes))
+		}
+		for metric, values := range map[StandardResourceMetric][]float64{MedianCPUUsage: cpu, MedianMemUsage: mem} {
+			v, _ := stats.Median(values)
+			results[string(metric)][name] = v
+		}
+		for metric, values := range map[StandardResourceMetric][]float64{P95CPUUsage: cpu, P95MemUsage: mem} {
+			v, _ := stats.Percentile(values, 95)
+			results[string(metric)][name] = v
+		}
+		for metric, values := range map[StandardResourceMetric][]float64{MaxCPUUsage: cpu, MaxMemUsage: mem} {
+			v, _ := stats.Max(values)
+			results[string(metric)][name] = v
+		}
+
+		first, last := inRange[0], inRange[len(inRange)-1]
+		results[string(NetworkRxBytes)][name] = counterDelta(first.NetworkRxBytes, last.NetworkRxBytes)
+		results[string(NetworkTxBytes)][name] = counterDelta(first.NetworkTxBytes, last.NetworkTxBytes)
+		results[string(BlockReadBytes)][name] = counterDelta(first.BlockReadBytes, last.BlockReadBytes)
+		results[string(BlockWriteBytes)][name] = counterDelta(first.BlockWriteBytes, last.BlockWriteBytes)
+	}
+
+	for metric, byContainer := range results {
+		d.QueryResults[metric] = byContainer
+	}
+
+	L.Info().
+		Int("Containers", len(d.Samples)).
+		Msg("Docker resource metrics computed")
+
+	return nil
+}
+
+// counterDelta returns the increase of a counter, counters restart from zero when the container restarts
+func counterDelta(first, last uint64) float64 {
+	if last < first {
+		return float64(last)
+	}
+	return float64(last - first)
+}
+
+// UnmarshalJSON decodes JSON data into a DockerQueryExecutor, converting results back to map[string]float64
+func (d *DockerQueryExecutor) UnmarshalJSON(data []byte) error {
+	type Alias DockerQueryExecutor
+	var raw struct {
+		Alias
+		QueryResults map[string]map[string]float64 `json:"query_results"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*d = DockerQueryExecutor(raw.Alias)
+	d.QueryResults = make(map[string]interface{})
+	for metric, byContainer := range raw.QueryResults {
+		d.QueryResults[metric] = byContainer
+	}
+	d.mu = &sync.Mutex{}
+	d.wg = &sync.WaitGroup{}
+	d.streamed = make(map[string]bool)
+	return nil
+}
+
+// DockerResultsByContainer are Docker resource metrics keyed by container and metric name
+type DockerResultsByContainer map[string]map[string]float64
+
+// MustAllDockerResults returns results of all Docker executors of the report keyed by container and metric name
+func MustAllDockerResults(sr *StandardReport) DockerResultsByContainer {
+	results := make(DockerResultsByContainer)
+	for _, queryExecutor := range sr.QueryExecutors {
+		if !strings.EqualFold(queryExecutor.Kind(), string(StandardQueryExecutor_Docker)) {
+			continue
+		}
+		byMetric, err := ResultsAs(map[string]float64{}, queryExecutor)
+		if err != nil {
+			panic(err)
+		}
+		for metric, byContainer := range byMetric {
+			for name, v := range byContainer {
+				if _, ok := results[name]; !ok {
+					results[name] = make(map[string]float64)
+				}
+				results[name][metric] = v
+			}
+		}
+	}
+	return results
+}
+
+// CompareDockerWithThresholds checks whether resource usage of any container sampled by Docker executors has increased
+// more than the threshold in percent: CPU and memory thresholds apply to median, p95 and max usage, network and block IO thresholds
+// to bytes transferred during the test. Containers are matched by name, so they need stable names across runs.
+func CompareDockerWithThresholds(cpuThreshold, memThreshold, networkThreshold, blockIOThreshold float64, currentReport, previousReport *StandardReport) (bool, error) {
+	if currentReport == nil || previousReport == nil {
+		return true, errors.New("one or both reports are nil")
+	}
+
+	L.Info().
+		Str("Current report", currentReport.CommitOrTag).
+		Str("Previous report", previousReport.CommitOrTag).
+		Float64("CPU threshold", cpuThreshold).
+		Float64("Memory threshold", memThreshold).
+		Float64("Network threshold", networkThreshold).
+		Float64("Block IO threshold", blockIOThreshold).
+		Msg("Comparing Docker metrics with thresholds")
+
+	var errs []error
+	for name, threshold := range map[string]float64{"cpu": cpuThreshold, "memory": memThreshold, "network": networkThreshold, "block IO": blockIOThreshold} {
+		if threshold < 0 || threshold > 100 {
+			errs = append(errs, fmt.Errorf("%s threshold %.4f is not in the range [0, 100]", name, threshold))
+		}
+	}
+	if len(errs) > 0 {
+		return true, goerrors.Join(errs...)
+	}
+
+	thresholds := map[StandardResourceMetric]float64{
+		MedianCPUUsage:  cpuThreshold,
+		P95CPUUsage:     cpuThreshold,
+		MaxCPUUsage:     cpuThreshold,
+		MedianMemUsage:  memThreshold,
+		P95MemUsage:     memThreshold,
+		MaxMemUsage:     memThreshold,
+		NetworkRxBytes:  networkThreshold,
+		NetworkTxBytes:  networkThreshold,
+		BlockReadBytes:  blockIOThreshold,
+		BlockWriteBytes: blockIOThreshold,
+	}
+
+	allCurrentResults := MustAllDockerResults(currentReport)
+	allPreviousResults := MustAllDockerResults(previousReport)
+
+	errors := make(map[string][]error)
+	for _, name := range sortedKeys(allCurrentResults) {
+		previousForContainer, ok := allPreviousResults[name]
+		if !ok {
+			errors[name] = append(errors[name], fmt.Errorf("container %s results were missing from previous report", name))
+			continue
+		}
+		for _, metric := range DockerResourceMetrics {
+			currentMetric, ok := allCurrentResults[name][string(metric)]
+			if !ok {
+				continue
+			}
+			previousMetric, ok := previousForContainer[string(metric)]
+			if !ok {
+				errors[name] = append(errors[name], fmt.Errorf("%s metric results were missing from previous report for container %s", metric, name))
+				continue
+			}
+			diffPercentage := calculateDiffPercentage(currentMetric, previousMetric)
+			if diffPercentage > thresholds[metric] {
+				errors[name] = append(errors[name], fmt.Errorf("%s is %.4f%% different, which is higher than the threshold %.4f%%", metric, diffPercentage, thresholds[metric]))
+			}
+		}
+	}
+
+	PrintStandardDockerMetrics(currentReport, previousReport)
+
+	L.Info().
+		Str("Current report", currentReport.CommitOrTag).
+		Str("Previous report", previousReport.CommitOrTag).
+		Int("Number of meaningful differences", len(errors)).
+		Msg("Finished comparing Docker metrics with thresholds")
+
+	return len(errors) > 0, concatenateGeneratorErrors(errors)
+}
+
+// PrintStandardDockerMetrics outputs a comparison of Docker resource metrics of every container between two reports
+func PrintStandardDockerMetrics(currentReport, previousReport *StandardReport) {
+	currentResults := MustAllDockerResults(currentReport)
+	previousResults := MustAllDockerResults(previousReport)
+
+	for _, name := range sortedKeys(currentResults) {
+		table := tablewriter.NewWriter(os.Stderr)
+		table.SetHeader([]string{"Metric", previousReport.CommitOrTag, currentReport.CommitOrTag, "Diff %"})
+
+		for _, metric := range DockerResourceMetrics {
+			metricString := string(metric)
+			diff := calculateDiffPercentage(currentResults[name][metricString], previousResults[name][metricString])
+			table.Append([]string{metricString, fmt.Sprintf("%.4f", previousResults[name][metricString]), fmt.Sprintf("%.4f", currentResults[name][metricString]), fmt.Sprintf("%.4f", diff)})
+		}
+
+		table.SetBorder(true)
+		table.SetRowLine(true)
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+
+		title := "Container: " + name
+		fmt.Println(title)
+		fmt.Println(strings.Repeat("=", len(title)))
+
+		table.Render()
+	}
+}
@@ -0,0 +1,235 @@
+package benchspy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDockerClient struct {
+	containers []container.Summary
+	stats      map[string][]container.StatsResponse
+
+	mu          sync.Mutex
+	statsCalls  map[string]int
+	listFilters client.Filters
+}
+
+func (f *fakeDockerClient) ContainerList(_ context.Context, options client.ContainerListOptions) (client.ContainerListResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.listFilters = options.Filters
+	return client.ContainerListResult{Items: f.containers}, nil
+}
+
+func (f *fakeDockerClient) ContainerStats(_ context.Context, containerID string, _ client.ContainerStatsOptions) (client.ContainerStatsResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.statsCalls == nil {
+		f.statsCalls = make(map[string]int)
+	}
+	f.statsCalls[containerID]++
+	stats, ok := f.stats[containerID]
+	if !ok {
+		return client.ContainerStatsResult{}, errors.New("no such container")
+	}
+	var body bytes.Buffer
+	for _, s := range stats {
+		if err := json.NewEncoder(&body).Encode(s); err != nil {
+			return client.ContainerStatsResult{}, err
+		}
+	}
+	return client.ContainerStatsResult{Body: io.NopCloser(&body)}, nil
+}
+
+func (f *fakeDockerClient) Close() error {
+	return nil
+}
+
+func newFakeStats(read time.Time, cpu, preCPU, system, preSystem, mem, rx, written uint64) container.StatsResponse {
+	s := container.StatsResponse{Read: read}
+	s.CPUStats.CPUUsage.TotalUsage = cpu
+	s.CPUStats.SystemUsage = system
+	s.CPUStats.OnlineCPUs = 2
+	s.PreCPUStats.CPUUsage.TotalUsage = preCPU
+	s.PreCPUStats.SystemUsage = preSystem
+	s.MemoryStats.Usage = mem
+	s.MemoryStats.Stats = map[string]uint64{"inactive_file": 200}
+	s.Networks = map[string]container.NetworkStats{"eth0": {RxBytes: rx, TxBytes: rx / 2}}
+	s.BlkioStats.IoServiceBytesRecursive = []container.BlkioStatEntry{{Op: "Write", Value: written}, {Op: "Total", Value: written}}
+	return s
+}
+
+func TestBenchSpy_DockerQueryExecutor(t *testing.T) {
+	baseTime := time.Now().Truncate(time.Second)
+	fake := &fakeDockerClient{
+		containers: []container.Summary{
+			{ID: "1", Names: []string{"/node-1"}},
+			{ID: "2", Names: []string{"/postgres"}},
+		},
+		stats: map[string][]container.StatsResponse{
+			"1": {
+				// first stats have no previous CPU usage
+				newFakeStats(baseTime, 100, 0, 1000, 0, 1000, 0, 0),
+				newFakeStats(baseTime.Add(time.Second), 150, 100, 1100, 1000, 1000, 100, 10),
+				newFakeStats(baseTime.Add(2*time.Second), 175, 150, 1200, 1100, 1200, 300, 30),
+				newFakeStats(baseTime.Add(3*time.Second), 187, 175, 1300, 1200, 800, 1000, 40),
+			},
+		},
+	}
+
+	newExecutor := func(t *testing.T) *DockerQueryExecutor {
+		qe, err := NewDockerQueryExecutor(&DockerConfig{NameRegexPattern: "^node", DiscoveryInterval: 10 * time.Millisecond})
+		require.NoError(t, err)
+		qe.client = fake
+		return qe
+	}
+
+	t.Run("sample and execute", func(t *testing.T) {
+		qe := newExecutor(t)
+		require.Error(t, qe.Validate(), "executor wasn't started")
+
+		require.NoError(t, qe.start(context.Background()))
+		require.Error(t, qe.start(context.Background()), "executor is already running")
+		require.NoError(t, qe.Validate())
+
+		require.Eventually(t, func() bool {
+			qe.mu.Lock()
+			defer qe.mu.Unlock()
+			return len(qe.Samples["node-1"]) == 3
+		}, 5*time.Second, 10*time.Millisecond)
+		// give discovery a few more rounds, containers must be streamed only once
+		time.Sleep(50 * time.Millisecond)
+
+		qe.TimeRange(baseTime.Add(time.Second), baseTime.Add(2*time.Second))
+		require.NoError(t, qe.Execute(context.Background()))
+		qe.Stop()
+
+		fake.mu.Lock()
+		require.Equal(t, map[string]int{"1": 1}, fake.statsCalls)
+		require.Equal(t, client.Filters{"label": {"framework=ctf": true}}, fake.listFilters)
+		fake.mu.Unlock()
+
+		require.NotContains(t, qe.Samples, "postgres")
+		sample := qe.Samples["node-1"][0]
+		require.True(t, baseTime.Add(time.Second).Equal(sample.Time))
+		sample.Time = time.Time{}
+		require.Equal(t, DockerStatsSample{
+			CPUPercent:      100,
+			MemoryBytes:     800,
+			NetworkRxBytes:  100,
+			NetworkTxBytes:  50,
+			BlockWriteBytes: 10,
+		}, sample)
+
+		results := MustAllDockerResults(&StandardReport{QueryExecutors: []QueryExecutor{qe}})
+		require.Len(t, results, 1)
+		require.InDelta(t, 75, results["node-1"][string(MedianCPUUsage)], 0.0001)
+		require.InDelta(t, 100, results["node-1"][string(MaxCPUUsage)], 0.0001)
+		require.InDelta(t, 900, results["node-1"][string(MedianMemUsage)], 0.0001)
+		require.InDelta(t, 1000, results["node-1"][string(MaxMemUsage)], 0.0001)
+		require.InDelta(t, 200, results["node-1"][string(NetworkRxBytes)], 0.0001)
+		require.InDelta(t, 100, results["node-1"][string(NetworkTxBytes)], 0.0001)
+		require.InDelta(t, 0, results["node-1"][string(BlockReadBytes)], 0.0001)
+		require.InDelta(t, 20, results["node-1"][string(BlockWriteBytes)], 0.0001)
+	})
+
+	t.Run("store and compare", func(t *testing.T) {
+		newReport := func(ref string) *StandardReport {
+			qe := newExecutor(t)
+			require.NoError(t, qe.start(context.Background()))
+			require.Eventually(t, func() bool {
+				qe.mu.Lock()
+				defer qe.mu.Unlock()
+				return len(qe.Samples["node-1"]) == 3
+			}, 5*time.Second, 10*time.Millisecond)
+			qe.TimeRange(baseTime, baseTime.Add(time.Hour))
+			require.NoError(t, qe.Execute(context.Background()))
+			return &StandardReport{
+				BasicData:      BasicData{TestName: "docker", CommitOrTag: ref},
+				QueryExecutors: []QueryExecutor{qe},
+			}
+		}
+		previous, current := newReport("v1"), newReport("v2")
+
+		storage := &LocalStorage{Directory: t.TempDir()}
+		_, err := storage.Store(previous.TestName, previous.CommitOrTag, previous)
+		require.NoError(t, err)
+		loaded := &StandardReport{}
+		require.NoError(t, storage.Load(previous.TestName, previous.CommitOrTag, loaded))
+
+		loadedExecutor, ok := loaded.QueryExecutors[0].(*DockerQueryExecutor)
+		require.True(t, ok)
+		require.Len(t, loadedExecutor.Samples["node-1"], 3)
+		require.Equal(t, MustAllDockerResults(previous), MustAllDockerResults(loaded))
+		require.NoError(t, current.IsComparable(loaded))
+		require.NoError(t, loadedExecutor.Validate())
+
+		failed, err := CompareDockerWithThresholds(1, 1, 1, 1, current, loaded)
+		require.NoError(t, err)
+		require.False(t, failed)
+
+		currentExecutor := current.QueryExecutors[0].(*DockerQueryExecutor)
+		currentExecutor.QueryResults[string(MaxMemUsage)].(map[string]float64)["node-1"] *= 1.5
+		failed, err = CompareDockerWithThresholds(1, 1, 1, 1, current, loaded)
+		require.Error(t, err)
+		require.True(t, failed)
+		require.Contains(t, err.Error(), "max_mem_usage is 50.0000% different")
+
+		failed, err = CompareDockerWithThresholds(1, 101, 1, 1, current, loaded)
+		require.Error(t, err)
+		require.True(t, failed)
+		require.Contains(t, err.Error(), "memory threshold 101.0000 is not in the range [0, 100]")
+
+		renamed := &StandardReport{BasicData: loaded.BasicData, QueryExecutors: []QueryExecutor{&DockerQueryExecutor{
+			KindName:     string(StandardQueryExecutor_Docker),
+			Labels:       DefaultDockerLabels,
+			QueryResults: map[string]interface{}{string(MaxMemUsage): map[string]float64{"node-2": 1}},
+		}}}
+		failed, err = CompareDockerWithThresholds(1, 1, 1, 1, current, renamed)
+		require.Error(t, err)
+		require.True(t, failed)
+		require.Contains(t, err.Error(), "container node-1 results were missing from previous report")
+	})
+
+	t.Run("not comparable", func(t *testing.T) {
+		qe := newExecutor(t)
+		other, err := NewDockerQueryExecutor(&DockerConfig{NameRegexPattern: "^db"})
+		require.NoError(t, err)
+		require.ErrorContains(t, qe.IsComparable(other), "container name patterns are different")
+
+		other, err = NewDockerQueryExecutor(&DockerConfig{Labels: map[string]string{"app": "node"}, NameRegexPattern: "^node"})
+		require.NoError(t, err)
+		require.ErrorContains(t, qe.IsComparable(other), "container labels are different")
+
+		require.Error(t, qe.IsComparable(&MockQueryExecutor{}))
+
+		report := &StandardReport{QueryExecutors: []QueryExecutor{qe}}
+		require.ErrorContains(t, report.IsComparable(&StandardReport{}), "executors count is different")
+	})
+
+	t.Run("invalid name pattern", func(t *testing.T) {
+		_, err := NewDockerQueryExecutor(&DockerConfig{NameRegexPattern: "("})
+		require.Error(t, err)
+	})
+
+	t.Run("cgroup v1 memory", func(t *testing.T) {
+		s := newFakeStats(baseTime, 200, 100, 1200, 1000, 1000, 0, 0)
+		s.CPUStats.OnlineCPUs = 0
+		s.CPUStats.CPUUsage.PercpuUsage = []uint64{100, 100, 0, 0}
+		s.MemoryStats.Stats = map[string]uint64{"total_inactive_file": 400}
+		sample, ok := newDockerStatsSample(&s)
+		require.True(t, ok)
+		require.InDelta(t, 200, sample.CPUPercent, 0.0001)
+		require.Equal(t, uint64(600), sample.MemoryBytes)
+	})
+}
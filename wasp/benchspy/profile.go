@@ -0,0 +1,142 @@
+package benchspy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/wasp"
+)
+
+// NewStandardReportFromProfile waits for all generators of the profile to finish, creates a report with executors
+// for every one of them and fetches its data. Generators can run with different schedules, per-generator queries
+// use the time range of their own generator. Options are the same as for NewStandardReport, except for WithGenerators.
+// Time windows recorded by profile hooks, ex.: havoc.ChaosPlan experiments, are added to the report.
+func NewStandardReportFromProfile(ctx context.Context, profile *wasp.Profile, commitOrTag string, opts ...StandardReportOption) (*StandardReport, error) {
+	if profile == nil || len(profile.Generators) == 0 {
+		return nil, errors.New("profile has no generators")
+	}
+
+	config := standardReportConfig{}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if len(config.generators) > 0 {
+		return nil, errors.New("generators are taken from the profile, don't set them with WithGenerators")
+	}
+
+	names := make(map[string]bool)
+	for _, g := range profile.Generators {
+		if g == nil || g.Cfg == nil {
+			return nil, errors.New("one of the profile's generators is nil")
+		}
+		if names[g.Cfg.GenName] {
+			return nil, fmt.Errorf("generator names must be unique in a report, %s is used more than once", g.Cfg.GenName)
+		}
+		names[g.Cfg.GenName] = true
+	}
+
+	L.Info().
+		Str("Profile ID", profile.ProfileID).
+		Int("Generators", len(profile.Generators)).
+		Msg("Waiting for profile to finish before creating a report")
+
+	profile.Wait()
+
+	opts = append(opts, WithGenerators(profile.Generators...))
+	if len(profile.Windows()) > 0 {
+		opts = append(opts, WithTimeWindows(append(config.windows, profile.Windows()...)...))
+	}
+	report, err := NewStandardReport(commitOrTag, opts...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create report for profile %s", profile.ProfileID)
+	}
+
+	if err := report.FetchData(ctx); err != nil {
+		return nil, errors.Wrapf(err, "failed to fetch data for profile %s", profile.ProfileID)
+	}
+
+	return report, nil
+}
+
+// AggregateDirectResults computes standard load metrics of all Direct executors of the report together,
+// as if all calls were made by a single generator. Latencies are pooled, so generators that made more calls,
+// ex.: because of a longer schedule or a higher rate, weigh more. Percentiles are nearest-rank, and have histogram
+// precision for reports loaded from storage.
+func AggregateDirectResults(sr *StandardReport) (map[string]float64, error) {
+	var merged *weightedSamples
+	var calls, failed int64
+	for _, qe := range sr.QueryExecutors {
+		dqe, ok := qe.(*DirectQueryExecutor)
+		if !ok {
+			continue
+		}
+		samples, c, f, err := directSamples(dqe)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get samples of generator %s", dqe.GeneratorName())
+		}
+		merged = mergeWeightedSamples(merged, samples)
+		calls += c
+		failed += f
+	}
+	if merged == nil || merged.total == 0 {
+		return nil, errors.New("report has no Direct executors with samples")
+	}
+
+	return map[string]float64{
+		string(MedianLatency):       merged.percentile(50),
+		string(Percentile95Latency): merged.percentile(95),
+		string(Percentile99Latency): merged.percentile(99),
+		string(MaxLatency):          merged.values[len(merged.values)-1],
+		string(ErrorRate):           float64(failed) / float64(calls),
+	}, nil
+}
+
+func mergeWeightedSamples(a, b *weightedSamples) *weightedSamples {
+	if a == nil {
+		return b
+	}
+	counts := make(map[float64]int64)
+	for _, ws := range []*weightedSamples{a, b} {
+		for i, v := range ws.values {
+			counts[v] += ws.counts[i]
+		}
+	}
+	merged := &weightedSamples{total: a.total + b.total}
+	for v := range counts {
+		merged.values = append(merged.values, v)
+	}
+	sort.Float64s(merged.values)
+	for _, v := range merged.values {
+		merged.counts = append(merged.counts, counts[v])
+	}
+	return merged
+}
+
+// CompareGeneratorsDirect returns differences in percent of Direct metrics of every generator of the report against
+// the baseline generator, ex.: to compare two implementations loaded side by side in one profile. Standard metrics
+// are latencies and rates, so generators with different schedules can be compared.
+func CompareGeneratorsDirect(sr *StandardReport, baselineGenerator string) (DirectResultsByGenerator, error) {
+	results := MustAllDirectResults(sr)
+	baseline, ok := results[baselineGenerator]
+	if !ok {
+		return nil, fmt.Errorf("baseline generator %s has no Direct results", baselineGenerator)
+	}
+
+	diffs := make(DirectResultsByGenerator)
+	for generator, metrics := range results {
+		if generator == baselineGenerator {
+			continue
+		}
+		diffs[generator] = make(map[string]float64)
+		for metric, value := range metrics {
+			if baselineValue, ok := baseline[metric]; ok {
+				diffs[generator][metric] = calculateDiffPercentage(value, baselineValue)
+			}
+		}
+	}
+
+	return diffs, nil
+}
@@ -0,0 +1,96 @@
+package benchspy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/wasp"
+)
+
+func TestBenchSpy_NewStandardReportFromProfile(t *testing.T) {
+	newGenerator := func(name string, rps int64, duration, callSleep time.Duration) *wasp.Generator {
+		gen, err := wasp.NewGenerator(&wasp.Config{
+			T:        t,
+			GenName:  name,
+			LoadType: wasp.RPS,
+			Schedule: wasp.Plain(rps, duration),
+			Gun:      wasp.NewMockGun(&wasp.MockGunConfig{CallSleep: callSleep}),
+		})
+		require.NoError(t, err)
+		return gen
+	}
+
+	fast := newGenerator("fast", 10, 2*time.Second, 10*time.Millisecond)
+	slow := newGenerator("slow", 5, 3*time.Second, 40*time.Millisecond)
+	profile := wasp.NewProfile().Add(fast, nil).Add(slow, nil)
+	_, err := profile.Run(false)
+	require.NoError(t, err)
+
+	report, err := NewStandardReportFromProfile(context.Background(), profile, "v1", WithStandardQueries(StandardQueryExecutor_Direct))
+	require.NoError(t, err)
+	require.Len(t, report.QueryExecutors, 2)
+	require.Len(t, report.GeneratorConfigs, 2)
+
+	fastStart, fastEnd, err := report.GeneratorTimeRange("fast")
+	require.NoError(t, err)
+	slowStart, slowEnd, err := report.GeneratorTimeRange("slow")
+	require.NoError(t, err)
+	require.Equal(t, report.TestStart, minTime(fastStart, slowStart))
+	require.Equal(t, report.TestEnd, slowEnd)
+	require.True(t, fastEnd.Before(slowEnd), "generators ran with different schedules")
+
+	results := MustAllDirectResults(report)
+	require.Greater(t, results["slow"][string(MedianLatency)], results["fast"][string(MedianLatency)])
+
+	t.Run("aggregate", func(t *testing.T) {
+		aggregated, err := AggregateDirectResults(report)
+		require.NoError(t, err)
+		// fast made 20 calls and slow 15, so the pooled median is a latency of fast
+		require.Less(t, aggregated[string(MedianLatency)], results["slow"][string(MedianLatency)])
+		require.InDelta(t, results["fast"][string(MedianLatency)], aggregated[string(MedianLatency)], 5)
+		require.InDelta(t, results["slow"][string(MaxLatency)], aggregated[string(MaxLatency)], 0.001)
+		require.Equal(t, 0.0, aggregated[string(ErrorRate)])
+
+		// reports loaded from storage are aggregated from stored histograms
+		storage := &LocalStorage{Directory: t.TempDir()}
+		_, err = storage.Store(report.TestName, report.CommitOrTag, report)
+		require.NoError(t, err)
+		loaded := &StandardReport{}
+		require.NoError(t, storage.Load(report.TestName, report.CommitOrTag, loaded))
+		loadedAggregated, err := AggregateDirectResults(loaded)
+		require.NoError(t, err)
+		for metric, value := range aggregated {
+			require.InEpsilon(t, value+1, loadedAggregated[metric]+1, 0.02, metric)
+		}
+	})
+
+	t.Run("compare generators", func(t *testing.T) {
+		diffs, err := CompareGeneratorsDirect(report, "fast")
+		require.NoError(t, err)
+		require.Len(t, diffs, 1)
+		require.Greater(t, diffs["slow"][string(MedianLatency)], 100.0)
+
+		_, err = CompareGeneratorsDirect(report, "unknown")
+		require.Error(t, err)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := NewStandardReportFromProfile(context.Background(), wasp.NewProfile(), "v1", WithStandardQueries(StandardQueryExecutor_Direct))
+		require.Error(t, err)
+		_, err = NewStandardReportFromProfile(context.Background(), profile, "v1", WithStandardQueries(StandardQueryExecutor_Direct), WithGenerators(fast))
+		require.Error(t, err)
+		duplicated := &wasp.Profile{ProfileID: "duplicated", Generators: []*wasp.Generator{fast, fast}}
+		_, err = NewStandardReportFromProfile(context.Background(), duplicated, "v1", WithStandardQueries(StandardQueryExecutor_Direct))
+		require.ErrorContains(t, err, "used more than once")
+	})
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,642 @@
+package benchspy
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"strings"
+	textTemplate "text/template"
+
+	"github.com/montanaflynn/stats"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+)
+
+const DefaultRenderDiffThreshold = 5.0
+
+// RenderConfig configures RenderHTML and RenderMarkdown
+type RenderConfig struct {
+	// Title of the rendered report, defaults to "Performance comparison"
+	Title string
+	// DiffThreshold is the change in percent of the latest report against the previous one
+	// above which a metric is marked as worse or better, defaults to 5
+	DiffThreshold float64
+}
+
+// Validate checks the config and sets defaults
+func (c *RenderConfig) Validate() error {
+	if c.DiffThreshold < 0 {
+		return errors.New("diff threshold must be >= 0")
+	}
+	if c.DiffThreshold == 0 {
+		c.DiffThreshold = DefaultRenderDiffThreshold
+	}
+	if c.Title == "" {
+		c.Title = "Performance comparison"
+	}
+	return nil
+}
+
+type renderedCell struct {
+	Text string
+	// Class is "worse", "better" or empty
+	Class string
+}
+
+type renderedTable struct {
+	Title  string
+	Header []string
+	Rows   [][]renderedCell
+}
+
+type chartTick struct {
+	Pos   float64
+	Label string
+}
+
+type chartLine struct {
+	Name   string
+	Color  string
+	Points string
+	// LegendY is the position of the line name in the legend
+	LegendY float64
+}
+
+type renderedChart struct {
+	Title  string
+	XLabel string
+	YLabel string
+	Lines  []chartLine
+	XTicks []chartTick
+	YTicks []chartTick
+}
+
+type renderedSection struct {
+	Title  string
+	Tables []renderedTable
+	Charts []renderedChart
+}
+
+type renderedComparison struct {
+	Title     string
+	Refs      []string
+	Latest    string
+	Previous  string
+	Threshold float64
+	// Worse lists metrics of the latest report that got worse by more than the threshold
+	Worse    []string
+	Sections []renderedSection
+}
+
+// chart geometry of the SVG view box
+const (
+	chartWidth        = 640
+	chartHeight       = 300
+	chartMarginLeft   = 70
+	chartMarginRight  = 20
+	chartMarginTop    = 20
+	chartMarginBottom = 45
+)
+
+var chartColors = []string{"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd", "#8c564b", "#e377c2", "#7f7f7f", "#bcbd22", "#17becf"}
+
+// cdfPercentiles are the points of latency distribution charts, the extreme tail is left out to keep the scale readable
+var cdfPercentiles = func() []float64 {
+	p := make([]float64, 0)
+	for i := 0; i < 100; i++ {
+		p = append(p, float64(i))
+	}
+	return append(p, 99.5, 99.9)
+}()
+
+type comparisonBuilder struct {
+	cfg     *RenderConfig
+	reports []*StandardReport
+	view    *renderedComparison
+}
+
+// RenderHTML writes a self-contained HTML page comparing reports ordered from the oldest to the newest:
+// tables of Direct, Loki and Prometheus results, latency distribution charts of Direct executors and charts of Loki series.
+// Changes of the latest report against the previous one are highlighted.
+func RenderHTML(w io.Writer, cfg *RenderConfig, reports ...*StandardReport) error {
+	view, err := newRenderedComparison(cfg, reports...)
+	if err != nil {
+		return err
+	}
+	tmpl, err := template.New("html").Funcs(template.FuncMap{
+		"chartWidth":  func() int { return chartWidth },
+		"chartHeight": func() int { return chartHeight },
+		"plotLeft":    func() int { return chartMarginLeft },
+		"plotRight":   func() int { return chartWidth - chartMarginRight },
+		"plotTop":     func() int { return chartMarginTop },
+		"plotBottom":  func() int { return chartHeight - chartMarginBottom },
+	}).Parse(htmlReportTemplate)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse HTML template")
+	}
+	return tmpl.Execute(w, view)
+}
+
+// RenderMarkdown writes a summary comparing reports ordered from the oldest to the newest, suitable for a PR comment.
+// It contains the same tables as RenderHTML without charts.
+func RenderMarkdown(w io.Writer, cfg *RenderConfig, reports ...*StandardReport) error {
+	view, err := newRenderedComparison(cfg, reports...)
+	if err != nil {
+		return err
+	}
+	tmpl, err := textTemplate.New("markdown").Funcs(textTemplate.FuncMap{
+		"cell": markdownCell,
+		"code": func(s string) string { return "`" + s + "`" },
+		"join": strings.Join,
+		"separator": func(n int) string {
+			return strings.Repeat("|---", n) + "|"
+		},
+		"escape": markdownEscape,
+	}).Parse(markdownReportTemplate)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse Markdown template")
+	}
+	return tmpl.Execute(w, view)
+}
+
+func markdownEscape(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
+func markdownCell(c renderedCell) string {
+	switch c.Class {
+	case "worse":
+		return "**" + c.Text + "**"
+	case "better":
+		return "_" + c.Text + "_"
+	default:
+		return markdownEscape(c.Text)
+	}
+}
+
+func newRenderedComparison(cfg *RenderConfig, reports ...*StandardReport) (*renderedComparison, error) {
+	if cfg == nil {
+		cfg = &RenderConfig{}
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if len(reports) < 2 {
+		return nil, fmt.Errorf("at least 2 reports are needed for comparison, got %d", len(reports))
+	}
+	for _, report := range reports {
+		if report == nil {
+			return nil, errors.New("one of the reports is nil")
+		}
+	}
+
+	b := &comparisonBuilder{
+		cfg:     cfg,
+		reports: reports,
+		view: &renderedComparison{
+			Title:     cfg.Title,
+			Latest:    reports[len(reports)-1].CommitOrTag,
+			Previous:  reports[len(reports)-2].CommitOrTag,
+			Threshold: cfg.DiffThreshold,
+		},
+	}
+	for _, report := range reports {
+		b.view.Refs = append(b.view.Refs, report.CommitOrTag)
+	}
+
+	if err := b.addGeneratorSections(); err != nil {
+		return nil, err
+	}
+	if err := b.addPrometheusSection(); err != nil {
+		return nil, err
+	}
+	b.addDockerSection()
+	return b.view, nil
+}
+
+func (b *comparisonBuilder) header(first string) []string {
+	return append(append([]string{first}, b.view.Refs...), "Diff %")
+}
+
+// row returns cells with values of every report and the change of the latest value against the previous one,
+// NaN values are missing in the report. Scope prefixes the label in the list of worse metrics
+func (b *comparisonBuilder) row(scope, label, metric string, values []float64) []renderedCell {
+	cells := []renderedCell{{Text: label}}
+	for _, v := range values {
+		if math.IsNaN(v) {
+			cells = append(cells, renderedCell{Text: "-"})
+		} else {
+			cells = append(cells, renderedCell{Text: fmt.Sprintf("%.4f", v)})
+		}
+	}
+
+	current, previous := values[len(values)-1], values[len(values)-2]
+	if math.IsNaN(current) || math.IsNaN(previous) {
+		return append(cells, renderedCell{Text: "-"})
+	}
+	diff := calculateDiffPercentage(current, previous)
+	worse := diff
+	if higherIsBetter(metric) {
+		worse = -diff
+	}
+	diffCell := renderedCell{Text: fmt.Sprintf("%+.2f", diff)}
+	switch {
+	case worse > b.cfg.DiffThreshold:
+		diffCell.Class = "worse"
+		b.view.Worse = append(b.view.Worse, fmt.Sprintf("%s %s: %s%%", scope, label, diffCell.Text))
+	case worse < -b.cfg.DiffThreshold:
+		diffCell.Class = "better"
+	}
+	return append(cells, diffCell)
+}
+
+func (b *comparisonBuilder) addGeneratorSections() error {
+	direct := make([]DirectResultsByGenerator, 0, len(b.reports))
+	loki := make([]map[string]map[string][]float64, 0, len(b.reports))
+	generators := make(map[string]struct{})
+	for _, report := range b.reports {
+		directResults := MustAllDirectResults(report)
+		for generator := range directResults {
+			generators[generator] = struct{}{}
+		}
+		direct = append(direct, directResults)
+
+		lokiResults := make(map[string]map[string][]float64)
+		for generator, queries := range MustAllLokiResults(report) {
+			generators[generator] = struct{}{}
+			lokiResults[generator] = make(map[string][]float64)
+			for query, series := range queries {
+				asFloats, err := StringSliceToFloat64Slice(series)
+				if err != nil {
+					return errors.Wrapf(err, "failed to convert Loki results of query %s of generator %s in report %s", query, generator, report.CommitOrTag)
+				}
+				lokiResults[generator][query] = asFloats
+			}
+		}
+		loki = append(loki, lokiResults)
+	}
+
+	for _, generator := range sortedKeys(generators) {
+		section := renderedSection{Title: "Generator: " + generator}
+
+		directMetrics := make(map[string]struct{})
+		for _, results := range direct {
+			for metric := range results[generator] {
+				directMetrics[metric] = struct{}{}
+			}
+		}
+		if len(directMetrics) > 0 {
+			table := renderedTable{Title: "Direct metrics", Header: b.header("Metric")}
+			for _, metric := range orderedMetrics(directMetrics) {
+				values := make([]float64, 0, len(direct))
+				for _, results := range direct {
+					v, ok := results[generator][metric]
+					if !ok {
+						v = math.NaN()
+					}
+					values = append(values, v)
+				}
+				table.Rows = append(table.Rows, b.row(generator, metric, metric, values))
+			}
+			section.Tables = append(section.Tables, table)
+
+			if chart, ok := b.latencyChart(generator); ok {
+				section.Charts = append(section.Charts, chart)
+			}
+		}
+
+		lokiQueries := make(map[string]struct{})
+		for _, results := range loki {
+			for query := range results[generator] {
+				lokiQueries[query] = struct{}{}
+			}
+		}
+		if len(lokiQueries) > 0 {
+			table := renderedTable{Title: "Loki metrics (mean of series)", Header: b.header("Query")}
+			for _, query := range orderedMetrics(lokiQueries) {
+				values := make([]float64, 0, len(loki))
+				series := make([][]float64, 0, len(loki))
+				for _, results := range loki {
+					s, ok := results[generator][query]
+					mean := math.NaN()
+					if ok && len(s) > 0 {
+						mean, _ = stats.Mean(s)
+					}
+					values = append(values, mean)
+					series = append(series, s)
+				}
+				table.Rows = append(table.Rows, b.row(generator, query, query, values))
+				section.Charts = append(section.Charts, b.seriesChart(query, series))
+			}
+			section.Tables = append(section.Tables, table)
+		}
+
+		b.view.Sections = append(b.view.Sections, section)
+	}
+	return nil
+}
+
+// orderedMetrics returns standard load metrics in their usual order followed by other names sorted alphabetically
+func orderedMetrics(names map[string]struct{}) []string {
+	ordered := make([]string, 0, len(names))
+	seen := make(map[string]bool)
+	for _, metric := range StandardLoadMetrics {
+		if _, ok := names[string(metric)]; ok {
+			ordered = append(ordered, string(metric))
+			seen[string(metric)] = true
+		}
+	}
+	for _, name := range sortedKeys(names) {
+		if !seen[name] {
+			ordered = append(ordered, name)
+		}
+	}
+	return ordered
+}
+
+// latencyChart returns latency CDFs of all reports with Direct samples of the generator
+func (b *comparisonBuilder) latencyChart(generator string) (renderedChart, bool) {
+	names := make([]string, 0)
+	xs, ys := make([][]float64, 0), make([][]float64, 0)
+	for _, report := range b.reports {
+		for _, qe := range report.QueryExecutors {
+			dqe, ok := qe.(*DirectQueryExecutor)
+			if !ok || dqe.GeneratorName() != generator {
+				continue
+			}
+			samples, _, _, err := directSamples(dqe)
+			if err != nil || samples.total == 0 {
+				continue
+			}
+			x := make([]float64, 0, len(cdfPercentiles))
+			for _, p := range cdfPercentiles {
+				x = append(x, samples.percentile(p))
+			}
+			names = append(names, report.CommitOrTag)
+			xs = append(xs, x)
+			ys = append(ys, cdfPercentiles)
+		}
+	}
+	if len(names) == 0 {
+		return renderedChart{}, false
+	}
+	return newLineChart("Latency distribution", "latency, ms", "percentile", names, xs, ys), true
+}
+
+// seriesChart returns values of a series of every report over time
+func (b *comparisonBuilder) seriesChart(title string, series [][]float64) renderedChart {
+	names := make([]string, 0)
+	xs, ys := make([][]float64, 0), make([][]float64, 0)
+	for i, s := range series {
+		if len(s) == 0 {
+			continue
+		}
+		x := make([]float64, len(s))
+		for j := range s {
+			x[j] = float64(j)
+		}
+		names = append(names, b.reports[i].CommitOrTag)
+		xs = append(xs, x)
+		ys = append(ys, s)
+	}
+	return newLineChart(title, "sample", "value", names, xs, ys)
+}
+
+func (b *comparisonBuilder) addPrometheusSection() error {
+	results := make([]map[string]float64, 0, len(b.reports))
+	rows := make(map[string]string)
+	for _, report := range b.reports {
+		reportResults := make(map[string]float64)
+		for query, value := range MustAllPrometheusResults(report) {
+			flattened, err := flattenPrometheusValue(value)
+			if err != nil {
+				return errors.Wrapf(err, "failed to flatten Prometheus results of query %s in report %s", query, report.CommitOrTag)
+			}
+			for series, v := range flattened {
+				label := query + series
+				reportResults[label] = v
+				rows[label] = query
+			}
+		}
+		results = append(results, reportResults)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	table := renderedTable{Title: "Prometheus metrics", Header: b.header("Query")}
+	for _, label := range sortedKeys(rows) {
+		values := make([]float64, 0, len(results))
+		for _, reportResults := range results {
+			v, ok := reportResults[label]
+			if !ok {
+				v = math.NaN()
+			}
+			values = append(values, v)
+		}
+		table.Rows = append(table.Rows, b.row("prometheus", label, rows[label], values))
+	}
+	b.view.Sections = append(b.view.Sections, renderedSection{Title: "Resources", Tables: []renderedTable{table}})
+	return nil
+}
+
+func (b *comparisonBuilder) addDockerSection() {
+	results := make([]DockerResultsByContainer, 0, len(b.reports))
+	containers := make(map[string]struct{})
+	for _, report := range b.reports {
+		reportResults := MustAllDockerResults(report)
+		for name := range reportResults {
+			containers[name] = struct{}{}
+		}
+		results = append(results, reportResults)
+	}
+	if len(containers) == 0 {
+		return
+	}
+
+	section := renderedSection{Title: "Containers"}
+	for _, name := range sortedKeys(containers) {
+		table := renderedTable{Title: name, Header: b.header("Metric")}
+		for _, metric := range DockerResourceMetrics {
+			values := make([]float64, 0, len(results))
+			for _, reportResults := range results {
+				v, ok := reportResults[name][string(metric)]
+				if !ok {
+					v = math.NaN()
+				}
+				values = append(values, v)
+			}
+			table.Rows = append(table.Rows, b.row(name, string(metric), string(metric), values))
+		}
+		section.Tables = append(section.Tables, table)
+	}
+	b.view.Sections = append(b.view.Sections, section)
+}
+
+// flattenPrometheusValue returns a single value per series keyed by its labels, matrix series are reduced to their mean
+func flattenPrometheusValue(value model.Value) (map[string]float64, error) {
+	flattened := make(map[string]float64)
+	switch v := value.(type) {
+	case *model.Vector:
+		return flattenPrometheusValue(*v)
+	case *model.Matrix:
+		return flattenPrometheusValue(*v)
+	case *model.Scalar:
+		flattened[""] = float64(v.Value)
+	case model.Vector:
+		for _, sample := range v {
+			flattened[seriesLabels(sample.Metric)] = float64(sample.Value)
+		}
+	case model.Matrix:
+		for _, stream := range v {
+			values := make([]float64, 0, len(stream.Values))
+			for _, pair := range stream.Values {
+				values = append(values, float64(pair.Value))
+			}
+			if len(values) == 0 {
+				continue
+			}
+			mean, _ := stats.Mean(values)
+			flattened[seriesLabels(stream.Metric)] = mean
+		}
+	case *model.String:
+		// not a number, nothing to compare
+	default:
+		return nil, fmt.Errorf("unsupported Prometheus value type %T", value)
+	}
+	return flattened, nil
+}
+
+func seriesLabels(metric model.Metric) string {
+	if len(metric) == 0 {
+		return ""
+	}
+	return metric.String()
+}
+
+// newLineChart scales lines to the plot area, names, xs and ys must have the same length
+func newLineChart(title, xLabel, yLabel string, names []string, xs, ys [][]float64) renderedChart {
+	chart := renderedChart{Title: title, XLabel: xLabel, YLabel: yLabel}
+	xMin, xMax := math.Inf(1), math.Inf(-1)
+	yMin, yMax := math.Inf(1), math.Inf(-1)
+	for i := range xs {
+		for j := range xs[i] {
+			xMin, xMax = math.Min(xMin, xs[i][j]), math.Max(xMax, xs[i][j])
+			yMin, yMax = math.Min(yMin, ys[i][j]), math.Max(yMax, ys[i][j])
+		}
+	}
+	if math.IsInf(xMin, 1) {
+		return chart
+	}
+	// start value axes at zero unless there are negative values, flat lines are drawn in the middle
+	yMin = math.Min(yMin, 0)
+	if xMax == xMin {
+		xMin, xMax = xMin-1, xMax+1
+	}
+	if yMax == yMin {
+		yMax = yMin + 1
+	}
+
+	plotWidth := float64(chartWidth - chartMarginLeft - chartMarginRight)
+	plotHeight := float64(chartHeight - chartMarginTop - chartMarginBottom)
+	scaleX := func(x float64) float64 { return chartMarginLeft + (x-xMin)/(xMax-xMin)*plotWidth }
+	scaleY := func(y float64) float64 { return chartMarginTop + plotHeight - (y-yMin)/(yMax-yMin)*plotHeight }
+
+	for i, name := range names {
+		points := make([]string, 0, len(xs[i]))
+		for j := range xs[i] {
+			points = append(points, fmt.Sprintf("%.1f,%.1f", scaleX(xs[i][j]), scaleY(ys[i][j])))
+		}
+		chart.Lines = append(chart.Lines, chartLine{Name: name, Color: chartColors[i%len(chartColors)], Points: strings.Join(points, " "), LegendY: float64(chartMarginTop + 14*(i+1))})
+	}
+
+	const ticks = 5
+	for i := 0; i <= ticks; i++ {
+		x := xMin + (xMax-xMin)*float64(i)/ticks
+		y := yMin + (yMax-yMin)*float64(i)/ticks
+		chart.XTicks = append(chart.XTicks, chartTick{Pos: scaleX(x), Label: fmt.Sprintf("%.4g", x)})
+		chart.YTicks = append(chart.YTicks, chartTick{Pos: scaleY(y), Label: fmt.Sprintf("%.4g", y)})
+	}
+	return chart
+}
+
+const markdownReportTemplate = `## {{ .Title }}
+
+Compared reports: {{ range $i, $ref := .Refs }}{{ if $i }}, {{ end }}{{ code $ref }}{{ end }}. Diff is the change of {{ code .Latest }} against {{ code .Previous }}, changes above {{ printf "%.2f" .Threshold }}% are marked as **worse** or _better_.
+
+{{ if .Worse -}}
+**{{ len .Worse }} metric(s) got worse:**
+{{ range .Worse }}
+- {{ escape . }}
+{{- end }}
+{{- else -}}
+No metric got worse by more than {{ printf "%.2f" .Threshold }}%.
+{{- end }}
+{{ range .Sections }}
+### {{ .Title }}
+{{ range .Tables }}
+**{{ .Title }}**
+
+| {{ join .Header " | " }} |
+{{ separator (len .Header) }}
+{{ range .Rows }}|{{ range . }} {{ cell . }} |{{ end }}
+{{ end }}{{ end }}{{ end }}`
+
+const htmlReportTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #24292f; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #d0d7de; padding: 4px 10px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+th { background: #f6f8fa; }
+td.worse { background: #ffebe9; color: #cf222e; font-weight: bold; }
+td.better { background: #dafbe1; color: #1a7f37; }
+.summary.worse { color: #cf222e; }
+.charts { display: flex; flex-wrap: wrap; gap: 1em; }
+svg { border: 1px solid #d0d7de; background: #fff; }
+svg text { font-size: 11px; fill: #57606a; }
+svg .title { font-size: 13px; fill: #24292f; }
+</style>
+</head>
+<body>
+<h1>{{ .Title }}</h1>
+<p>Compared reports: {{ range $i, $ref := .Refs }}{{ if $i }}, {{ end }}<code>{{ $ref }}</code>{{ end }}.
+Diff is the change of <code>{{ .Latest }}</code> against <code>{{ .Previous }}</code>, changes above {{ printf "%.2f" .Threshold }}% are highlighted.</p>
+{{ if .Worse -}}
+<div class="summary worse"><strong>{{ len .Worse }} metric(s) got worse:</strong>
+<ul>{{ range .Worse }}<li>{{ . }}</li>{{ end }}</ul></div>
+{{- else -}}
+<p class="summary">No metric got worse by more than {{ printf "%.2f" .Threshold }}%.</p>
+{{- end }}
+{{ range .Sections }}
+<h2>{{ .Title }}</h2>
+{{ range .Tables }}
+<h3>{{ .Title }}</h3>
+<table>
+<tr>{{ range .Header }}<th>{{ . }}</th>{{ end }}</tr>
+{{ range .Rows }}<tr>{{ range . }}<td{{ if .Class }} class="{{ .Class }}"{{ end }}>{{ .Text }}</td>{{ end }}</tr>
+{{ end }}</table>
+{{ end }}
+{{ if .Charts }}<div class="charts">
+{{ range .Charts }}<svg xmlns="http://www.w3.org/2000/svg" width="{{ chartWidth }}" height="{{ chartHeight }}" viewBox="0 0 {{ chartWidth }} {{ chartHeight }}">
+<text class="title" x="{{ plotLeft }}" y="14">{{ .Title }}</text>
+<line x1="{{ plotLeft }}" y1="{{ plotBottom }}" x2="{{ plotRight }}" y2="{{ plotBottom }}" stroke="#8c959f"/>
+<line x1="{{ plotLeft }}" y1="{{ plotTop }}" x2="{{ plotLeft }}" y2="{{ plotBottom }}" stroke="#8c959f"/>
+{{ range .XTicks }}<text x="{{ printf "%.1f" .Pos }}" y="{{ plotBottom }}" dy="14" text-anchor="middle">{{ .Label }}</text>
+{{ end }}{{ range .YTicks }}<text x="{{ plotLeft }}" y="{{ printf "%.1f" .Pos }}" dx="-4" dy="4" text-anchor="end">{{ .Label }}</text>
+<line x1="{{ plotLeft }}" y1="{{ printf "%.1f" .Pos }}" x2="{{ plotRight }}" y2="{{ printf "%.1f" .Pos }}" stroke="#eaeef2"/>
+{{ end }}<text x="{{ plotRight }}" y="{{ chartHeight }}" dy="-6" text-anchor="end">{{ .XLabel }}</text>
+<text x="4" y="{{ plotTop }}" dy="-6">{{ .YLabel }}</text>
+{{ range $line := .Lines }}<polyline fill="none" stroke="{{ $line.Color }}" stroke-width="1.5" points="{{ $line.Points }}"/>
+<text x="{{ plotRight }}" y="{{ $line.LegendY }}" dx="-4" text-anchor="end" style="fill: {{ $line.Color }}">{{ $line.Name }}</text>
+{{ end }}</svg>
+{{ end }}</div>{{ end }}
+{{ end }}
+</body>
+</html>
+`
@@ -0,0 +1,111 @@
+package benchspy
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/wasp"
+)
+
+func newRenderTestReport(ref string, median, cpu float64) *StandardReport {
+	latency := wasp.NewLatencyHistogram()
+	for i := 1; i <= 100; i++ {
+		latency.Record(time.Duration(median*float64(i)/50) * time.Millisecond)
+	}
+	return &StandardReport{
+		BasicData: BasicData{TestName: "render", CommitOrTag: ref},
+		QueryExecutors: []QueryExecutor{
+			&DirectQueryExecutor{
+				KindName:  string(StandardQueryExecutor_Direct),
+				Generator: &wasp.Generator{Cfg: &wasp.Config{GenName: "vu1"}},
+				QueryResults: map[string]interface{}{
+					string(MedianLatency): median,
+					string(ErrorRate):     0.0,
+				},
+				Samples: &DirectSamples{Latency: latency, Calls: 100},
+			},
+			&LokiQueryExecutor{
+				KindName:            string(StandardQueryExecutor_Loki),
+				GeneratorNameString: "vu1",
+				QueryResults: map[string]interface{}{
+					string(Percentile95Latency): []string{"10", "20", "30"},
+				},
+			},
+			&PrometheusQueryExecutor{
+				KindName: string(StandardQueryExecutor_Prometheus),
+				QueryResults: map[string]interface{}{
+					string(MedianCPUUsage): model.Vector{&model.Sample{
+						Metric: model.Metric{"name": "node|1"},
+						Value:  model.SampleValue(cpu),
+					}},
+				},
+			},
+		},
+	}
+}
+
+func TestBenchSpy_RenderComparison(t *testing.T) {
+	reports := []*StandardReport{
+		newRenderTestReport("v1", 10, 0.5),
+		newRenderTestReport("v2", 10, 0.5),
+		newRenderTestReport("v3", 12, 0.4),
+	}
+
+	t.Run("markdown", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, RenderMarkdown(&out, &RenderConfig{Title: "PR performance"}, reports...))
+		md := out.String()
+		require.Contains(t, md, "## PR performance")
+		require.Contains(t, md, "**1 metric(s) got worse:**")
+		require.Contains(t, md, "- vu1 median_latency: +20.00%")
+		require.Contains(t, md, "| Metric | v1 | v2 | v3 | Diff % |")
+		require.Contains(t, md, "| median_latency | 10.0000 | 10.0000 | 12.0000 | **+20.00** |")
+		require.Contains(t, md, "| error_rate | 0.0000 | 0.0000 | 0.0000 | +0.00 |")
+		require.Contains(t, md, "| 95th_percentile_latency | 20.0000 | 20.0000 | 20.0000 | +0.00 |")
+		require.Contains(t, md, `| median_cpu_usage{name="node\|1"} | 0.5000 | 0.5000 | 0.4000 | _-20.00_ |`)
+		require.NotContains(t, md, "<svg")
+	})
+
+	t.Run("html", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, RenderHTML(&out, nil, reports...))
+		page := out.String()
+		require.True(t, strings.HasPrefix(page, "<!DOCTYPE html>"))
+		require.Contains(t, page, "<title>Performance comparison</title>")
+		require.Contains(t, page, `<td class="worse">&#43;20.00</td>`)
+		require.Contains(t, page, `<td class="better">-20.00</td>`)
+		require.Contains(t, page, "Latency distribution")
+		// one latency distribution chart and one Loki series chart with a line per report
+		require.Equal(t, 2, strings.Count(page, "<svg"))
+		require.Equal(t, 6, strings.Count(page, "<polyline"))
+		require.NotContains(t, page, "<script")
+	})
+
+	t.Run("stored reports", func(t *testing.T) {
+		storage := &LocalStorage{Directory: t.TempDir()}
+		loaded := make([]*StandardReport, 0)
+		for _, report := range reports[1:] {
+			_, err := storage.Store(report.TestName, report.CommitOrTag, report)
+			require.NoError(t, err)
+			l := &StandardReport{}
+			require.NoError(t, storage.Load(report.TestName, report.CommitOrTag, l))
+			loaded = append(loaded, l)
+		}
+		var out bytes.Buffer
+		require.NoError(t, RenderMarkdown(&out, nil, loaded...))
+		require.Contains(t, out.String(), "| median_latency | 10.0000 | 12.0000 | **+20.00** |")
+		require.Contains(t, out.String(), `| median_cpu_usage{name="node\|1"} | 0.5000 | 0.4000 | _-20.00_ |`)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		var out bytes.Buffer
+		require.Error(t, RenderMarkdown(&out, nil, reports[0]))
+		require.Error(t, RenderHTML(&out, nil, reports[0], nil))
+		require.Error(t, RenderHTML(&out, &RenderConfig{DiffThreshold: -1}, reports...))
+	})
+}
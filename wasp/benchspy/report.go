@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/pkg/errors"
@@ -21,25 +22,36 @@ type StandardReport struct {
 	BasicData
 	LocalStorage
 	QueryExecutors []QueryExecutor `json:"query_executors"`
+	// Windows are time ranges of the test compared separately, ex.: chaos experiments, see WithTimeWindows
+	Windows []wasp.TimeWindow `json:"windows,omitempty"`
+	// Storage replaces LocalStorage when set, ex.: S3Storage
+	Storage ReportStorage `json:"-"`
 }
 
-// Store saves the report to local storage as a JSON file.
-// It returns the absolute path of the stored file and any error encountered.
+func (sr *StandardReport) storage() ReportStorage {
+	if sr.Storage != nil {
+		return sr.Storage
+	}
+	return &sr.LocalStorage
+}
+
+// Store saves the report to local storage as a JSON file, or to Storage if it's set.
+// It returns the absolute path (or location) of the stored report and any error encountered.
 func (sr *StandardReport) Store() (string, error) {
-	return sr.LocalStorage.Store(sr.TestName, sr.CommitOrTag, sr)
+	return sr.storage().Store(sr.TestName, sr.CommitOrTag, sr)
 }
 
 // Load retrieves a report based on the specified test name and commit or tag.
-// It utilizes local storage to find and decode the corresponding report file,
+// It utilizes local storage, or Storage if it's set, to find and decode the corresponding report file,
 // ensuring that the report is available for further processing or analysis.
 func (sr *StandardReport) Load(testName, commitOrTag string) error {
-	return sr.LocalStorage.Load(testName, commitOrTag, sr)
+	return sr.storage().Load(testName, commitOrTag, sr)
 }
 
-// LoadLatest retrieves the most recent report for the specified test name from local storage.
+// LoadLatest retrieves the most recent report for the specified test name from local storage, or Storage if it's set.
 // It returns an error if the report cannot be loaded, enabling users to access historical test data efficiently.
 func (sr *StandardReport) LoadLatest(testName string) error {
-	return sr.LocalStorage.Load(testName, "", sr)
+	return sr.storage().Load(testName, "", sr)
 }
 
 // ResultsAs retrieves and casts results from a query executor to a specified type.
@@ -115,21 +127,38 @@ type DirectResultsByGenerator map[string]map[string]float64
 // MustAllDirectResults extracts and returns all direct results from a given StandardReport.
 // It panics if any result extraction fails, ensuring that only valid results are processed.
 func MustAllDirectResults(sr *StandardReport) DirectResultsByGenerator {
+	results, err := AllDirectResults(sr)
+	if err != nil {
+		panic(err)
+	}
+
+	return results
+}
+
+// AllDirectResults extracts and returns all direct results from a given StandardReport.
+// It returns an error if the report has a missing executor or results that aren't numbers.
+func AllDirectResults(sr *StandardReport) (DirectResultsByGenerator, error) {
 	results := make(DirectResultsByGenerator)
 
-	for _, queryExecutor := range sr.QueryExecutors {
+	for i, queryExecutor := range sr.QueryExecutors {
+		if queryExecutor == nil {
+			return nil, fmt.Errorf("query executor %d is missing", i)
+		}
 		if strings.EqualFold(queryExecutor.Kind(), string(StandardQueryExecutor_Direct)) {
+			asNamedGenerator, ok := queryExecutor.(NamedGenerator)
+			if !ok {
+				return nil, fmt.Errorf("direct query executor %d doesn't implement NamedGenerator, it's %T", i, queryExecutor)
+			}
 			singleResult, err := ResultsAs(0.0, queryExecutor)
 			if err != nil {
-				panic(err)
+				return nil, fmt.Errorf("failed to get results of generator %s: %w", asNamedGenerator.GeneratorName(), err)
 			}
 
-			asNamedGenerator := queryExecutor.(NamedGenerator)
 			results[asNamedGenerator.GeneratorName()] = singleResult
 		}
 	}
 
-	return results
+	return results, nil
 }
 
 // MustAllPrometheusResults retrieves all Prometheus query results from a StandardReport.
@@ -346,13 +375,16 @@ func (sr *StandardReport) FetchData(ctx context.Context) error {
 
 	errGroup, errCtx := errgroup.WithContext(ctx)
 	for _, queryExecutor := range sr.QueryExecutors {
+		if directExecutor, ok := queryExecutor.(*DirectQueryExecutor); ok {
+			directExecutor.Windows = sr.Windows
+		}
 		errGroup.Go(func() error {
 			// feature: PLAIN SEGMENT ONLY
 			// go over all schedules and execute the code below only for ones with type "plain"
 			// and then concatenate that data and return that; if parallelizing then we should first
 			// create a slice of plain segments and then, when sending results over channel include the index,
 			// so that we can concatenate them in the right order
-			queryExecutor.TimeRange(sr.TestStart, sr.TestEnd)
+			queryExecutor.TimeRange(sr.executorTimeRange(queryExecutor))
 
 			// in case someone skipped helper functions and didn't set the start and end times
 			if validateErr := queryExecutor.Validate(); validateErr != nil {
@@ -374,6 +406,27 @@ func (sr *StandardReport) FetchData(ctx context.Context) error {
 	return nil
 }
 
+func (sr *StandardReport) dockerExecutors() []*DockerQueryExecutor {
+	var executors []*DockerQueryExecutor
+	for _, queryExecutor := range sr.QueryExecutors {
+		if dockerExecutor, ok := queryExecutor.(*DockerQueryExecutor); ok {
+			executors = append(executors, dockerExecutor)
+		}
+	}
+	return executors
+}
+
+// executorTimeRange returns the time range of the generator for Loki executors, since generators can run with different schedules,
+// and the report's time range otherwise
+func (sr *StandardReport) executorTimeRange(queryExecutor QueryExecutor) (time.Time, time.Time) {
+	if lokiExecutor, ok := queryExecutor.(*LokiQueryExecutor); ok {
+		if start, end, err := sr.GeneratorTimeRange(lokiExecutor.GeneratorName()); err == nil {
+			return start, end
+		}
+	}
+	return sr.TestStart, sr.TestEnd
+}
+
 // IsComparable checks if the current report can be compared with another report.
 // It validates the type of the other report and ensures that their basic data and query executors are comparable.
 // This function is useful for verifying report consistency before performing further analysis.
@@ -400,6 +453,17 @@ func (sr *StandardReport) IsComparable(otherReport Reporter) error {
 		}
 	}
 
+	// Docker executors aren't tied to generators, so they are matched in order
+	dockerExecutors, otherDockerExecutors := sr.dockerExecutors(), asStandardReport.dockerExecutors()
+	if len(dockerExecutors) != len(otherDockerExecutors) {
+		return fmt.Errorf("docker executors count is different. Expected %d, got %d", len(dockerExecutors), len(otherDockerExecutors))
+	}
+	for i, dockerExecutor := range dockerExecutors {
+		if dockerErr := dockerExecutor.IsComparable(otherDockerExecutors[i]); dockerErr != nil {
+			return dockerErr
+		}
+	}
+
 	L.Debug().
 		Msg("Reports are comparable")
 
@@ -412,6 +476,8 @@ type standardReportConfig struct {
 	prometheusConfig *PrometheusConfig
 	queryExecutors   []QueryExecutor
 	reportDirectory  string
+	reportStorage    ReportStorage
+	windows          []wasp.TimeWindow
 }
 
 type StandardReportOption func(*standardReportConfig)
@@ -450,6 +516,14 @@ func WithReportDirectory(reportDirectory string) StandardReportOption {
 	}
 }
 
+// WithReportStorage sets a storage used instead of the local directory, ex.: S3Storage.
+// FetchNewStandardReportAndLoadLatestPrevious loads the previous report from it as well.
+func WithReportStorage(storage ReportStorage) StandardReportOption {
+	return func(c *standardReportConfig) {
+		c.reportStorage = storage
+	}
+}
+
 // WithQueryExecutors sets the query executors for a standard report configuration.
 // It allows customization of how queries are executed, enhancing report generation flexibility.
 func WithQueryExecutors(queryExecutors ...QueryExecutor) StandardReportOption {
@@ -468,6 +542,9 @@ func (c *standardReportConfig) validate() error {
 
 	hasPrometehus := false
 	for _, t := range c.executorTypes {
+		if t == StandardQueryExecutor_Docker {
+			return errors.New("docker executor samples stats during the test, create it with NewDockerQueryExecutor, start it before the test and pass it with WithQueryExecutors")
+		}
 		if t == StandardQueryExecutor_Prometheus {
 			hasPrometehus = true
 			if c.prometheusConfig == WithoutPrometheus {
@@ -480,6 +557,10 @@ func (c *standardReportConfig) validate() error {
 		return errors.New("generators are not set, at least one is required")
 	}
 
+	if err := validateTimeWindows(c.windows); err != nil {
+		return err
+	}
+
 	if c.prometheusConfig != WithoutPrometheus {
 		if !hasPrometehus {
 			return errors.New("prometheus config is set, but query executor type is not set to prometheus")
@@ -555,6 +636,8 @@ func NewStandardReport(commitOrTag string, opts ...StandardReportOption) (*Stand
 	if config.reportDirectory != "" {
 		sr.LocalStorage.Directory = config.reportDirectory
 	}
+	sr.Storage = config.reportStorage
+	sr.Windows = config.windows
 
 	L.Info().
 		Str("Reference", commitOrTag).
@@ -616,7 +699,12 @@ func initStandardQueryExecutor(kind StandardQueryExecutorType, basicData *BasicD
 		if !generatorHasLabels(g) {
 			return nil, fmt.Errorf("generator %s is missing branch or commit labels", g.Cfg.GenName)
 		}
-		executor, executorErr := NewStandardMetricsLokiExecutor(g.Cfg.LokiConfig, basicData.TestName, g.Cfg.GenName, g.Cfg.Labels["branch"], g.Cfg.Labels["commit"], basicData.TestStart, basicData.TestEnd)
+		// generators can run with different schedules, query only the time range of this one
+		start, end, rangeErr := basicData.GeneratorTimeRange(g.Cfg.GenName)
+		if rangeErr != nil {
+			return nil, rangeErr
+		}
+		executor, executorErr := NewStandardMetricsLokiExecutor(g.Cfg.LokiConfig, basicData.TestName, g.Cfg.GenName, g.Cfg.Labels["branch"], g.Cfg.Labels["commit"], start, end)
 		if executorErr != nil {
 			return nil, errors.Wrapf(executorErr, "failed to create standard Loki query executor for generator %s", g.Cfg.GenName)
 		}
@@ -658,8 +746,11 @@ func (sr *StandardReport) UnmarshalJSON(data []byte) error {
 		return queryErr
 	}
 
+	// storage isn't serialized, keep the one used to load the report
+	storage := sr.Storage
 	*sr = StandardReport(raw.Alias)
 	sr.QueryExecutors = queryExecutors
+	sr.Storage = storage
 	return nil
 }
 
@@ -687,6 +778,8 @@ func unmarshallQueryExecutors(raw []json.RawMessage) ([]QueryExecutor, error) {
 			executor = &DirectQueryExecutor{}
 		case "prometheus":
 			executor = &PrometheusQueryExecutor{}
+		case "docker":
+			executor = &DockerQueryExecutor{}
 		default:
 			return nil, fmt.Errorf("unknown query executor type: %s\nIf you added a new query executor make sure to add a custom JSON unmarshaller to StandardReport.UnmarshalJSON()", typeIndicator.Kind)
 		}
@@ -809,6 +902,7 @@ func FetchNewStandardReportAndLoadLatestPrevious(ctx context.Context, newCommitO
 
 	previousReport := &StandardReport{
 		LocalStorage: localStorage,
+		Storage:      config.reportStorage,
 	}
 
 	if err = previousReport.LoadLatest(newReport.TestName); err != nil {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -53,6 +54,21 @@ func TestBenchSpy_NewStandardReport(t *testing.T) {
 		assert.IsType(t, &DirectQueryExecutor{}, report.QueryExecutors[0])
 	})
 
+	t.Run("successful creation (docker)", func(t *testing.T) {
+		dockerExecutor, err := NewDockerQueryExecutor(nil)
+		require.NoError(t, err)
+		report, err := NewStandardReport("test-commit", WithStandardQueries(StandardQueryExecutor_Direct), WithQueryExecutors(dockerExecutor), WithGenerators(basicGen))
+		require.NoError(t, err)
+		assert.Equal(t, 2, len(report.QueryExecutors))
+		assert.IsType(t, &DockerQueryExecutor{}, report.QueryExecutors[1])
+	})
+
+	t.Run("docker as standard query", func(t *testing.T) {
+		_, err := NewStandardReport("test-commit", WithStandardQueries(StandardQueryExecutor_Docker), WithGenerators(basicGen))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "NewDockerQueryExecutor")
+	})
+
 	t.Run("missing branch label", func(t *testing.T) {
 		invalidGen := &wasp.Generator{
 			Cfg: &wasp.Config{
@@ -2029,3 +2045,77 @@ func TestBenchSpy_Standard_Direct_Metrics_Two_Generators_E2E(t *testing.T) {
 	hasErrors, errors := CompareDirectWithThresholds(10.0, 10.0, 10.0, 10.0, 10.0, currentReport, previousReport)
 	require.False(t, hasErrors, fmt.Sprintf("errors found: %v", errors))
 }
+
+func TestBenchSpy_NewStandardReport_AdaptiveGenerator(t *testing.T) {
+	gen, err := wasp.NewGenerator(&wasp.Config{
+		T:        t,
+		GenName:  "adaptive",
+		LoadType: wasp.RPS,
+		Gun: wasp.NewMockGun(&wasp.MockGunConfig{
+			CallSleep: 10 * time.Millisecond,
+		}),
+		Adaptive: &wasp.AdaptiveConfig{
+			StartRPS:     5,
+			StepRPS:      5,
+			MaxRPS:       10,
+			StepDuration: 500 * time.Millisecond,
+			MaxErrorRate: 0.1,
+			MaxDuration:  5 * time.Second,
+		},
+	})
+	require.NoError(t, err)
+	gen.Run(true)
+
+	report, err := NewStandardReport(
+		"v1",
+		WithStandardQueries(StandardQueryExecutor_Direct),
+		WithGenerators(gen),
+	)
+	require.NoError(t, err)
+	require.False(t, report.TestStart.IsZero())
+	require.True(t, report.TestEnd.After(report.TestStart))
+
+	fetchCtx, cancelFn := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancelFn()
+	require.NoError(t, report.FetchData(fetchCtx))
+}
+
+func TestBenchSpy_NewStandardReport_RecordedRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.jsonl.gz")
+	gen, err := wasp.NewGenerator(&wasp.Config{
+		T:        t,
+		GenName:  "recorded",
+		LoadType: wasp.RPS,
+		Schedule: wasp.Combine(wasp.Plain(10, 1*time.Second), wasp.Plain(20, 1*time.Second)),
+		Gun:      wasp.NewMockGun(&wasp.MockGunConfig{CallSleep: 10 * time.Millisecond}),
+		Recorder: &wasp.RecorderConfig{Path: path},
+	})
+	require.NoError(t, err)
+	gen.Run(true)
+
+	run, err := wasp.LoadRun(path)
+	require.NoError(t, err)
+	offline := run.Generator()
+	// testing.T isn't recorded, the report takes the test name from it
+	offline.Cfg.T = t
+	report, err := NewStandardReport(
+		"v1",
+		WithStandardQueries(StandardQueryExecutor_Direct),
+		WithGenerators(offline),
+	)
+	require.NoError(t, err)
+	require.Equal(t, gen.Cfg.Schedule[0].StartTime.UTC(), report.TestStart.UTC())
+	require.Equal(t, gen.Cfg.Schedule[1].EndTime.UTC(), report.TestEnd.UTC())
+
+	fetchCtx, cancelFn := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancelFn()
+	require.NoError(t, report.FetchData(fetchCtx))
+
+	live, err := NewStandardReport(
+		"v2",
+		WithStandardQueries(StandardQueryExecutor_Direct),
+		WithGenerators(gen),
+	)
+	require.NoError(t, err)
+	require.NoError(t, report.IsComparable(live))
+}
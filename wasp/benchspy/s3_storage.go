@@ -0,0 +1,223 @@
+package benchspy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/pkg/errors"
+)
+
+const (
+	DEFAULT_S3_PREFIX  = "performance_reports"
+	DEFAULT_S3_TIMEOUT = 30 * time.Second
+)
+
+// S3Config points S3Storage to a bucket of any S3-compatible storage, ex.: AWS S3 or Minio started with framework's s3provider
+type S3Config struct {
+	// Endpoint is host[:port] of the storage without scheme, ex.: "s3.amazonaws.com" or "localhost:9000"
+	Endpoint  string `json:"endpoint"`
+	AccessKey string `json:"-"`
+	SecretKey string `json:"-"`
+	Bucket    string `json:"bucket"`
+	Region    string `json:"region"`
+	// Secure enables TLS
+	Secure bool `json:"secure"`
+	// Prefix is prepended to all object keys, defaults to "performance_reports"
+	Prefix string `json:"prefix"`
+	// GitDirectory is any directory inside the Git repository used to order references, defaults to the current directory
+	GitDirectory string `json:"-"`
+	// Timeout of a single storage request, defaults to 30s
+	Timeout time.Duration `json:"-"`
+}
+
+// S3Storage stores reports as JSON objects "<prefix>/<test name>/<ref>.json" in an S3-compatible bucket,
+// so CI runners can share reports without committing them to the repository
+type S3Storage struct {
+	Config *S3Config
+	client *minio.Client
+}
+
+// NewS3Storage creates a storage client, the bucket must exist
+func NewS3Storage(cfg *S3Config) (*S3Storage, error) {
+	if cfg == nil || cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, errors.New("S3 endpoint and bucket are required")
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = DEFAULT_S3_PREFIX
+	}
+	if cfg.GitDirectory == "" {
+		cfg.GitDirectory = "."
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DEFAULT_S3_TIMEOUT
+	}
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.Secure,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create S3 client")
+	}
+	return &S3Storage{Config: cfg, client: client}, nil
+}
+
+func (s *S3Storage) testPrefix(testName string) string {
+	// nested tests might contain slashes, replace them with underscores
+	return path.Join(s.Config.Prefix, strings.ReplaceAll(testName, "/", "_")) + "/"
+}
+
+func (s *S3Storage) key(testName, commitOrTag string) string {
+	return s.testPrefix(testName) + commitOrTag + ".json"
+}
+
+// Store uploads the report as a JSON object and returns its "s3://<bucket>/<key>" location
+func (s *S3Storage) Store(testName, commitOrTag string, report interface{}) (string, error) {
+	if testName == "" || commitOrTag == "" {
+		return "", errors.New("test name and reference are required to store a report")
+	}
+	L.Debug().
+		Str("Test name", testName).
+		Str("Reference", commitOrTag).
+		Msg("Storing report on S3")
+
+	asJson, err := json.MarshalIndent(report, "", " ")
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.Config.Timeout)
+	defer cancel()
+	key := s.key(testName, commitOrTag)
+	_, err = s.client.PutObject(ctx, s.Config.Bucket, key, bytes.NewReader(asJson), int64(len(asJson)), minio.PutObjectOptions{ContentType: "application/json"})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to upload report %s", key)
+	}
+
+	location := fmt.Sprintf("s3://%s/%s", s.Config.Bucket, key)
+	L.Info().
+		Str("Test name", testName).
+		Str("Reference", commitOrTag).
+		Str("Report path", location).
+		Msg("Report stored successfully")
+
+	return location, nil
+}
+
+// Load downloads and decodes the report, if commitOrTag is empty the latest report in Git history is loaded
+func (s *S3Storage) Load(testName, commitOrTag string, report interface{}) error {
+	if testName == "" {
+		return errors.New("test name is empty. Please set it and try again")
+	}
+
+	ref := commitOrTag
+	if ref == "" {
+		L.Info().
+			Str("Test name", testName).
+			Msg("Loading latest report from S3")
+
+		latest, err := s.LatestRefs(testName, 1)
+		if err != nil {
+			return err
+		}
+		ref = latest[0]
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.Config.Timeout)
+	defer cancel()
+	key := s.key(testName, ref)
+	obj, err := s.client.GetObject(ctx, s.Config.Bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to download report %s", key)
+	}
+	defer func() { _ = obj.Close() }()
+
+	if err := json.NewDecoder(obj).Decode(report); err != nil {
+		return errors.Wrapf(err, "failed to decode report %s", key)
+	}
+
+	L.Info().
+		Str("Test name", testName).
+		Str("Reference", ref).
+		Str("Report path", key).
+		Msg("Report loaded successfully")
+
+	return nil
+}
+
+// ListRefs returns references of all reports stored for the test in alphabetical order
+func (s *S3Storage) ListRefs(testName string) ([]string, error) {
+	prefix := s.testPrefix(testName)
+	keys, err := s.list(prefix, true)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list reports of test %s", testName)
+	}
+	var refs []string
+	for _, key := range keys {
+		if strings.HasSuffix(key, ".json") {
+			refs = append(refs, strings.TrimSuffix(strings.TrimPrefix(key, prefix), ".json"))
+		}
+	}
+	sort.Strings(refs)
+	return refs, nil
+}
+
+// ListTestNames returns names of all tests with stored reports, nested test names have slashes replaced with underscores
+func (s *S3Storage) ListTestNames() ([]string, error) {
+	prefix := s.Config.Prefix + "/"
+	keys, err := s.list(prefix, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list tests")
+	}
+	var names []string
+	for _, key := range keys {
+		// without recursion tests are listed as common prefixes ending with a slash
+		if strings.HasSuffix(key, "/") {
+			names = append(names, strings.TrimSuffix(strings.TrimPrefix(key, prefix), "/"))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// list returns keys, or common prefixes if not recursive, of objects with the prefix
+func (s *S3Storage) list(prefix string, recursive bool) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.Config.Timeout)
+	defer cancel()
+	var keys []string
+	for obj := range s.client.ListObjects(ctx, s.Config.Bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: recursive}) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		keys = append(keys, obj.Key)
+	}
+	return keys, nil
+}
+
+// LatestRefs returns up to n latest references of reports stored for the test ordered by Git history, newest first
+func (s *S3Storage) LatestRefs(testName string, n int) ([]string, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("number of references must be > 0, got %d", n)
+	}
+	refs, err := s.ListRefs(testName)
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(refs) {
+	case 0:
+		return nil, fmt.Errorf("no reports found for test %s in bucket %s", testName, s.Config.Bucket)
+	case 1:
+		return refs, nil
+	default:
+		return findLatestGitRefs(s.Config.GitDirectory, refs, n)
+	}
+}
@@ -0,0 +1,306 @@
+package benchspy
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/wasp"
+)
+
+// fakeS3 implements the part of S3 API used by S3Storage: put, get and list objects v2 with path-style addressing.
+// Run the storage against framework's s3provider Minio to test it with a real S3 implementation
+type fakeS3 struct {
+	mu      *sync.Mutex
+	bucket  string
+	objects map[string][]byte
+}
+
+type fakeS3ListResult struct {
+	XMLName        xml.Name `xml:"ListBucketResult"`
+	Name           string   `xml:"Name"`
+	Prefix         string   `xml:"Prefix"`
+	KeyCount       int      `xml:"KeyCount"`
+	MaxKeys        int      `xml:"MaxKeys"`
+	IsTruncated    bool     `xml:"IsTruncated"`
+	Contents       []fakeS3Object
+	CommonPrefixes []fakeS3Prefix
+}
+
+type fakeS3Object struct {
+	Key          string `xml:"Key"`
+	Size         int    `xml:"Size"`
+	LastModified string `xml:"LastModified"`
+}
+
+type fakeS3Prefix struct {
+	Prefix string `xml:"Prefix"`
+}
+
+func newFakeS3(t *testing.T, bucket string) *httptest.Server {
+	f := &fakeS3{mu: &sync.Mutex{}, bucket: bucket, objects: make(map[string][]byte)}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != f.bucket {
+		f.error(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	switch {
+	case r.Method == http.MethodPut:
+		body, err := f.readBody(r)
+		if err != nil {
+			f.error(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		f.objects[key] = body
+		w.Header().Set("ETag", `"etag"`)
+	case r.Method == http.MethodGet && key == "":
+		f.list(w, r.URL.Query().Get("prefix"), r.URL.Query().Get("delimiter"))
+	case r.Method == http.MethodGet:
+		body, ok := f.objects[key]
+		if !ok {
+			f.error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("ETag", `"etag"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		_, _ = w.Write(body)
+	default:
+		f.error(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+// readBody decodes aws-chunked payloads used with streaming signatures over plain HTTP
+func (f *fakeS3) readBody(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+	var body []byte
+	rd := bufio.NewReader(r.Body)
+	for {
+		header, err := rd.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(header), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return body, nil
+		}
+		chunk := make([]byte, size+2)
+		if _, err := io.ReadFull(rd, chunk); err != nil {
+			return nil, err
+		}
+		body = append(body, chunk[:size]...)
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, prefix, delimiter string) {
+	res := fakeS3ListResult{Name: f.bucket, Prefix: prefix, MaxKeys: 1000}
+	prefixes := make(map[string]bool)
+	keys := make([]string, 0)
+	for key := range f.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if delimiter != "" {
+			if idx := strings.Index(strings.TrimPrefix(key, prefix), delimiter); idx >= 0 {
+				p := key[:len(prefix)+idx+len(delimiter)]
+				if !prefixes[p] {
+					prefixes[p] = true
+					res.CommonPrefixes = append(res.CommonPrefixes, fakeS3Prefix{Prefix: p})
+				}
+				continue
+			}
+		}
+		res.Contents = append(res.Contents, fakeS3Object{Key: key, Size: len(f.objects[key]), LastModified: time.Now().UTC().Format(time.RFC3339)})
+	}
+	res.KeyCount = len(res.Contents) + len(res.CommonPrefixes)
+	w.Header().Set("Content-Type", "application/xml")
+	_ = xml.NewEncoder(w).Encode(res)
+}
+
+func (f *fakeS3) error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+}
+
+func newTestS3Storage(t *testing.T, gitDir string) *S3Storage {
+	srv := newFakeS3(t, "reports")
+	storage, err := NewS3Storage(&S3Config{
+		Endpoint:     strings.TrimPrefix(srv.URL, "http://"),
+		AccessKey:    "access",
+		SecretKey:    "secret",
+		Bucket:       "reports",
+		Region:       "us-east-1",
+		GitDirectory: gitDir,
+	})
+	require.NoError(t, err)
+	return storage
+}
+
+func TestBenchSpy_S3Storage(t *testing.T) {
+	testS3Storage(t, func(gitDir string) *S3Storage { return newTestS3Storage(t, gitDir) })
+}
+
+// TestBenchSpy_S3Storage_Minio runs against a real S3 implementation, ex.: start Minio with framework's s3provider
+// (framework/examples/myproject/local_s3.toml) and set BENCHSPY_S3_* variables to its output
+func TestBenchSpy_S3Storage_Minio(t *testing.T) {
+	endpoint := os.Getenv("BENCHSPY_S3_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("BENCHSPY_S3_ENDPOINT is not set")
+	}
+	testS3Storage(t, func(gitDir string) *S3Storage {
+		storage, err := NewS3Storage(&S3Config{
+			Endpoint:  endpoint,
+			AccessKey: os.Getenv("BENCHSPY_S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("BENCHSPY_S3_SECRET_KEY"),
+			Bucket:    os.Getenv("BENCHSPY_S3_BUCKET"),
+			Region:    os.Getenv("BENCHSPY_S3_REGION"),
+			// every run starts with an empty prefix
+			Prefix:       fmt.Sprintf("benchspy-test-%d", time.Now().UnixNano()),
+			GitDirectory: gitDir,
+		})
+		require.NoError(t, err)
+		return storage
+	})
+}
+
+func testS3Storage(t *testing.T, newStorage func(gitDir string) *S3Storage) {
+	gitDir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = gitDir
+		out, err := cmd.Output()
+		require.NoError(t, err)
+		return strings.TrimSpace(string(out))
+	}
+	git("init")
+	git("config", "--local", "user.email", "test@example.com")
+	git("config", "--local", "user.name", "Test User")
+	git("config", "--local", "commit.gpgsign", "false")
+
+	storage := newStorage(gitDir)
+
+	t.Run("store and load by ref", func(t *testing.T) {
+		location, err := storage.Store("parent/sub", "v1", testReport{Data: "v1"})
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("s3://%s/%s/parent_sub/v1.json", storage.Config.Bucket, storage.Config.Prefix), location)
+
+		var loaded testReport
+		require.NoError(t, storage.Load("parent/sub", "v1", &loaded))
+		require.Equal(t, "v1", loaded.Data)
+
+		require.Error(t, storage.Load("parent/sub", "missing", &loaded))
+		_, err = storage.LatestRefs("unknown", 1)
+		require.Error(t, err)
+	})
+
+	t.Run("latest by git history", func(t *testing.T) {
+		var hashes []string
+		for i := 0; i < 3; i++ {
+			git("commit", "--allow-empty", "-m", fmt.Sprintf("commit %d", i))
+			hash := git("rev-parse", "HEAD")
+			hashes = append(hashes, hash)
+			_, err := storage.Store("history", hash, testReport{Data: hash})
+			require.NoError(t, err)
+		}
+
+		refs, err := storage.ListRefs("history")
+		require.NoError(t, err)
+		require.ElementsMatch(t, hashes, refs)
+
+		latest, err := storage.LatestRefs("history", 2)
+		require.NoError(t, err)
+		require.Equal(t, []string{hashes[2], hashes[1]}, latest)
+
+		var loaded testReport
+		require.NoError(t, storage.Load("history", "", &loaded))
+		require.Equal(t, hashes[2], loaded.Data)
+
+		names, err := storage.ListTestNames()
+		require.NoError(t, err)
+		require.Equal(t, []string{"history", "parent_sub"}, names)
+	})
+
+	t.Run("standard report", func(t *testing.T) {
+		git("commit", "--allow-empty", "-m", "report")
+		hash := git("rev-parse", "HEAD")
+		report := newTrendTestReport(hash, map[string]interface{}{string(MedianLatency): 10.0})
+		report.TestName = "standard"
+		report.Storage = storage
+		_, err := report.Store()
+		require.NoError(t, err)
+
+		loaded := &StandardReport{Storage: storage}
+		require.NoError(t, loaded.LoadLatest("standard"))
+		require.Equal(t, storage, loaded.Storage)
+		require.Equal(t, hash, loaded.CommitOrTag)
+		require.Equal(t, 10.0, MustAllDirectResults(loaded)["gen"][string(MedianLatency)])
+
+		history, err := LoadStandardReportHistory(storage, "standard", 5)
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+	})
+
+	t.Run("config", func(t *testing.T) {
+		_, err := NewS3Storage(&S3Config{Endpoint: "localhost:9000"})
+		require.Error(t, err)
+		_, err = storage.Store("", "v1", testReport{})
+		require.Error(t, err)
+	})
+}
+
+func TestBenchSpy_FetchNewReportAndLoadLatestPrevious_S3(t *testing.T) {
+	storage := newTestS3Storage(t, ".")
+	gen, err := wasp.NewGenerator(&wasp.Config{
+		T:        t,
+		GenName:  "s3",
+		LoadType: wasp.RPS,
+		Schedule: wasp.Plain(5, time.Second),
+		Gun:      wasp.NewMockGun(&wasp.MockGunConfig{CallSleep: 10 * time.Millisecond}),
+	})
+	require.NoError(t, err)
+	gen.Run(true)
+
+	previous, err := NewStandardReport("HEAD", WithStandardQueries(StandardQueryExecutor_Direct), WithGenerators(gen), WithReportStorage(storage))
+	require.NoError(t, err)
+	require.NoError(t, previous.FetchData(t.Context()))
+	location, err := previous.Store()
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(location, "s3://reports/"))
+
+	current, loaded, err := FetchNewStandardReportAndLoadLatestPrevious(t.Context(), "HEAD", WithStandardQueries(StandardQueryExecutor_Direct), WithGenerators(gen), WithReportStorage(storage))
+	require.NoError(t, err)
+	require.Equal(t, storage, current.Storage)
+	require.Equal(t, previous.QueryExecutors[0].Results(), loaded.QueryExecutors[0].Results())
+}
@@ -0,0 +1,561 @@
+package benchspy
+
+import (
+	goerrors "errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/wasp"
+)
+
+const (
+	DefaultStatisticalAlpha               = 0.05
+	DefaultStatisticalBootstrapIterations = 1000
+	DefaultStatisticalMaxBootstrapSamples = 10_000
+
+	StatisticalTestMannWhitneyU  = "mann-whitney-u"
+	StatisticalTestBootstrap     = "bootstrap"
+	StatisticalTestTwoProportion = "two-proportion-z"
+)
+
+var (
+	ErrNoSamples = errors.New("direct query executor has neither generator responses nor stored samples")
+)
+
+// Verdict is a result of a statistical comparison of a metric
+type Verdict string
+
+const (
+	VerdictNoChange         Verdict = "no_change"
+	VerdictRegression       Verdict = "regression"
+	VerdictImprovement      Verdict = "improvement"
+	VerdictInsufficientData Verdict = "insufficient_data"
+)
+
+// StatisticalConfig configures CompareDirectStatistically
+type StatisticalConfig struct {
+	// Alpha is the significance level, a change is significant if its p-value is below Alpha, defaults to 0.05.
+	// Bootstrap confidence intervals are computed at 1-Alpha level
+	Alpha float64
+	// BootstrapIterations is the amount of resamples used for confidence intervals, defaults to 1000
+	BootstrapIterations int
+	// MaxBootstrapSamples caps the size of each resample, larger samples are resampled at this size which widens the intervals, defaults to 10000
+	MaxBootstrapSamples int
+	// MinEffect is the minimum relative change in percent for a significant change to be reported as a regression or improvement, defaults to 0
+	MinEffect float64
+	// MinSamples is the minimum amount of calls in both reports to run the tests, defaults to 20
+	MinSamples int64
+	// Seed makes bootstrap resampling reproducible
+	Seed int64
+}
+
+// Validate checks the config and sets defaults
+func (c *StatisticalConfig) Validate() error {
+	if c.Alpha == 0 {
+		c.Alpha = DefaultStatisticalAlpha
+	}
+	if c.Alpha <= 0 || c.Alpha >= 1 {
+		return fmt.Errorf("alpha %.4f is not in the range (0, 1)", c.Alpha)
+	}
+	if c.MinEffect < 0 {
+		return fmt.Errorf("min effect %.4f must be >= 0", c.MinEffect)
+	}
+	if c.BootstrapIterations <= 0 {
+		c.BootstrapIterations = DefaultStatisticalBootstrapIterations
+	}
+	if c.MaxBootstrapSamples <= 0 {
+		c.MaxBootstrapSamples = DefaultStatisticalMaxBootstrapSamples
+	}
+	if c.MinSamples <= 0 {
+		c.MinSamples = 20
+	}
+	return nil
+}
+
+// MetricVerdict is a statistical comparison of one metric of a generator, latencies are in milliseconds.
+// CILow and CIHigh bound the difference current - previous
+type MetricVerdict struct {
+	Metric         string  `json:"metric"`
+	Test           string  `json:"test"`
+	Previous       float64 `json:"previous"`
+	Current        float64 `json:"current"`
+	DiffPercentage float64 `json:"diff_percentage"`
+	CILow          float64 `json:"ci_low"`
+	CIHigh         float64 `json:"ci_high"`
+	PValue         float64 `json:"p_value"`
+	Verdict        Verdict `json:"verdict"`
+}
+
+// MannWhitneyResult is a result of the Mann-Whitney U test of current against previous latencies.
+// Effect is the probability that a current call is slower than a previous one, 0.5 means no shift
+type MannWhitneyResult struct {
+	U      float64 `json:"u"`
+	Z      float64 `json:"z"`
+	PValue float64 `json:"p_value"`
+	Effect float64 `json:"effect"`
+}
+
+// GeneratorVerdict holds metric verdicts of one generator
+type GeneratorVerdict struct {
+	Generator       string                    `json:"generator"`
+	PreviousSamples int64                     `json:"previous_samples"`
+	CurrentSamples  int64                     `json:"current_samples"`
+	MannWhitney     *MannWhitneyResult        `json:"mann_whitney,omitempty"`
+	Metrics         map[string]*MetricVerdict `json:"metrics"`
+}
+
+// StatisticalComparison is a result of CompareDirectStatistically
+type StatisticalComparison struct {
+	Current    string                       `json:"current"`
+	Previous   string                       `json:"previous"`
+	Alpha      float64                      `json:"alpha"`
+	Generators map[string]*GeneratorVerdict `json:"generators"`
+}
+
+// Regressions returns all metric verdicts that are regressions, prefixed with the generator name
+func (sc *StatisticalComparison) Regressions() map[string]*MetricVerdict {
+	regressions := make(map[string]*MetricVerdict)
+	for name, gv := range sc.Generators {
+		for metric, mv := range gv.Metrics {
+			if mv.Verdict == VerdictRegression {
+				regressions[name+"/"+metric] = mv
+			}
+		}
+	}
+	return regressions
+}
+
+// HasRegression returns true if any metric of any generator regressed
+func (sc *StatisticalComparison) HasRegression() bool {
+	return len(sc.Regressions()) > 0
+}
+
+// weightedSamples are distinct sorted values with their counts, raw samples have mostly count 1, histograms are already grouped
+type weightedSamples struct {
+	values []float64
+	counts []int64
+	total  int64
+}
+
+func newWeightedSamplesFromValues(values []float64) *weightedSamples {
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	ws := &weightedSamples{}
+	for _, v := range sorted {
+		if n := len(ws.values); n > 0 && ws.values[n-1] == v {
+			ws.counts[n-1]++
+		} else {
+			ws.values = append(ws.values, v)
+			ws.counts = append(ws.counts, 1)
+		}
+		ws.total++
+	}
+	return ws
+}
+
+func newWeightedSamplesFromHistogram(h *wasp.LatencyHistogram) *weightedSamples {
+	ws := &weightedSamples{}
+	for _, b := range h.Buckets() {
+		ws.values = append(ws.values, durationToMillis(b.Value))
+		ws.counts = append(ws.counts, b.Count)
+		ws.total += b.Count
+	}
+	return ws
+}
+
+// percentile is a nearest-rank percentile, p is 0-100
+func (ws *weightedSamples) percentile(p float64) float64 {
+	return percentileOfCounts(ws.values, ws.counts, ws.total, p)
+}
+
+func percentileOfCounts(values []float64, counts []int64, total int64, p float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	rank := int64(math.Ceil(p / 100 * float64(total)))
+	rank = max(rank, 1)
+	var acc int64
+	for i, c := range counts {
+		acc += c
+		if acc >= rank {
+			return values[i]
+		}
+	}
+	return values[len(values)-1]
+}
+
+// resample draws n values with replacement and returns their counts per distinct value
+func (ws *weightedSamples) resample(rnd *rand.Rand, n int64, cumulative []int64, counts []int64) {
+	for i := range counts {
+		counts[i] = 0
+	}
+	for i := int64(0); i < n; i++ {
+		x := rnd.Int63n(ws.total)
+		counts[sort.Search(len(cumulative), func(j int) bool { return cumulative[j] > x })]++
+	}
+}
+
+func (ws *weightedSamples) cumulative() []int64 {
+	cumulative := make([]int64, len(ws.counts))
+	var acc int64
+	for i, c := range ws.counts {
+		acc += c
+		cumulative[i] = acc
+	}
+	return cumulative
+}
+
+func durationToMillis(d time.Duration) float64 {
+	// same precision as standard Direct queries
+	return float64(d.Nanoseconds()) / 1_000_000
+}
+
+// directResponses returns generator responses kept in memory, reports loaded from storage don't have them
+func directResponses(dqe *DirectQueryExecutor) []*wasp.Response {
+	if dqe.Generator == nil || dqe.Generator.GetData() == nil {
+		return nil
+	}
+	data := dqe.Generator.GetData()
+	return append(append([]*wasp.Response{}, data.OKResponses.Data...), data.FailResponses.Data...)
+}
+
+// directSamples returns raw latencies of generator responses if they are available, stored histogram otherwise
+func directSamples(dqe *DirectQueryExecutor) (*weightedSamples, int64, int64, error) {
+	responses := directResponses(dqe)
+	if len(responses) == 0 {
+		return histogramSamples(dqe)
+	}
+	values := make([]float64, 0, len(responses))
+	var failed int64
+	for _, r := range responses {
+		values = append(values, durationToMillis(r.Duration))
+		if r.Failed || r.Timeout {
+			failed++
+		}
+	}
+	return newWeightedSamplesFromValues(values), int64(len(responses)), failed, nil
+}
+
+// histogramSamples returns the latency histogram of the executor, it's built from generator responses if it wasn't executed yet
+func histogramSamples(dqe *DirectQueryExecutor) (*weightedSamples, int64, int64, error) {
+	samples := dqe.Samples
+	if samples == nil || samples.Latency == nil {
+		if responses := directResponses(dqe); len(responses) > 0 {
+			samples = newDirectSamples(responses)
+		}
+	}
+	if samples == nil || samples.Latency == nil {
+		return nil, 0, 0, ErrNoSamples
+	}
+	return newWeightedSamplesFromHistogram(samples.Latency), samples.Calls, samples.Failed, nil
+}
+
+// mannWhitneyU tests whether current latencies are shifted against previous ones, using normal approximation
+// with tie and continuity correction
+func mannWhitneyU(previous, current *weightedSamples) *MannWhitneyResult {
+	n1, n2 := float64(previous.total), float64(current.total)
+	n := n1 + n2
+	var rankSum, tieTerm, before float64
+	i, j := 0, 0
+	for i < len(previous.values) || j < len(current.values) {
+		var a, b int64
+		switch {
+		case j >= len(current.values) || (i < len(previous.values) && previous.values[i] < current.values[j]):
+			a = previous.counts[i]
+			i++
+		case i >= len(previous.values) || current.values[j] < previous.values[i]:
+			b = current.counts[j]
+			j++
+		default:
+			a, b = previous.counts[i], current.counts[j]
+			i++
+			j++
+		}
+		t := float64(a + b)
+		rankSum += float64(b) * (before + (t+1)/2)
+		tieTerm += t*t*t - t
+		before += t
+	}
+	u := rankSum - n2*(n2+1)/2
+	res := &MannWhitneyResult{U: u, Effect: u / (n1 * n2), PValue: 1}
+	mean := n1 * n2 / 2
+	variance := n1 * n2 / 12 * ((n + 1) - tieTerm/(n*(n-1)))
+	if variance <= 0 {
+		return res
+	}
+	diff := u - mean
+	switch {
+	case diff > 0.5:
+		diff -= 0.5
+	case diff < -0.5:
+		diff += 0.5
+	default:
+		diff = 0
+	}
+	res.Z = diff / math.Sqrt(variance)
+	res.PValue = math.Erfc(math.Abs(res.Z) / math.Sqrt2)
+	return res
+}
+
+// bootstrapPercentileDiffs returns bootstrapped differences current - previous for every percentile
+func bootstrapPercentileDiffs(cfg *StatisticalConfig, previous, current *weightedSamples, percentiles []float64) [][]float64 {
+	rnd := rand.New(rand.NewSource(cfg.Seed)) //nolint:gosec
+	prevN := min(previous.total, int64(cfg.MaxBootstrapSamples))
+	curN := min(current.total, int64(cfg.MaxBootstrapSamples))
+	prevCum, curCum := previous.cumulative(), current.cumulative()
+	prevCounts, curCounts := make([]int64, len(previous.counts)), make([]int64, len(current.counts))
+	diffs := make([][]float64, len(percentiles))
+	for it := 0; it < cfg.BootstrapIterations; it++ {
+		previous.resample(rnd, prevN, prevCum, prevCounts)
+		current.resample(rnd, curN, curCum, curCounts)
+		for i, p := range percentiles {
+			diffs[i] = append(diffs[i], percentileOfCounts(current.values, curCounts, curN, p)-percentileOfCounts(previous.values, prevCounts, prevN, p))
+		}
+	}
+	return diffs
+}
+
+// bootstrapInterval returns the 1-alpha percentile interval of diffs and a two-sided p-value of the difference being 0
+func bootstrapInterval(diffs []float64, alpha float64) (float64, float64, float64) {
+	sorted := append([]float64{}, diffs...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	at := func(q float64) float64 {
+		idx := int(math.Floor(q * float64(n-1)))
+		return sorted[min(max(idx, 0), n-1)]
+	}
+	var below, above int
+	for _, d := range sorted {
+		if d <= 0 {
+			below++
+		}
+		if d >= 0 {
+			above++
+		}
+	}
+	p := math.Min(1, 2*math.Min(float64(below), float64(above))/float64(n))
+	return at(alpha / 2), at(1 - alpha/2), p
+}
+
+// twoProportionZ tests whether the failure rate changed, it returns the p-value and the 1-alpha Wald interval of the difference
+func twoProportionZ(prevCalls, prevFailed, curCalls, curFailed int64, alpha float64) (float64, float64, float64) {
+	n1, n2 := float64(prevCalls), float64(curCalls)
+	p1, p2 := float64(prevFailed)/n1, float64(curFailed)/n2
+	diff := p2 - p1
+	z := math.Sqrt2 * math.Erfinv(1-alpha)
+	margin := z * math.Sqrt(p1*(1-p1)/n1+p2*(1-p2)/n2)
+	pooled := float64(prevFailed+curFailed) / (n1 + n2)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/n1 + 1/n2))
+	if se == 0 {
+		return 1, diff - margin, diff + margin
+	}
+	return math.Erfc(math.Abs(diff) / se / math.Sqrt2), diff - margin, diff + margin
+}
+
+// decide returns a verdict for a significant or insignificant change, higher values are worse
+func (c *StatisticalConfig) decide(significant bool, diffPercentage float64) Verdict {
+	if !significant || math.Abs(diffPercentage) < c.MinEffect {
+		return VerdictNoChange
+	}
+	if diffPercentage > 0 {
+		return VerdictRegression
+	}
+	if diffPercentage < 0 {
+		return VerdictImprovement
+	}
+	return VerdictNoChange
+}
+
+func compareGeneratorStatistically(cfg *StatisticalConfig, name string, previousExecutor, currentExecutor *DirectQueryExecutor) (*GeneratorVerdict, error) {
+	samplesOf := directSamples
+	if len(directResponses(previousExecutor)) == 0 || len(directResponses(currentExecutor)) == 0 {
+		// raw latencies are shifted against the upper bounds of histogram buckets, so both sides are histograms
+		// if either of the reports was loaded from storage
+		samplesOf = histogramSamples
+	}
+	previous, prevCalls, prevFailed, err := samplesOf(previousExecutor)
+	if err != nil {
+		return nil, fmt.Errorf("previous report: %w", err)
+	}
+	current, curCalls, curFailed, err := samplesOf(currentExecutor)
+	if err != nil {
+		return nil, fmt.Errorf("current report: %w", err)
+	}
+
+	gv := &GeneratorVerdict{
+		Generator:       name,
+		PreviousSamples: previous.total,
+		CurrentSamples:  current.total,
+		Metrics:         make(map[string]*MetricVerdict),
+	}
+	if previous.total < cfg.MinSamples || current.total < cfg.MinSamples {
+		for _, metric := range []StandardLoadMetric{MedianLatency, Percentile95Latency, Percentile99Latency, ErrorRate} {
+			gv.Metrics[string(metric)] = &MetricVerdict{Metric: string(metric), Verdict: VerdictInsufficientData, PValue: 1}
+		}
+		return gv, nil
+	}
+
+	gv.MannWhitney = mannWhitneyU(previous, current)
+
+	percentiles := []float64{50, 95, 99}
+	metrics := []StandardLoadMetric{MedianLatency, Percentile95Latency, Percentile99Latency}
+	diffs := bootstrapPercentileDiffs(cfg, previous, current, percentiles)
+	for i, metric := range metrics {
+		mv := &MetricVerdict{
+			Metric:   string(metric),
+			Test:     StatisticalTestBootstrap,
+			Previous: previous.percentile(percentiles[i]),
+			Current:  current.percentile(percentiles[i]),
+		}
+		mv.DiffPercentage = calculateDiffPercentage(mv.Current, mv.Previous)
+		mv.CILow, mv.CIHigh, mv.PValue = bootstrapInterval(diffs[i], cfg.Alpha)
+		significant := mv.CILow > 0 || mv.CIHigh < 0
+		if metric == MedianLatency {
+			// the median shift is confirmed by the rank test, which uses all samples and not only the middle ones
+			mv.Test = StatisticalTestMannWhitneyU + "+" + StatisticalTestBootstrap
+			mv.PValue = gv.MannWhitney.PValue
+			significant = significant && gv.MannWhitney.PValue < cfg.Alpha
+		}
+		mv.Verdict = cfg.decide(significant, mv.DiffPercentage)
+		gv.Metrics[string(metric)] = mv
+	}
+
+	er := &MetricVerdict{
+		Metric:   string(ErrorRate),
+		Test:     StatisticalTestTwoProportion,
+		Previous: float64(prevFailed) / float64(prevCalls),
+		Current:  float64(curFailed) / float64(curCalls),
+	}
+	er.DiffPercentage = calculateDiffPercentage(er.Current, er.Previous)
+	er.PValue, er.CILow, er.CIHigh = twoProportionZ(prevCalls, prevFailed, curCalls, curFailed, cfg.Alpha)
+	er.Verdict = cfg.decide(er.PValue < cfg.Alpha, er.DiffPercentage)
+	gv.Metrics[string(ErrorRate)] = er
+
+	return gv, nil
+}
+
+func directExecutorsByGenerator(sr *StandardReport) map[string]*DirectQueryExecutor {
+	executors := make(map[string]*DirectQueryExecutor)
+	for _, queryExecutor := range sr.QueryExecutors {
+		if asDirect, ok := queryExecutor.(*DirectQueryExecutor); ok {
+			executors[asDirect.GeneratorName()] = asDirect
+		}
+	}
+	return executors
+}
+
+// CompareDirectStatistically compares latency distributions and error rates of Direct query executors of both reports
+// using raw per-call samples, or latency histograms stored with reports loaded from storage.
+// Median latency shift is tested with Mann-Whitney U test, p95 and p99 latency changes with bootstrap confidence intervals
+// and error rate with two-proportion z-test. Max latency is a single extreme value and is not tested, use CompareDirectWithThresholds for it.
+// It returns a verdict per generator and metric and an error listing all regressions if any were found.
+func CompareDirectStatistically(cfg *StatisticalConfig, currentReport, previousReport *StandardReport) (*StatisticalComparison, error) {
+	if currentReport == nil || previousReport == nil {
+		return nil, errors.New("one or both reports are nil")
+	}
+	if cfg == nil {
+		cfg = &StatisticalConfig{}
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	L.Info().
+		Str("Current report", currentReport.CommitOrTag).
+		Str("Previous report", previousReport.CommitOrTag).
+		Float64("Alpha", cfg.Alpha).
+		Int("Bootstrap iterations", cfg.BootstrapIterations).
+		Float64("Min effect", cfg.MinEffect).
+		Msg("Comparing Direct metrics statistically")
+
+	currentExecutors := directExecutorsByGenerator(currentReport)
+	previousExecutors := directExecutorsByGenerator(previousReport)
+
+	comparison := &StatisticalComparison{
+		Current:    currentReport.CommitOrTag,
+		Previous:   previousReport.CommitOrTag,
+		Alpha:      cfg.Alpha,
+		Generators: make(map[string]*GeneratorVerdict),
+	}
+	var errs []error
+	for name, currentExecutor := range currentExecutors {
+		previousExecutor, ok := previousExecutors[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("generator %s results were missing from previous report", name))
+			continue
+		}
+		gv, err := compareGeneratorStatistically(cfg, name, previousExecutor, currentExecutor)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("[%s] %w", name, err))
+			continue
+		}
+		comparison.Generators[name] = gv
+	}
+	if len(errs) > 0 {
+		return nil, goerrors.Join(errs...)
+	}
+
+	regressions := make(map[string][]error)
+	for name, gv := range comparison.Generators {
+		for _, mv := range gv.Metrics {
+			if mv.Verdict == VerdictRegression {
+				regressions[name] = append(regressions[name], fmt.Errorf("%s is %.4f%% different with p-value %.4f, which is lower than alpha %.4f", mv.Metric, mv.DiffPercentage, mv.PValue, cfg.Alpha))
+			}
+		}
+	}
+
+	L.Info().
+		Str("Current report", currentReport.CommitOrTag).
+		Str("Previous report", previousReport.CommitOrTag).
+		Int("Generators with regressions", len(regressions)).
+		Msg("Finished comparing Direct metrics statistically")
+
+	return comparison, concatenateGeneratorErrors(regressions)
+}
+
+// PrintStatisticalComparison outputs verdicts of all metrics of all generators
+func PrintStatisticalComparison(comparison *StatisticalComparison) {
+	names := make([]string, 0, len(comparison.Generators))
+	for name := range comparison.Generators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		gv := comparison.Generators[name]
+		table := tablewriter.NewWriter(os.Stderr)
+		table.SetHeader([]string{"Metric", comparison.Previous, comparison.Current, "Diff %", "CI", "p-value", "Test", "Verdict"})
+		for _, metric := range []StandardLoadMetric{MedianLatency, Percentile95Latency, Percentile99Latency, ErrorRate} {
+			mv, ok := gv.Metrics[string(metric)]
+			if !ok {
+				continue
+			}
+			table.Append([]string{
+				mv.Metric,
+				fmt.Sprintf("%.4f", mv.Previous),
+				fmt.Sprintf("%.4f", mv.Current),
+				fmt.Sprintf("%.4f", mv.DiffPercentage),
+				fmt.Sprintf("[%.4f, %.4f]", mv.CILow, mv.CIHigh),
+				fmt.Sprintf("%.4f", mv.PValue),
+				mv.Test,
+				string(mv.Verdict),
+			})
+		}
+		table.SetBorder(true)
+		table.SetRowLine(true)
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+
+		title := "Generator: " + name
+		fmt.Println(title)
+		fmt.Println(strings.Repeat("=", len(title)))
+
+		table.Render()
+	}
+}
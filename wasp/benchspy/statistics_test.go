@@ -0,0 +1,152 @@
+package benchspy
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/wasp"
+)
+
+// newStatisticalTestReport creates an executed report of an offline generator with normally distributed latencies
+func newStatisticalTestReport(t *testing.T, ref string, seed int64, calls int, meanMs, failRate float64) *StandardReport {
+	rnd := rand.New(rand.NewSource(seed)) //nolint:gosec
+	run := &wasp.RecordedRun{Meta: &wasp.RunMeta{Config: &wasp.Config{GenName: "gen", LoadType: wasp.RPS}}}
+	for i := 0; i < calls; i++ {
+		d := time.Duration((meanMs + rnd.NormFloat64()*meanMs/10) * float64(time.Millisecond))
+		run.Responses = append(run.Responses, &wasp.Response{Duration: d, Failed: rnd.Float64() < failRate})
+	}
+	executor, err := NewStandardDirectQueryExecutor(run.Generator())
+	require.NoError(t, err)
+	require.NoError(t, executor.Execute(context.Background()))
+	return &StandardReport{
+		BasicData:      BasicData{TestName: "stat", CommitOrTag: ref},
+		QueryExecutors: []QueryExecutor{executor},
+	}
+}
+
+// loadStatisticalTestReport returns the report as it's loaded from storage, without generator responses
+func loadStatisticalTestReport(t *testing.T, r *StandardReport) *StandardReport {
+	data, err := json.Marshal(r)
+	require.NoError(t, err)
+	var loaded StandardReport
+	require.NoError(t, json.Unmarshal(data, &loaded))
+	return &loaded
+}
+
+func TestBenchSpy_MannWhitneyU(t *testing.T) {
+	res := mannWhitneyU(newWeightedSamplesFromValues([]float64{1, 2, 3}), newWeightedSamplesFromValues([]float64{4, 5, 6}))
+	require.Equal(t, 9.0, res.U)
+	require.Equal(t, 1.0, res.Effect)
+
+	res = mannWhitneyU(newWeightedSamplesFromValues([]float64{1, 2}), newWeightedSamplesFromValues([]float64{2, 3}))
+	require.Equal(t, 3.5, res.U)
+	require.Equal(t, 0.875, res.Effect)
+
+	res = mannWhitneyU(newWeightedSamplesFromValues([]float64{5, 5, 5}), newWeightedSamplesFromValues([]float64{5, 5}))
+	require.Equal(t, 1.0, res.PValue)
+}
+
+func TestBenchSpy_CompareDirectStatistically(t *testing.T) {
+	cfg := &StatisticalConfig{Seed: 1}
+
+	t.Run("same distribution", func(t *testing.T) {
+		previous := newStatisticalTestReport(t, "v1", 1, 1000, 50, 0.01)
+		current := newStatisticalTestReport(t, "v2", 2, 1000, 50, 0.01)
+		res, err := CompareDirectStatistically(cfg, current, previous)
+		require.NoError(t, err)
+		require.False(t, res.HasRegression())
+		gv := res.Generators["gen"]
+		require.Equal(t, int64(1000), gv.CurrentSamples)
+		require.InDelta(t, 0.5, gv.MannWhitney.Effect, 0.05)
+		for _, metric := range []StandardLoadMetric{MedianLatency, Percentile95Latency, Percentile99Latency, ErrorRate} {
+			mv := gv.Metrics[string(metric)]
+			require.Equal(t, VerdictNoChange, mv.Verdict, metric)
+			require.LessOrEqual(t, mv.CILow, mv.CIHigh)
+		}
+	})
+
+	t.Run("regression", func(t *testing.T) {
+		previous := newStatisticalTestReport(t, "v1", 1, 1000, 50, 0.01)
+		current := newStatisticalTestReport(t, "v2", 2, 1000, 60, 0.1)
+		res, err := CompareDirectStatistically(cfg, current, previous)
+		require.Error(t, err)
+		require.True(t, res.HasRegression())
+		gv := res.Generators["gen"]
+		require.Greater(t, gv.MannWhitney.Effect, 0.7)
+		require.Len(t, res.Regressions(), 4)
+		median := gv.Metrics[string(MedianLatency)]
+		require.InDelta(t, 20, median.DiffPercentage, 5)
+		require.Greater(t, median.CILow, 0.0)
+		require.Less(t, median.PValue, 0.001)
+		require.Equal(t, StatisticalTestTwoProportion, gv.Metrics[string(ErrorRate)].Test)
+	})
+
+	t.Run("improvement and min effect", func(t *testing.T) {
+		previous := newStatisticalTestReport(t, "v1", 1, 1000, 50, 0)
+		current := newStatisticalTestReport(t, "v2", 2, 1000, 48, 0)
+		res, err := CompareDirectStatistically(cfg, current, previous)
+		require.NoError(t, err)
+		require.Equal(t, VerdictImprovement, res.Generators["gen"].Metrics[string(MedianLatency)].Verdict)
+
+		res, err = CompareDirectStatistically(&StatisticalConfig{Seed: 1, MinEffect: 10}, current, previous)
+		require.NoError(t, err)
+		require.Equal(t, VerdictNoChange, res.Generators["gen"].Metrics[string(MedianLatency)].Verdict)
+	})
+
+	t.Run("stored reports use histograms", func(t *testing.T) {
+		previous := newStatisticalTestReport(t, "v1", 1, 1000, 50, 0.01)
+		current := newStatisticalTestReport(t, "v2", 2, 1000, 60, 0.1)
+		live, _ := CompareDirectStatistically(cfg, current, previous)
+		stored, err := CompareDirectStatistically(cfg, loadStatisticalTestReport(t, current), loadStatisticalTestReport(t, previous))
+		require.Error(t, err)
+		for metric, mv := range live.Generators["gen"].Metrics {
+			storedMv := stored.Generators["gen"].Metrics[metric]
+			require.Equal(t, mv.Verdict, storedMv.Verdict, metric)
+			require.InEpsilon(t, mv.Current, storedMv.Current, 0.02, metric)
+		}
+	})
+
+	t.Run("live report against stored baseline", func(t *testing.T) {
+		previous := newStatisticalTestReport(t, "v1", 1, 1000, 50, 0.01)
+		current := newStatisticalTestReport(t, "v2", 2, 1000, 50, 0.01)
+		live, err := CompareDirectStatistically(cfg, current, previous)
+		require.NoError(t, err)
+		for _, tc := range []struct{ current, previous *StandardReport }{
+			{current, loadStatisticalTestReport(t, previous)},
+			{loadStatisticalTestReport(t, current), previous},
+		} {
+			res, err := CompareDirectStatistically(cfg, tc.current, tc.previous)
+			require.NoError(t, err)
+			gv := res.Generators["gen"]
+			require.InDelta(t, live.Generators["gen"].MannWhitney.PValue, gv.MannWhitney.PValue, 0.1)
+			for metric, mv := range gv.Metrics {
+				require.Equal(t, VerdictNoChange, mv.Verdict, metric)
+			}
+		}
+	})
+
+	t.Run("insufficient data", func(t *testing.T) {
+		previous := newStatisticalTestReport(t, "v1", 1, 10, 50, 0)
+		current := newStatisticalTestReport(t, "v2", 2, 10, 100, 0)
+		res, err := CompareDirectStatistically(cfg, current, previous)
+		require.NoError(t, err)
+		require.Equal(t, VerdictInsufficientData, res.Generators["gen"].Metrics[string(MedianLatency)].Verdict)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := CompareDirectStatistically(cfg, nil, nil)
+		require.Error(t, err)
+		_, err = CompareDirectStatistically(&StatisticalConfig{Alpha: 2}, &StandardReport{}, &StandardReport{})
+		require.Error(t, err)
+		empty := &StandardReport{QueryExecutors: []QueryExecutor{&DirectQueryExecutor{Generator: &wasp.Generator{Cfg: &wasp.Config{GenName: "gen"}}}}}
+		_, err = CompareDirectStatistically(cfg, empty, empty)
+		require.ErrorIs(t, err, ErrNoSamples)
+		_, err = CompareDirectStatistically(cfg, newStatisticalTestReport(t, "v2", 2, 100, 50, 0), &StandardReport{})
+		require.Error(t, err)
+	})
+}
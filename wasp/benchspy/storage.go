@@ -1,6 +1,7 @@
 package benchspy
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"fmt"
@@ -9,6 +10,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -152,30 +154,41 @@ func (l *LocalStorage) findLatestGitRef(refs []string) (string, error) {
 		Int("References found", len(refs)).
 		Msg("Finding latest report based on Git history")
 
+	latest, err := findLatestGitRefs(l.Directory, refs, 1)
+	if err != nil {
+		return "", err
+	}
+
+	return latest[0], nil
+}
+
+// findLatestGitRefs orders refs by history of the Git repository containing gitDirectory and returns up to n latest ones, newest first.
+// Refs that can't be resolved to a commit are skipped, refs pointing to the same commit are returned together
+func findLatestGitRefs(gitDirectory string, refs []string, n int) ([]string, error) {
 	L.Trace().
 		Str("References", strings.Join(refs, ", ")).
 		Msg("Resolving references to commit hashes")
 
-	var ref string
 	// Find git root
 	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
-	cmd.Dir = l.Directory
+	cmd.Dir = gitDirectory
 	out, err := cmd.Output()
 	if err != nil {
-		return "", errors.Wrap(err, "failed to find git root")
+		return nil, errors.Wrap(err, "failed to find git root")
 	}
 	gitRoot := strings.TrimSpace(string(out))
 
 	// Resolve all refs to commit hashes
-	resolvedRefs := make(map[string]string)
+	refsByHash := make(map[string][]string)
 	for _, ref := range refs {
 		cmd = exec.Command("git", "rev-parse", ref)
 		cmd.Dir = gitRoot
 		if out, err := cmd.Output(); err == nil {
-			resolvedRefs[ref] = strings.TrimSpace(string(out))
+			hash := strings.TrimSpace(string(out))
+			refsByHash[hash] = append(refsByHash[hash], ref)
 			L.Trace().
 				Str("Reference", ref).
-				Str("Resolved", resolvedRefs[ref]).
+				Str("Resolved", hash).
 				Msg("Reference resolved to commit hash")
 		} else {
 			L.Warn().
@@ -184,45 +197,99 @@ func (l *LocalStorage) findLatestGitRef(refs []string) (string, error) {
 		}
 	}
 
-	// Find latest among resolved commits
 	var commitRefs []string
-	for _, hash := range resolvedRefs {
+	for hash := range refsByHash {
 		commitRefs = append(commitRefs, hash)
 	}
+	sort.Strings(commitRefs)
 
 	L.Debug().
 		Str("Commits found", strings.Join(commitRefs, ", ")).
-		Msg("Finding latest commit among resolved references")
+		Msg("Ordering resolved references by Git history")
+
+	if len(commitRefs) == 0 {
+		return nil, errors.New("failed to find latest reference: none of the references could be resolved to a commit")
+	}
 
-	args := append([]string{"rev-list", "--topo-order", "--date-order", "--max-count=1"}, commitRefs...)
+	// children are always listed before their parents, so resolved commits come out newest first.
+	// The output is streamed and git is stopped as soon as n refs or all resolved commits are found,
+	// so the whole history isn't walked
+	args := append([]string{"rev-list", "--topo-order", "--date-order"}, commitRefs...)
 	cmd = exec.Command("git", args...)
 	cmd.Dir = gitRoot
-	out, err = cmd.Output()
+	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		return "", errors.Wrap(err, "failed to find latest reference")
+		return nil, errors.Wrap(err, "failed to find latest reference")
 	}
-	latestCommit := strings.TrimSpace(string(out))
-
-	// Find original ref for this commit
-	foundOriginal := false
-	for origRef, hash := range resolvedRefs {
-		if hash == latestCommit {
-			ref = origRef
-			foundOriginal = true
+	if err := cmd.Start(); err != nil {
+		return nil, errors.Wrap(err, "failed to find latest reference")
+	}
+
+	var latest []string
+	found := 0
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		if origRefs, ok := refsByHash[strings.TrimSpace(scanner.Text())]; ok {
+			sort.Strings(origRefs)
+			latest = append(latest, origRefs...)
+			found++
+		}
+		if len(latest) >= n || found == len(refsByHash) {
 			break
 		}
 	}
+	scanErr := scanner.Err()
+	// git is killed if it's still walking the history, its exit error is expected then
+	_ = cmd.Process.Kill()
+	waitErr := cmd.Wait()
+	if scanErr != nil {
+		return nil, errors.Wrap(scanErr, "failed to find latest reference")
+	}
+	if found < len(refsByHash) && len(latest) < n && waitErr != nil {
+		return nil, errors.Wrap(waitErr, "failed to find latest reference")
+	}
 
 	L.Debug().
-		Str("Latest commit", latestCommit).
-		Str("Original ref", ref).
-		Msg("Found original reference for latest commit")
+		Str("Latest references", strings.Join(latest, ", ")).
+		Msg("Found latest references")
 
-	if !foundOriginal {
-		return "", fmt.Errorf("no file found for latest commit %s. This should never happen", latestCommit)
+	if len(latest) == 0 {
+		return nil, errors.New("no commits found for any of the references. This should never happen")
 	}
 
-	return ref, nil
+	return latest[:min(len(latest), n)], nil
+}
+
+// LatestRefs returns up to n latest references of reports stored for the test, newest first.
+// References are ordered by Git history the same way Load finds the latest report
+func (l *LocalStorage) LatestRefs(testName string, n int) ([]string, error) {
+	l.defaultDirectoryIfEmpty()
+	if testName == "" {
+		return nil, errors.New("test name is empty. Please set it and try again")
+	}
+	if n <= 0 {
+		return nil, fmt.Errorf("number of references must be > 0, got %d", n)
+	}
+
+	cleanTestName := l.cleanTestName(testName)
+	entries, err := os.ReadDir(l.Directory)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read storage directory")
+	}
+
+	refs, refErr := l.findAllGitlikeReferences(cleanTestName, entries)
+	if refErr != nil {
+		return nil, refErr
+	}
+
+	switch len(refs) {
+	case 0:
+		return nil, fmt.Errorf("no reports found in directory %s", l.Directory)
+	case 1:
+		return refs, nil
+	default:
+		return findLatestGitRefs(l.Directory, refs, n)
+	}
 }
 
 func (l *LocalStorage) findRef(cleanTestName string) (string, error) {
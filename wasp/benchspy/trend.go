@@ -0,0 +1,315 @@
+package benchspy
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/montanaflynn/stats"
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultTrendWindow             = 10
+	DefaultTrendMADThreshold       = 3.0
+	DefaultTrendMaxDriftPercentage = 10.0
+
+	// madScale makes MAD a consistent estimator of the standard deviation of normally distributed values
+	madScale = 1.4826
+	// minMADRatio floors MAD to a ratio of the baseline, so negligible changes of very stable metrics aren't flagged
+	minMADRatio = 0.01
+)
+
+// TrendConfig configures AnalyzeTrend
+type TrendConfig struct {
+	// Window is the amount of preceding reports used for a rolling baseline, defaults to 10
+	Window int
+	// MADThreshold is the amount of scaled MADs a value has to differ from its baseline to be flagged as a step change, defaults to 3
+	MADThreshold float64
+	// MaxDriftPercentage is the max allowed change in percent of the rolling median of the latest Window reports
+	// against the rolling median of the first Window reports, defaults to 10
+	MaxDriftPercentage float64
+	// Metrics are Direct query names to analyze, defaults to StandardLoadMetrics
+	Metrics []string
+}
+
+// Validate checks the config and sets defaults
+func (c *TrendConfig) Validate() error {
+	if c.Window < 0 || c.MADThreshold < 0 || c.MaxDriftPercentage < 0 {
+		return errors.New("window, MAD threshold and max drift percentage must be >= 0")
+	}
+	if c.Window == 0 {
+		c.Window = DefaultTrendWindow
+	}
+	if c.MADThreshold == 0 {
+		c.MADThreshold = DefaultTrendMADThreshold
+	}
+	if c.MaxDriftPercentage == 0 {
+		c.MaxDriftPercentage = DefaultTrendMaxDriftPercentage
+	}
+	if len(c.Metrics) == 0 {
+		for _, metric := range StandardLoadMetrics {
+			c.Metrics = append(c.Metrics, string(metric))
+		}
+	}
+	return nil
+}
+
+// TrendPoint is a metric value of one report compared to the rolling baseline of preceding reports,
+// the first Window reports aren't scored
+type TrendPoint struct {
+	Ref   string  `json:"ref"`
+	Value float64 `json:"value"`
+	// Baseline and MAD are the median and the median absolute deviation of up to Window preceding values
+	Baseline float64 `json:"baseline"`
+	MAD      float64 `json:"mad"`
+	// Score is the distance from the baseline in scaled MADs, positive means worse
+	Score float64 `json:"score"`
+	// Step is set when the value is worse than the baseline by more than MADThreshold scaled MADs
+	Step bool `json:"step"`
+}
+
+// MetricTrend is a trend of one metric of one generator, points are ordered from the oldest report
+type MetricTrend struct {
+	Generator string       `json:"generator"`
+	Metric    string       `json:"metric"`
+	Points    []TrendPoint `json:"points"`
+	// FirstBaseline and LastBaseline are rolling medians of the first and the latest Window values
+	FirstBaseline float64 `json:"first_baseline"`
+	LastBaseline  float64 `json:"last_baseline"`
+	// DriftPercentage is the change of LastBaseline against FirstBaseline, positive means worse
+	DriftPercentage float64 `json:"drift_percentage"`
+	// Drift is set when the baseline degraded by more than MaxDriftPercentage and more than MADThreshold scaled MADs of the first window
+	Drift bool `json:"drift"`
+}
+
+// Flagged returns true if the metric drifted or any report was a step change
+func (mt *MetricTrend) Flagged() bool {
+	if mt.Drift {
+		return true
+	}
+	for _, p := range mt.Points {
+		if p.Step {
+			return true
+		}
+	}
+	return false
+}
+
+// TrendAnalysis is a result of AnalyzeTrend, trends are keyed by generator and metric name
+type TrendAnalysis struct {
+	Refs   []string                           `json:"refs"`
+	Trends map[string]map[string]*MetricTrend `json:"trends"`
+}
+
+// Flagged returns all trends with a drift or a step change
+func (ta *TrendAnalysis) Flagged() []*MetricTrend {
+	flagged := make([]*MetricTrend, 0)
+	for _, generator := range sortedKeys(ta.Trends) {
+		for _, metric := range sortedKeys(ta.Trends[generator]) {
+			if mt := ta.Trends[generator][metric]; mt.Flagged() {
+				flagged = append(flagged, mt)
+			}
+		}
+	}
+	return flagged
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// higherIsBetter returns true for metrics where a decrease is a degradation
+func higherIsBetter(metric string) bool {
+	return metric == string(MaxSustainableRPS)
+}
+
+// medianAndMAD returns the median and the scaled median absolute deviation of values, see minMADRatio
+func medianAndMAD(values []float64) (float64, float64) {
+	median, _ := stats.Median(values)
+	deviations := make([]float64, 0, len(values))
+	for _, v := range values {
+		deviations = append(deviations, math.Abs(v-median))
+	}
+	mad, _ := stats.Median(deviations)
+	return median, math.Max(mad*madScale, math.Abs(median)*minMADRatio)
+}
+
+// robustScore returns the distance of the value from the baseline in scaled MADs, oriented so that positive is worse.
+// When all baseline values are equal any change is infinitely far
+func robustScore(metric string, value, baseline, mad float64) float64 {
+	diff := value - baseline
+	if higherIsBetter(metric) {
+		diff = -diff
+	}
+	if mad == 0 {
+		switch {
+		case diff > 0:
+			return math.Inf(1)
+		case diff < 0:
+			return math.Inf(-1)
+		default:
+			return 0
+		}
+	}
+	return diff / mad
+}
+
+func (c *TrendConfig) analyzeMetric(generator, metric string, refs []string, values []float64) *MetricTrend {
+	mt := &MetricTrend{Generator: generator, Metric: metric}
+	for i, v := range values {
+		p := TrendPoint{Ref: refs[i], Value: v}
+		if i >= c.Window {
+			p.Baseline, p.MAD = medianAndMAD(values[max(0, i-c.Window):i])
+			p.Score = robustScore(metric, v, p.Baseline, p.MAD)
+			p.Step = p.Score > c.MADThreshold
+		}
+		mt.Points = append(mt.Points, p)
+	}
+
+	// drift needs two windows to not compare a window with itself
+	if len(values) < 2*c.Window {
+		return mt
+	}
+	var firstMAD float64
+	mt.FirstBaseline, firstMAD = medianAndMAD(values[:c.Window])
+	mt.LastBaseline, _ = medianAndMAD(values[len(values)-c.Window:])
+	mt.DriftPercentage = calculateDiffPercentage(mt.LastBaseline, mt.FirstBaseline)
+	if higherIsBetter(metric) {
+		mt.DriftPercentage = -mt.DriftPercentage
+	}
+	mt.Drift = mt.DriftPercentage > c.MaxDriftPercentage &&
+		robustScore(metric, mt.LastBaseline, mt.FirstBaseline, firstMAD) > c.MADThreshold
+	return mt
+}
+
+// AnalyzeTrend computes rolling baselines of Direct metrics across reports ordered from the oldest to the newest,
+// see LoadStandardReportHistory. Every report is compared to the median and MAD of Window preceding reports to find step changes,
+// and the median of the latest Window reports is compared to the median of the first Window reports to find slow degradations
+// that no single step reveals. Reports missing a generator or a metric are skipped for it.
+func AnalyzeTrend(cfg *TrendConfig, reports ...*StandardReport) (*TrendAnalysis, error) {
+	if cfg == nil {
+		cfg = &TrendConfig{}
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if len(reports) < 2 {
+		return nil, fmt.Errorf("at least 2 reports are needed for trend analysis, got %d", len(reports))
+	}
+
+	L.Info().
+		Int("Reports", len(reports)).
+		Int("Window", cfg.Window).
+		Float64("MAD threshold", cfg.MADThreshold).
+		Float64("Max drift percentage", cfg.MaxDriftPercentage).
+		Msg("Analyzing trend of Direct metrics")
+
+	analysis := &TrendAnalysis{Trends: make(map[string]map[string]*MetricTrend)}
+	refs := make(map[string]map[string][]string)
+	values := make(map[string]map[string][]float64)
+	for _, report := range reports {
+		if report == nil {
+			return nil, errors.New("one of the reports is nil")
+		}
+		analysis.Refs = append(analysis.Refs, report.CommitOrTag)
+		allResults, err := AllDirectResults(report)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get direct results of report %s: %w", report.CommitOrTag, err)
+		}
+		for generator, results := range allResults {
+			if _, ok := values[generator]; !ok {
+				refs[generator] = make(map[string][]string)
+				values[generator] = make(map[string][]float64)
+			}
+			for _, metric := range cfg.Metrics {
+				if v, ok := results[metric]; ok {
+					refs[generator][metric] = append(refs[generator][metric], report.CommitOrTag)
+					values[generator][metric] = append(values[generator][metric], v)
+				}
+			}
+		}
+	}
+
+	for generator, metrics := range values {
+		analysis.Trends[generator] = make(map[string]*MetricTrend)
+		for metric, metricValues := range metrics {
+			analysis.Trends[generator][metric] = cfg.analyzeMetric(generator, metric, refs[generator][metric], metricValues)
+		}
+	}
+
+	L.Info().
+		Int("Reports", len(reports)).
+		Int("Flagged metrics", len(analysis.Flagged())).
+		Msg("Finished analyzing trend of Direct metrics")
+
+	return analysis, nil
+}
+
+// LoadStandardReportHistory loads up to n latest reports of the test from the storage, ex.: LocalStorage or S3Storage,
+// ordered from the oldest to the newest, so they can be passed to AnalyzeTrend
+func LoadStandardReportHistory(storage ReportStorage, testName string, n int) ([]*StandardReport, error) {
+	refs, err := storage.LatestRefs(testName, n)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find latest references")
+	}
+
+	reports := make([]*StandardReport, len(refs))
+	for i, ref := range refs {
+		report := &StandardReport{Storage: storage}
+		if err := storage.Load(testName, ref, report); err != nil {
+			return nil, errors.Wrapf(err, "failed to load report %s", ref)
+		}
+		// refs are newest first
+		reports[len(refs)-1-i] = report
+	}
+
+	return reports, nil
+}
+
+// PrintTrendAnalysis outputs values of every analyzed metric of every generator, flagged values are marked with "!"
+func PrintTrendAnalysis(analysis *TrendAnalysis) {
+	for _, generator := range sortedKeys(analysis.Trends) {
+		table := tablewriter.NewWriter(os.Stderr)
+		table.SetHeader(append(append([]string{"Metric"}, analysis.Refs...), "Drift %"))
+
+		for _, metric := range sortedKeys(analysis.Trends[generator]) {
+			mt := analysis.Trends[generator][metric]
+			valuesByRef := make(map[string]string)
+			for _, p := range mt.Points {
+				value := fmt.Sprintf("%.4f", p.Value)
+				if p.Step {
+					value += " !"
+				}
+				valuesByRef[p.Ref] = value
+			}
+			row := []string{metric}
+			for _, ref := range analysis.Refs {
+				row = append(row, valuesByRef[ref])
+			}
+			drift := fmt.Sprintf("%.4f", mt.DriftPercentage)
+			if mt.Drift {
+				drift += " !"
+			}
+			table.Append(append(row, drift))
+		}
+
+		table.SetBorder(true)
+		table.SetRowLine(true)
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+
+		title := "Generator: " + generator
+		fmt.Println(title)
+		fmt.Println(strings.Repeat("=", len(title)))
+
+		table.Render()
+	}
+}
@@ -0,0 +1,152 @@
+package benchspy
+
+import (
+	"fmt"
+	"math"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/wasp"
+)
+
+func newTrendTestReport(ref string, results map[string]interface{}) *StandardReport {
+	return &StandardReport{
+		BasicData: BasicData{TestName: "trend", CommitOrTag: ref},
+		QueryExecutors: []QueryExecutor{&DirectQueryExecutor{
+			KindName:     string(StandardQueryExecutor_Direct),
+			Generator:    &wasp.Generator{Cfg: &wasp.Config{GenName: "gen"}},
+			QueryResults: results,
+		}},
+	}
+}
+
+// newTrendTestReports creates reports with median latency values and a bounded deterministic noise
+func newTrendTestReports(medians ...float64) []*StandardReport {
+	reports := make([]*StandardReport, 0)
+	for i, m := range medians {
+		reports = append(reports, newTrendTestReport(fmt.Sprintf("v%d", i), map[string]interface{}{
+			string(MedianLatency): m + 2*math.Sin(float64(i)*1.7),
+			string(ErrorRate):     0.0,
+		}))
+	}
+	return reports
+}
+
+func TestBenchSpy_AnalyzeTrend(t *testing.T) {
+	t.Run("stable", func(t *testing.T) {
+		res, err := AnalyzeTrend(nil, newTrendTestReports(100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100, 100)...)
+		require.NoError(t, err)
+		require.Empty(t, res.Flagged())
+		require.Len(t, res.Refs, 12)
+		mt := res.Trends["gen"][string(MedianLatency)]
+		require.Len(t, mt.Points, 12)
+		require.InDelta(t, 100.0, mt.Points[11].Baseline, 2)
+	})
+
+	t.Run("step change", func(t *testing.T) {
+		res, err := AnalyzeTrend(&TrendConfig{Window: 5}, newTrendTestReports(100, 100, 100, 100, 100, 100, 130, 130)...)
+		require.NoError(t, err)
+		flagged := res.Flagged()
+		require.Len(t, flagged, 1)
+		require.Equal(t, string(MedianLatency), flagged[0].Metric)
+		require.True(t, flagged[0].Points[6].Step)
+		require.False(t, flagged[0].Drift, "not enough reports for drift")
+	})
+
+	t.Run("slow degradation", func(t *testing.T) {
+		medians := make([]float64, 0)
+		for i := 0; i < 60; i++ {
+			medians = append(medians, 100+float64(i)*0.3)
+		}
+		res, err := AnalyzeTrend(nil, newTrendTestReports(medians...)...)
+		require.NoError(t, err)
+		mt := res.Trends["gen"][string(MedianLatency)]
+		for _, p := range mt.Points {
+			require.False(t, p.Step, "no single step should be flagged, %s: %.2f", p.Ref, p.Score)
+		}
+		require.True(t, mt.Drift)
+		require.InDelta(t, 15, mt.DriftPercentage, 2)
+		require.Len(t, res.Flagged(), 1)
+	})
+
+	t.Run("higher is better", func(t *testing.T) {
+		reports := make([]*StandardReport, 0)
+		for i, rps := range []float64{100, 101, 99, 100, 50} {
+			reports = append(reports, newTrendTestReport(fmt.Sprintf("v%d", i), map[string]interface{}{string(MaxSustainableRPS): rps}))
+		}
+		res, err := AnalyzeTrend(&TrendConfig{Window: 4, Metrics: []string{string(MaxSustainableRPS)}}, reports...)
+		require.NoError(t, err)
+		mt := res.Trends["gen"][string(MaxSustainableRPS)]
+		require.True(t, mt.Points[4].Step)
+		require.Greater(t, mt.Points[4].Score, 0.0)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := AnalyzeTrend(nil, newTrendTestReports(100)...)
+		require.Error(t, err)
+		_, err = AnalyzeTrend(&TrendConfig{Window: -1}, newTrendTestReports(100, 100)...)
+		require.Error(t, err)
+
+		missing := newTrendTestReports(100, 100, 100)
+		missing[1].QueryExecutors = append(missing[1].QueryExecutors, nil)
+		_, err = AnalyzeTrend(nil, missing...)
+		require.ErrorContains(t, err, "report v1: query executor 1 is missing")
+
+		notNumber := newTrendTestReports(100, 100)
+		notNumber = append(notNumber, newTrendTestReport("v2", map[string]interface{}{string(MedianLatency): "100"}))
+		_, err = AnalyzeTrend(nil, notNumber...)
+		require.ErrorContains(t, err, "report v2: failed to get results of generator gen")
+	})
+}
+
+func TestBenchSpy_LoadStandardReportHistory(t *testing.T) {
+	gitDir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = gitDir
+		out, err := cmd.Output()
+		require.NoError(t, err)
+		return strings.TrimSpace(string(out))
+	}
+	git("init")
+	git("config", "--local", "user.email", "test@example.com")
+	git("config", "--local", "user.name", "Test User")
+	git("config", "--local", "commit.gpgsign", "false")
+
+	storage := &LocalStorage{Directory: gitDir}
+	var hashes []string
+	for i := 0; i < 4; i++ {
+		git("commit", "--allow-empty", "-m", fmt.Sprintf("commit %d", i))
+		hash := git("rev-parse", "HEAD")
+		hashes = append(hashes, hash)
+		_, err := storage.Store("trend", hash, newTrendTestReport(hash, map[string]interface{}{string(MedianLatency): float64(100 + i)}))
+		require.NoError(t, err)
+	}
+
+	refs, err := storage.LatestRefs("trend", 10)
+	require.NoError(t, err)
+	require.Equal(t, []string{hashes[3], hashes[2], hashes[1], hashes[0]}, refs)
+
+	reports, err := LoadStandardReportHistory(storage, "trend", 3)
+	require.NoError(t, err)
+	require.Len(t, reports, 3)
+	for i, r := range reports {
+		require.Equal(t, hashes[i+1], r.CommitOrTag)
+	}
+
+	res, err := AnalyzeTrend(nil, reports...)
+	require.NoError(t, err)
+	require.Equal(t, []float64{101, 102, 103}, func() []float64 {
+		values := make([]float64, 0)
+		for _, p := range res.Trends["gen"][string(MedianLatency)].Points {
+			values = append(values, p.Value)
+		}
+		return values
+	}())
+
+	_, err = storage.LatestRefs("trend", 0)
+	require.Error(t, err)
+}
@@ -14,6 +14,16 @@ type Storer interface {
 	LoadLatest(testName string) error
 }
 
+// ReportStorage persists reports of any type under a test name and a Git reference, see LocalStorage and S3Storage
+type ReportStorage interface {
+	// Store stores the report and returns its location, or an error
+	Store(testName, commitOrTag string, report interface{}) (string, error)
+	// Load decodes the report into the provided value, if commitOrTag is empty the latest report in Git history is loaded
+	Load(testName, commitOrTag string, report interface{}) error
+	// LatestRefs returns up to n latest references of stored reports of the test ordered by Git history, newest first
+	LatestRefs(testName string, n int) ([]string, error)
+}
+
 type DataFetcher interface {
 	// Fetch populates the report with the data from the test
 	FetchData(ctx context.Context) error
@@ -56,6 +66,8 @@ const (
 	StandardQueryExecutor_Loki       StandardQueryExecutorType = "loki"
 	StandardQueryExecutor_Direct     StandardQueryExecutorType = "direct"
 	StandardQueryExecutor_Prometheus StandardQueryExecutorType = "prometheus"
+	// StandardQueryExecutor_Docker samples Docker container stats during the test, see DockerQueryExecutor
+	StandardQueryExecutor_Docker StandardQueryExecutorType = "docker"
 )
 
 type StandardLoadMetric string
@@ -66,6 +78,8 @@ const (
 	Percentile99Latency StandardLoadMetric = "99th_percentile_latency"
 	MaxLatency          StandardLoadMetric = "max_latency"
 	ErrorRate           StandardLoadMetric = "error_rate"
+	// MaxSustainableRPS is only available for generators with adaptive load, see wasp.AdaptiveConfig
+	MaxSustainableRPS StandardLoadMetric = "max_sustainable_rps"
 )
 
 var StandardLoadMetrics = []StandardLoadMetric{MedianLatency, Percentile95Latency, Percentile99Latency, MaxLatency, ErrorRate}
@@ -82,3 +96,13 @@ const (
 )
 
 var StandardResourceMetrics = []StandardResourceMetric{MedianCPUUsage, MedianMemUsage, P95CPUUsage, P95MemUsage, MaxCPUUsage, MaxMemUsage}
+
+// network and block IO metrics are only available with DockerQueryExecutor, they are totals of bytes transferred during the test
+const (
+	NetworkRxBytes  StandardResourceMetric = "network_rx_bytes"
+	NetworkTxBytes  StandardResourceMetric = "network_tx_bytes"
+	BlockReadBytes  StandardResourceMetric = "block_read_bytes"
+	BlockWriteBytes StandardResourceMetric = "block_write_bytes"
+)
+
+var DockerResourceMetrics = []StandardResourceMetric{MedianCPUUsage, MedianMemUsage, P95CPUUsage, P95MemUsage, MaxCPUUsage, MaxMemUsage, NetworkRxBytes, NetworkTxBytes, BlockReadBytes, BlockWriteBytes}
@@ -0,0 +1,151 @@
+package benchspy
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/wasp"
+)
+
+// WithTimeWindows sets time windows for which Direct queries are executed separately, ex.: chaos experiments
+// recorded by havoc.ChaosPlan. Reports created with NewStandardReportFromProfile get windows of the profile automatically.
+func WithTimeWindows(windows ...wasp.TimeWindow) StandardReportOption {
+	return func(c *standardReportConfig) {
+		c.windows = windows
+	}
+}
+
+func validateTimeWindows(windows []wasp.TimeWindow) error {
+	names := make(map[string]bool)
+	for _, w := range windows {
+		if w.Name == "" {
+			return errors.New("time window name is required")
+		}
+		if names[w.Name] {
+			return fmt.Errorf("time window names must be unique, %s is used more than once", w.Name)
+		}
+		names[w.Name] = true
+		if !w.End.After(w.Start) {
+			return fmt.Errorf("time window %s must end after it starts", w.Name)
+		}
+	}
+	return nil
+}
+
+// DirectResultsByWindow holds Direct results keyed by generator name, window name and metric name
+type DirectResultsByWindow map[string]map[string]map[string]float64
+
+// MustAllDirectWindowResults returns per-window results of all Direct executors of the report
+func MustAllDirectWindowResults(sr *StandardReport) DirectResultsByWindow {
+	results := make(DirectResultsByWindow)
+	for _, queryExecutor := range sr.QueryExecutors {
+		if directExecutor, ok := queryExecutor.(*DirectQueryExecutor); ok && len(directExecutor.WindowResults) > 0 {
+			results[directExecutor.GeneratorName()] = directExecutor.WindowResults
+		}
+	}
+	return results
+}
+
+// CompareDirectWindowsWithThresholds compares standard Direct metrics of every window of the current report with
+// the window of the same name of the previous report, ex.: to check that the system doesn't degrade more under
+// the same chaos experiment. Windows of the current report missing from the previous one are reported as errors.
+func CompareDirectWindowsWithThresholds(medianThreshold, p95Threshold, p99Threshold, maxThreshold, errorRateThreshold float64, currentReport, previousReport *StandardReport) (bool, error) {
+	if currentReport == nil || previousReport == nil {
+		return true, errors.New("one or both reports are nil")
+	}
+
+	L.Info().
+		Str("Current report", currentReport.CommitOrTag).
+		Str("Previous report", previousReport.CommitOrTag).
+		Int("Windows", len(currentReport.Windows)).
+		Msg("Comparing Direct metrics of time windows with thresholds")
+
+	if thresholdsErr := validateThresholds(medianThreshold, p95Threshold, p99Threshold, maxThreshold, errorRateThreshold); thresholdsErr != nil {
+		return true, thresholdsErr
+	}
+
+	currentResults := MustAllDirectWindowResults(currentReport)
+	previousResults := MustAllDirectWindowResults(previousReport)
+	if len(currentResults) == 0 {
+		return true, errors.New("current report has no Direct window results")
+	}
+
+	thresholds := map[StandardLoadMetric]float64{
+		MedianLatency:       medianThreshold,
+		Percentile95Latency: p95Threshold,
+		Percentile99Latency: p99Threshold,
+		MaxLatency:          maxThreshold,
+		ErrorRate:           errorRateThreshold,
+	}
+
+	errs := make(map[string][]error)
+	for generatorName, windows := range currentResults {
+		for windowName, current := range windows {
+			key := generatorName + "/" + windowName
+			previous, ok := previousResults[generatorName][windowName]
+			if !ok {
+				errs[key] = append(errs[key], fmt.Errorf("window %s results were missing from previous report", windowName))
+				continue
+			}
+			for _, metric := range StandardLoadMetrics {
+				currentValue, currentOk := current[string(metric)]
+				previousValue, previousOk := previous[string(metric)]
+				if !currentOk || !previousOk {
+					errs[key] = append(errs[key], fmt.Errorf("%s metric results were missing from one of the reports", metric))
+					continue
+				}
+				diffPercentage := calculateDiffPercentage(currentValue, previousValue)
+				if diffPercentage > thresholds[metric] {
+					errs[key] = append(errs[key], fmt.Errorf("%s is %.4f%% different, which is higher than the threshold %.4f%%", metric, diffPercentage, thresholds[metric]))
+				}
+			}
+		}
+	}
+
+	PrintStandardDirectWindowMetrics(currentReport, previousReport)
+
+	L.Info().
+		Str("Current report", currentReport.CommitOrTag).
+		Str("Previous report", previousReport.CommitOrTag).
+		Int("Number of meaningful differences", len(errs)).
+		Msg("Finished comparing Direct metrics of time windows with thresholds")
+
+	return len(errs) > 0, concatenateGeneratorErrors(errs)
+}
+
+// PrintStandardDirectWindowMetrics outputs a comparison table of Direct metrics for every generator and window
+func PrintStandardDirectWindowMetrics(currentReport, previousReport *StandardReport) {
+	currentResults := MustAllDirectWindowResults(currentReport)
+	previousResults := MustAllDirectWindowResults(previousReport)
+
+	for generatorName, windows := range currentResults {
+		for _, window := range currentReport.Windows {
+			current, ok := windows[window.Name]
+			if !ok {
+				continue
+			}
+			previous := previousResults[generatorName][window.Name]
+
+			table := tablewriter.NewWriter(os.Stderr)
+			table.SetHeader([]string{"Metric", previousReport.CommitOrTag, currentReport.CommitOrTag, "Diff %"})
+			for _, metricName := range StandardLoadMetrics {
+				metricString := string(metricName)
+				diff := calculateDiffPercentage(current[metricString], previous[metricString])
+				table.Append([]string{metricString, fmt.Sprintf("%.4f", previous[metricString]), fmt.Sprintf("%.4f", current[metricString]), fmt.Sprintf("%.4f", diff)})
+			}
+			table.SetBorder(true)
+			table.SetRowLine(true)
+			table.SetAlignment(tablewriter.ALIGN_LEFT)
+
+			title := fmt.Sprintf("Generator: %s, window: %s", generatorName, window.Name)
+			fmt.Println(title)
+			fmt.Println(strings.Repeat("=", len(title)))
+
+			table.Render()
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package benchspy
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/wasp"
+)
+
+// windowsHook records two consecutive windows from the profile start
+type windowsHook struct {
+	start time.Time
+}
+
+func (h *windowsHook) Start(p *wasp.Profile) error {
+	h.start = p.StartTime()
+	return nil
+}
+
+func (h *windowsHook) Stop(_ *wasp.Profile) ([]wasp.TimeWindow, error) {
+	return []wasp.TimeWindow{
+		{Name: "first", Start: h.start, End: h.start.Add(time.Second)},
+		{Name: "second", Start: h.start.Add(time.Second), End: h.start.Add(2 * time.Second)},
+		{Name: "after", Start: h.start.Add(time.Hour), End: h.start.Add(2 * time.Hour)},
+	}, nil
+}
+
+func TestBenchSpy_TimeWindows(t *testing.T) {
+	gen, err := wasp.NewGenerator(&wasp.Config{
+		T:        t,
+		GenName:  "gen",
+		LoadType: wasp.RPS,
+		Schedule: wasp.Plain(10, 2*time.Second),
+		Gun:      wasp.NewMockGun(&wasp.MockGunConfig{CallSleep: 10 * time.Millisecond}),
+	})
+	require.NoError(t, err)
+	profile := wasp.NewProfile().WithHooks(&windowsHook{}).Add(gen, nil)
+	_, err = profile.Run(false)
+	require.NoError(t, err)
+
+	report, err := NewStandardReportFromProfile(context.Background(), profile, "v1", WithStandardQueries(StandardQueryExecutor_Direct))
+	require.NoError(t, err)
+	require.Len(t, report.Windows, 3)
+
+	results := MustAllDirectWindowResults(report)
+	require.Len(t, results["gen"], 2, "windows without calls are skipped")
+	for _, window := range []string{"first", "second"} {
+		require.Greater(t, results["gen"][window][string(MedianLatency)], 10.0)
+		require.Equal(t, 0.0, results["gen"][window][string(ErrorRate)])
+	}
+
+	t.Run("window results are stored", func(t *testing.T) {
+		data, err := json.Marshal(report)
+		require.NoError(t, err)
+		var loaded StandardReport
+		require.NoError(t, json.Unmarshal(data, &loaded))
+		require.Equal(t, results, MustAllDirectWindowResults(&loaded))
+		require.Len(t, loaded.Windows, 3)
+	})
+
+	t.Run("compare", func(t *testing.T) {
+		failed, err := CompareDirectWindowsWithThresholds(1, 1, 1, 1, 1, report, report)
+		require.NoError(t, err)
+		require.False(t, failed)
+
+		previous := *report
+		previous.QueryExecutors = []QueryExecutor{&DirectQueryExecutor{
+			KindName:      string(StandardQueryExecutor_Direct),
+			Generator:     gen,
+			WindowResults: map[string]map[string]float64{"first": results["gen"]["first"]},
+		}}
+		failed, err = CompareDirectWindowsWithThresholds(1, 1, 1, 1, 1, report, &previous)
+		require.True(t, failed)
+		require.ErrorContains(t, err, "[gen/second] window second results were missing from previous report")
+
+		_, err = CompareDirectWindowsWithThresholds(101, 1, 1, 1, 1, report, report)
+		require.ErrorContains(t, err, "median threshold 101.0000 is not in the range [0, 100]")
+	})
+
+	t.Run("invalid windows", func(t *testing.T) {
+		now := time.Now()
+		_, err := NewStandardReport("v1",
+			WithStandardQueries(StandardQueryExecutor_Direct),
+			WithGenerators(gen),
+			WithTimeWindows(wasp.TimeWindow{Name: "a", Start: now, End: now.Add(time.Second)}, wasp.TimeWindow{Name: "a", Start: now, End: now.Add(time.Second)}),
+		)
+		require.ErrorContains(t, err, "a is used more than once")
+		_, err = NewStandardReport("v1",
+			WithStandardQueries(StandardQueryExecutor_Direct),
+			WithGenerators(gen),
+			WithTimeWindows(wasp.TimeWindow{Name: "a", Start: now, End: now}),
+		)
+		require.ErrorContains(t, err, "must end after it starts")
+	})
+}
@@ -0,0 +1,72 @@
+package wasp
+
+import (
+	"time"
+)
+
+/* Coordinated omission correction for RPS schedules */
+
+// intendedSchedule tracks when each call should have started according to the schedule,
+// regardless of when the rate limiter actually released it. Used only from the gun loop goroutine.
+type intendedSchedule struct {
+	segment *Segment
+	start   time.Time
+	next    time.Time
+}
+
+// intendedStartTime returns the time the current call was scheduled to start.
+// If the generator or the system under test can't keep up with the schedule, the intended start
+// is earlier than the actual start and the difference is the queueing delay hidden from Duration.
+func (g *Generator) intendedStartTime(now time.Time) time.Time {
+	if al, ok := (*g.rl.Load()).(*arrivalLimiter); ok {
+		return al.intendedStart()
+	}
+	g.currentSegmentMu.Lock()
+	seg := g.currentSegment
+	g.currentSegmentMu.Unlock()
+	// repeated schedules reuse the same segment, so the start time is compared too
+	if g.intended.segment != seg || !g.intended.start.Equal(seg.StartTime) {
+		g.intended.segment = seg
+		g.intended.start = seg.StartTime
+		g.intended.next = seg.StartTime
+	}
+	intended := g.intended.next
+	g.intended.next = g.intended.next.Add(g.Cfg.RateLimitUnitDuration / time.Duration(seg.From))
+	if intended.After(now) {
+		return now
+	}
+	return intended
+}
+
+// correctResponse sets the intended start and the coordinated omission corrected duration for a Response
+func correctResponse(res *Response, intendedStartedAt time.Time) {
+	res.IntendedStartedAt = &intendedStartedAt
+	if res.FinishedAt != nil {
+		res.CorrectedDuration = res.FinishedAt.Sub(intendedStartedAt)
+	}
+}
+
+// recordCorrectedDuration updates the corrected latency stats for a Response
+func (s *Stats) recordCorrectedDuration(res *Response) {
+	if res.IntendedStartedAt == nil {
+		return
+	}
+	d := int64(res.CorrectedDuration)
+	s.CorrectedCalls.Add(1)
+	s.CorrectedDurationSum.Add(d)
+	for {
+		cur := s.MaxCorrectedDuration.Load()
+		if d <= cur || s.MaxCorrectedDuration.CompareAndSwap(cur, d) {
+			return
+		}
+	}
+}
+
+// AvgCorrectedDuration returns the average coordinated omission corrected duration of all recorded calls
+func (s *Stats) AvgCorrectedDuration() time.Duration {
+	calls := s.CorrectedCalls.Load()
+	if calls == 0 {
+		return 0
+	}
+	return time.Duration(s.CorrectedDurationSum.Load() / calls)
+}
@@ -0,0 +1,495 @@
+package wasp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+/* Distributed mode without Kubernetes, one coordinator distributes a workload between worker processes over HTTP */
+
+const (
+	DefaultCoordinatorListenAddr     = ":7711"
+	DefaultCoordinatorStartDelay     = 3 * time.Second
+	DefaultWorkerRegisterTimeout     = 1 * time.Minute
+	DefaultWorkerRegisterRetryPeriod = 1 * time.Second
+	// DefaultCoordinatorTimeoutMargin is added to the profile duration to wait for workers to register and report
+	DefaultCoordinatorTimeoutMargin = 5 * time.Minute
+
+	coordinatorRegisterPath = "/register"
+	coordinatorJobPath      = "/job"
+	coordinatorResultPath   = "/result"
+)
+
+var (
+	ErrNoWorkers        = errors.New("coordinator requires workers > 0")
+	ErrClusterFull      = errors.New("all workers are already registered")
+	ErrUnknownWorker    = errors.New("unknown worker")
+	ErrNoCoordinator    = errors.New("coordinator URL is empty")
+	ErrNoProfileFactory = errors.New("profile factory is nil")
+)
+
+// CoordinatorConfig configures a Coordinator
+type CoordinatorConfig struct {
+	// ListenAddr is an address of the coordinator HTTP server, defaults to ":7711"
+	ListenAddr string
+	// Workers is the amount of workers to wait for before the start
+	Workers int
+	// Payload is encoded as JSON and sent to every worker, ex.: a *ProfileFile for ProfileFileFactory
+	Payload any
+	// StartDelay is a delay between the last worker registration and the synchronized start, it should cover
+	// the time needed to send the job to all workers, defaults to 3s
+	StartDelay time.Duration
+}
+
+// Validate checks required fields and sets defaults
+func (c *CoordinatorConfig) Validate() error {
+	if c.Workers <= 0 {
+		return ErrNoWorkers
+	}
+	if c.ListenAddr == "" {
+		c.ListenAddr = DefaultCoordinatorListenAddr
+	}
+	if c.StartDelay == 0 {
+		c.StartDelay = DefaultCoordinatorStartDelay
+	}
+	return nil
+}
+
+// WorkerJob is sent by a Coordinator to every registered worker
+type WorkerJob struct {
+	WorkerID string `json:"worker_id"`
+	// Index is a 0-based worker index
+	Index   int `json:"index"`
+	Workers int `json:"workers"`
+	// StartAt is the synchronized start time of all workers
+	StartAt time.Time       `json:"start_at"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// GeneratorResult is a serializable summary of a Generator run
+type GeneratorResult struct {
+	Name          string            `json:"name"`
+	RunFailed     bool              `json:"run_failed"`
+	Success       int64             `json:"success"`
+	Failed        int64             `json:"failed"`
+	CallTimeout   int64             `json:"call_timeout"`
+	Latency       *LatencyHistogram `json:"latency"`
+	OKResponses   []*Response       `json:"ok_responses"`
+	FailResponses []*Response       `json:"fail_responses"`
+	Errors        []string          `json:"errors"`
+}
+
+// NewGeneratorResult collects stats, latency and responses of a finished Generator
+func NewGeneratorResult(g *Generator) *GeneratorResult {
+	d := g.GetData()
+	hist := NewLatencyHistogram()
+	hist.Merge(g.Stats().Latency.Histogram())
+	return &GeneratorResult{
+		Name:          g.Cfg.GenName,
+		RunFailed:     g.Stats().RunFailed.Load(),
+		Success:       g.Stats().Success.Load(),
+		Failed:        g.Stats().Failed.Load(),
+		CallTimeout:   g.Stats().CallTimeout.Load(),
+		Latency:       hist,
+		OKResponses:   d.OKResponses.Data,
+		FailResponses: d.FailResponses.Data,
+		Errors:        g.Errors(),
+	}
+}
+
+// merge adds results of the same generator running on another worker
+func (r *GeneratorResult) merge(other *GeneratorResult) {
+	r.RunFailed = r.RunFailed || other.RunFailed
+	r.Success += other.Success
+	r.Failed += other.Failed
+	r.CallTimeout += other.CallTimeout
+	if other.Latency != nil {
+		r.Latency.Merge(other.Latency)
+	}
+	r.OKResponses = append(r.OKResponses, other.OKResponses...)
+	r.FailResponses = append(r.FailResponses, other.FailResponses...)
+	r.Errors = append(r.Errors, other.Errors...)
+}
+
+// WorkerResult is sent by a worker to the Coordinator after the run
+type WorkerResult struct {
+	WorkerID   string             `json:"worker_id"`
+	Name       string             `json:"name"`
+	Error      string             `json:"error,omitempty"`
+	Generators []*GeneratorResult `json:"generators"`
+}
+
+// ClusterResult is an aggregated result of all workers
+type ClusterResult struct {
+	Workers []*WorkerResult `json:"workers"`
+	// Generators are results merged by generator name
+	Generators map[string]*GeneratorResult `json:"generators"`
+}
+
+// Failed returns true if any worker failed to run or any generator run has failed
+func (c *ClusterResult) Failed() bool {
+	for _, w := range c.Workers {
+		if w.Error != "" {
+			return true
+		}
+	}
+	for _, g := range c.Generators {
+		if g.RunFailed {
+			return true
+		}
+	}
+	return false
+}
+
+func aggregateWorkerResults(workers []*WorkerResult) *ClusterResult {
+	res := &ClusterResult{Workers: workers, Generators: make(map[string]*GeneratorResult)}
+	for _, w := range workers {
+		for _, g := range w.Generators {
+			agg, ok := res.Generators[g.Name]
+			if !ok {
+				agg = &GeneratorResult{Name: g.Name, Latency: NewLatencyHistogram()}
+				res.Generators[g.Name] = agg
+			}
+			agg.merge(g)
+		}
+	}
+	return res
+}
+
+type coordinatorWorker struct {
+	id    string
+	name  string
+	index int
+}
+
+// Coordinator waits for workers, sends them a job with a synchronized start time and collects their results
+type Coordinator struct {
+	cfg      *CoordinatorConfig
+	ln       net.Listener
+	srv      *http.Server
+	payload  json.RawMessage
+	mu       *sync.Mutex
+	workers  map[string]*coordinatorWorker
+	startAt  time.Time
+	ready    chan struct{}
+	results  map[string]*WorkerResult
+	finished chan struct{}
+}
+
+// NewCoordinator creates a Coordinator and starts listening, workers can register right away
+func NewCoordinator(cfg *CoordinatorConfig) (*Coordinator, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(cfg.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode coordinator payload: %w", err)
+	}
+	ln, err := net.Listen("tcp", cfg.ListenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("coordinator failed to listen on %s: %w", cfg.ListenAddr, err)
+	}
+	c := &Coordinator{
+		cfg:      cfg,
+		ln:       ln,
+		payload:  payload,
+		mu:       &sync.Mutex{},
+		workers:  make(map[string]*coordinatorWorker),
+		ready:    make(chan struct{}),
+		results:  make(map[string]*WorkerResult),
+		finished: make(chan struct{}),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(coordinatorRegisterPath, c.handleRegister)
+	mux.HandleFunc(coordinatorJobPath, c.handleJob)
+	mux.HandleFunc(coordinatorResultPath, c.handleResult)
+	c.srv = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := c.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Err(err).Msg("Coordinator server failed")
+		}
+	}()
+	log.Info().Str("Addr", ln.Addr().String()).Int("Workers", cfg.Workers).Msg("Coordinator is waiting for workers")
+	return c, nil
+}
+
+// URL returns the coordinator URL workers should connect to
+func (c *Coordinator) URL() string {
+	return "http://" + c.ln.Addr().String()
+}
+
+// Run waits for all workers to finish and returns aggregated results, if ctx is done first
+// it returns results of workers that have finished so far along with the context error and names of workers
+// that didn't report, use a context with a deadline so a crashed worker doesn't block the coordinator forever
+func (c *Coordinator) Run(ctx context.Context) (*ClusterResult, error) {
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = c.srv.Shutdown(shutdownCtx)
+	}()
+	var ctxErr error
+	select {
+	case <-c.finished:
+	case <-ctx.Done():
+		ctxErr = ctx.Err()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	workers := make([]*WorkerResult, 0, len(c.results))
+	for _, r := range c.results {
+		workers = append(workers, r)
+	}
+	sort.Slice(workers, func(i, j int) bool {
+		return c.workers[workers[i].WorkerID].index < c.workers[workers[j].WorkerID].index
+	})
+	var err error
+	if missing := c.missingWorkers(); ctxErr != nil && len(missing) > 0 {
+		err = fmt.Errorf("%w, workers didn't report: %s", ctxErr, strings.Join(missing, ", "))
+	}
+	return aggregateWorkerResults(workers), err
+}
+
+// missingWorkers returns names of registered workers without results, ordered by index, and the amount
+// of workers which didn't register, c.mu must be held
+func (c *Coordinator) missingWorkers() []string {
+	registered := make([]*coordinatorWorker, 0, len(c.workers))
+	for _, cw := range c.workers {
+		if _, ok := c.results[cw.id]; !ok {
+			registered = append(registered, cw)
+		}
+	}
+	sort.Slice(registered, func(i, j int) bool { return registered[i].index < registered[j].index })
+	missing := make([]string, 0, len(registered)+1)
+	for _, cw := range registered {
+		name := cw.name
+		if name == "" {
+			name = fmt.Sprintf("#%d", cw.index)
+		}
+		missing = append(missing, name)
+	}
+	if unregistered := c.cfg.Workers - len(c.workers); unregistered > 0 {
+		missing = append(missing, fmt.Sprintf("%d unregistered", unregistered))
+	}
+	return missing
+}
+
+func (c *Coordinator) handleRegister(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Name string `json:"name"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.workers) >= c.cfg.Workers {
+		http.Error(w, ErrClusterFull.Error(), http.StatusConflict)
+		return
+	}
+	cw := &coordinatorWorker{id: uuid.NewString(), name: req.Name, index: len(c.workers)}
+	c.workers[cw.id] = cw
+	log.Info().Str("Name", cw.name).Int("Index", cw.index).Msg("Worker registered")
+	if len(c.workers) == c.cfg.Workers {
+		c.startAt = time.Now().Add(c.cfg.StartDelay)
+		log.Info().Time("StartAt", c.startAt).Msg("All workers registered")
+		close(c.ready)
+	}
+	writeJSON(w, map[string]string{"worker_id": cw.id})
+}
+
+// handleJob blocks until all workers are registered
+func (c *Coordinator) handleJob(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	cw, ok := c.workers[r.URL.Query().Get("worker_id")]
+	c.mu.Unlock()
+	if !ok {
+		http.Error(w, ErrUnknownWorker.Error(), http.StatusNotFound)
+		return
+	}
+	select {
+	case <-c.ready:
+	case <-r.Context().Done():
+		return
+	}
+	writeJSON(w, &WorkerJob{
+		WorkerID: cw.id,
+		Index:    cw.index,
+		Workers:  c.cfg.Workers,
+		StartAt:  c.startAt,
+		Payload:  c.payload,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (c *Coordinator) handleResult(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var res WorkerResult
+	if err := json.NewDecoder(r.Body).Decode(&res); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cw, ok := c.workers[res.WorkerID]
+	if !ok {
+		http.Error(w, ErrUnknownWorker.Error(), http.StatusNotFound)
+		return
+	}
+	if _, done := c.results[res.WorkerID]; done {
+		return
+	}
+	res.Name = cw.name
+	c.results[res.WorkerID] = &res
+	log.Info().Str("Name", cw.name).Int("Index", cw.index).Str("Error", res.Error).Msg("Worker finished")
+	if len(c.results) == c.cfg.Workers {
+		close(c.finished)
+	}
+}
+
+// WorkerConfig configures a Worker
+type WorkerConfig struct {
+	// CoordinatorURL is a Coordinator URL, ex.: "http://10.0.0.1:7711"
+	CoordinatorURL string
+	// Name is an informative worker name, ex.: a hostname
+	Name string
+	// RegisterTimeout is how long to retry registration while the coordinator is not up, defaults to 1m
+	RegisterTimeout time.Duration
+}
+
+// Validate checks required fields and sets defaults
+func (c *WorkerConfig) Validate() error {
+	if c.CoordinatorURL == "" {
+		return ErrNoCoordinator
+	}
+	if c.RegisterTimeout == 0 {
+		c.RegisterTimeout = DefaultWorkerRegisterTimeout
+	}
+	return nil
+}
+
+// ProfileFactory creates a worker part of the workload from a job
+type ProfileFactory func(job *WorkerJob) (*Profile, error)
+
+// ProfileFileFactory decodes a *ProfileFile coordinator payload and divides its load between workers, see ProfileFile.ForWorker
+func ProfileFileFactory(t *testing.T) ProfileFactory {
+	return func(job *WorkerJob) (*Profile, error) {
+		var pf ProfileFile
+		if err := json.Unmarshal(job.Payload, &pf); err != nil {
+			return nil, fmt.Errorf("failed to decode profile file payload: %w", err)
+		}
+		return pf.ForWorker(job.Index, job.Workers).NewProfile(t)
+	}
+}
+
+// Worker runs a part of the workload received from a Coordinator
+type Worker struct {
+	cfg    *WorkerConfig
+	client *resty.Client
+}
+
+// NewWorker creates a new Worker
+func NewWorker(cfg *WorkerConfig) (*Worker, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &Worker{cfg: cfg, client: resty.New().SetBaseURL(cfg.CoordinatorURL)}, nil
+}
+
+// Run registers the worker, waits for the job and the synchronized start, creates and runs the profile and sends results back
+func (w *Worker) Run(ctx context.Context, factory ProfileFactory) (*WorkerResult, error) {
+	if factory == nil {
+		return nil, ErrNoProfileFactory
+	}
+	id, err := w.register(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var job WorkerJob
+	r, err := w.client.R().SetContext(ctx).SetQueryParam("worker_id", id).SetResult(&job).Get(coordinatorJobPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get a job from coordinator: %w", err)
+	}
+	if r.IsError() {
+		return nil, fmt.Errorf("failed to get a job from coordinator: %s", r.String())
+	}
+	log.Info().Int("Index", job.Index).Int("Workers", job.Workers).Time("StartAt", job.StartAt).Msg("Worker is waiting for the start")
+	select {
+	case <-time.After(time.Until(job.StartAt)):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	res := &WorkerResult{WorkerID: id, Name: w.cfg.Name}
+	// generators start their schedule timers when created, so the profile is created at the start time
+	p, err := factory(&job)
+	if err != nil {
+		res.Error = err.Error()
+		return res, errors.Join(err, w.sendResult(ctx, res))
+	}
+	if _, err := p.Run(true); err != nil {
+		res.Error = err.Error()
+	}
+	for _, g := range p.Generators {
+		res.Generators = append(res.Generators, NewGeneratorResult(g))
+	}
+	return res, w.sendResult(ctx, res)
+}
+
+// register retries until the coordinator is up or RegisterTimeout
+func (w *Worker) register(ctx context.Context) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, w.cfg.RegisterTimeout)
+	defer cancel()
+	var reg struct {
+		WorkerID string `json:"worker_id"`
+	}
+	for {
+		r, err := w.client.R().
+			SetContext(ctx).
+			SetBody(map[string]string{"name": w.cfg.Name}).
+			SetResult(&reg).
+			Post(coordinatorRegisterPath)
+		switch {
+		case err == nil && r.StatusCode() == http.StatusConflict:
+			return "", ErrClusterFull
+		case err == nil && r.IsSuccess():
+			return reg.WorkerID, nil
+		}
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("failed to register in coordinator %s: %w", w.cfg.CoordinatorURL, ctx.Err())
+		case <-time.After(DefaultWorkerRegisterRetryPeriod):
+		}
+	}
+}
+
+func (w *Worker) sendResult(ctx context.Context, res *WorkerResult) error {
+	r, err := w.client.R().SetContext(ctx).SetBody(res).Post(coordinatorResultPath)
+	if err != nil {
+		return fmt.Errorf("failed to send results to coordinator: %w", err)
+	}
+	if r.IsError() {
+		return fmt.Errorf("failed to send results to coordinator: %s", r.String())
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package wasp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const distributedTestCoordinatorEnv = "WASP_TEST_COORDINATOR_URL"
+
+func TestProfileFileForWorker(t *testing.T) {
+	t.Parallel()
+	pf := &ProfileFile{Generators: []*GeneratorFile{{
+		Name: "gen",
+		Schedule: []*SegmentFile{
+			{Type: FileSegmentSteps, From: 10, Increase: -5, Steps: 2},
+			{Type: FileSegmentRepeat, Times: 2, Segments: []*SegmentFile{{Type: FileSegmentRamp, From: 1, To: 7}}},
+		},
+	}}}
+	var from, increase, rampFrom, rampTo int64
+	for i := 0; i < 3; i++ {
+		s := pf.ForWorker(i, 3).Generators[0].Schedule
+		from += s[0].From
+		increase += s[0].Increase
+		rampFrom += s[1].Segments[0].From
+		rampTo += s[1].Segments[0].To
+	}
+	require.Equal(t, []int64{10, -5, 1, 7}, []int64{from, increase, rampFrom, rampTo})
+	require.Equal(t, int64(10), pf.Generators[0].Schedule[0].From, "original must not be changed")
+	require.Equal(t, int64(4), pf.ForWorker(0, 3).Generators[0].Schedule[0].From)
+	require.Equal(t, int64(0), pf.ForWorker(2, 3).Generators[0].Schedule[1].Segments[0].From)
+}
+
+func TestLatencyHistogramJSON(t *testing.T) {
+	t.Parallel()
+	h := NewLatencyHistogram()
+	for i := 1; i <= 1000; i++ {
+		h.Record(time.Duration(i) * time.Millisecond)
+	}
+	d, err := json.Marshal(h)
+	require.NoError(t, err)
+	decoded := NewLatencyHistogram()
+	require.NoError(t, json.Unmarshal(d, decoded))
+	require.Equal(t, h.Snapshot(), decoded.Snapshot())
+}
+
+func TestDistributedCustomFactory(t *testing.T) {
+	t.Parallel()
+	c, err := NewCoordinator(&CoordinatorConfig{
+		ListenAddr: "127.0.0.1:0",
+		Workers:    2,
+		Payload:    map[string]int64{"rps": 10},
+		StartDelay: 500 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	var starts sync.Map
+	factory := func(job *WorkerJob) (*Profile, error) {
+		var payload map[string]int64
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return nil, err
+		}
+		starts.Store(job.Index, job.StartAt)
+		return NewProfile().Add(NewGenerator(&Config{
+			T:        t,
+			GenName:  "mock",
+			LoadType: RPS,
+			Schedule: Plain(payload["rps"]/int64(job.Workers), 2*time.Second),
+			Gun:      NewMockGun(&MockGunConfig{CallSleep: 10 * time.Millisecond}),
+		})), nil
+	}
+	wg := &sync.WaitGroup{}
+	// require can't be used in worker goroutines, errors are checked after they exit
+	workerErrs := make(chan error, 2)
+	for _, name := range []string{"a", "b"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w, err := NewWorker(&WorkerConfig{CoordinatorURL: c.URL(), Name: name})
+			if err != nil {
+				workerErrs <- err
+				return
+			}
+			_, err = w.Run(context.Background(), factory)
+			workerErrs <- err
+		}()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	res, err := c.Run(ctx)
+	require.NoError(t, err)
+	wg.Wait()
+	close(workerErrs)
+	for err := range workerErrs {
+		require.NoError(t, err)
+	}
+
+	require.False(t, res.Failed())
+	require.Len(t, res.Workers, 2)
+	s0, _ := starts.Load(0)
+	s1, _ := starts.Load(1)
+	require.Equal(t, s0, s1)
+	g := res.Generators["mock"]
+	require.NotNil(t, g)
+	require.Equal(t, res.Workers[0].Generators[0].Success+res.Workers[1].Generators[0].Success, g.Success)
+	require.Greater(t, g.Success, int64(15))
+	require.Len(t, g.OKResponses, int(g.Success))
+	require.Equal(t, g.Success, g.Latency.Count())
+	require.GreaterOrEqual(t, g.Latency.Percentile(50), 10*time.Millisecond)
+
+	// the coordinator is stopped after the run
+	late, err := NewWorker(&WorkerConfig{CoordinatorURL: c.URL(), RegisterTimeout: time.Second})
+	require.NoError(t, err)
+	_, err = late.register(context.Background())
+	require.Error(t, err)
+}
+
+func TestDistributedClusterFull(t *testing.T) {
+	t.Parallel()
+	c, err := NewCoordinator(&CoordinatorConfig{ListenAddr: "127.0.0.1:0", Workers: 1})
+	require.NoError(t, err)
+	w, err := NewWorker(&WorkerConfig{CoordinatorURL: c.URL()})
+	require.NoError(t, err)
+	_, err = w.register(context.Background())
+	require.NoError(t, err)
+	_, err = w.register(context.Background())
+	require.ErrorIs(t, err, ErrClusterFull)
+	_, err = NewCoordinator(&CoordinatorConfig{})
+	require.ErrorIs(t, err, ErrNoWorkers)
+	_, err = NewWorker(&WorkerConfig{})
+	require.ErrorIs(t, err, ErrNoCoordinator)
+}
+
+func TestDistributedWorkersDidNotReport(t *testing.T) {
+	t.Parallel()
+	c, err := NewCoordinator(&CoordinatorConfig{ListenAddr: "127.0.0.1:0", Workers: 3})
+	require.NoError(t, err)
+	// registered workers which crash before they report
+	for _, name := range []string{"a", "b"} {
+		w, err := NewWorker(&WorkerConfig{CoordinatorURL: c.URL(), Name: name})
+		require.NoError(t, err)
+		_, err = w.register(context.Background())
+		require.NoError(t, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	res, err := c.Run(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.ErrorContains(t, err, "workers didn't report: a, b, 1 unregistered")
+	require.Empty(t, res.Workers)
+}
+
+// TestDistributedWorkerProcess is a worker process started by TestSmokeDistributedProcesses
+func TestDistributedWorkerProcess(t *testing.T) {
+	url := os.Getenv(distributedTestCoordinatorEnv)
+	if url == "" {
+		t.Skip("only runs as a worker process of TestSmokeDistributedProcesses")
+	}
+	w, err := NewWorker(&WorkerConfig{CoordinatorURL: url, Name: "process"})
+	require.NoError(t, err)
+	_, err = w.Run(context.Background(), ProfileFileFactory(t))
+	require.NoError(t, err)
+}
+
+func TestSmokeDistributedProcesses(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+	}))
+	t.Cleanup(srv.Close)
+
+	pf := &ProfileFile{
+		Name: "distributed",
+		Generators: []*GeneratorFile{{
+			Name:     "http",
+			LoadType: FileLoadTypeRPS,
+			Schedule: []*SegmentFile{{Type: FileSegmentPlain, From: 20, Duration: FileDuration(2 * time.Second)}},
+			Gun:      &GunFile{Type: FileGunHTTP, HTTP: &HTTPGunConfig{URL: srv.URL}},
+		}},
+	}
+	c, err := NewCoordinator(&CoordinatorConfig{ListenAddr: "127.0.0.1:0", Workers: 3, Payload: pf})
+	require.NoError(t, err)
+
+	procs := make([]*exec.Cmd, 0)
+	for i := 0; i < 3; i++ {
+		//nolint
+		cmd := exec.Command(os.Args[0], "-test.run=^TestDistributedWorkerProcess$", "-test.count=1")
+		cmd.Env = append(os.Environ(), distributedTestCoordinatorEnv+"="+c.URL())
+		require.NoError(t, cmd.Start())
+		procs = append(procs, cmd)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+	res, err := c.Run(ctx)
+	require.NoError(t, err)
+	for _, p := range procs {
+		require.NoError(t, p.Wait())
+	}
+
+	require.False(t, res.Failed())
+	require.Len(t, res.Workers, 3)
+	g := res.Generators["http"]
+	require.NotNil(t, g)
+	for _, w := range res.Workers {
+		require.Greater(t, w.Generators[0].Success, int64(5))
+	}
+	require.Equal(t, calls.Load(), g.Success)
+	require.InDelta(t, 40, g.Success, 8)
+	require.Equal(t, g.Success, g.Latency.Count())
+}
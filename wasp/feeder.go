@@ -0,0 +1,69 @@
+package wasp
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+)
+
+// DataFeeder provides per-call variables for built-in guns, implementations must be safe for concurrent use
+type DataFeeder interface {
+	Next() (map[string]any, error)
+}
+
+// SliceFeeder is a DataFeeder cycling over a set of records, sequentially or randomly
+type SliceFeeder struct {
+	mu      *sync.Mutex
+	records []map[string]any
+	idx     int
+	random  bool
+}
+
+// NewSliceFeeder creates a DataFeeder from records, if random is true records are picked randomly, otherwise in order and wrapping around
+func NewSliceFeeder(records []map[string]any, random bool) (*SliceFeeder, error) {
+	if len(records) == 0 {
+		return nil, fmt.Errorf("feeder has no records")
+	}
+	return &SliceFeeder{mu: &sync.Mutex{}, records: records, random: random}, nil
+}
+
+// NewCSVFeeder creates a DataFeeder from a CSV file, the first row is a header with variable names
+func NewCSVFeeder(path string, random bool) (*SliceFeeder, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open feeder file: %w", err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feeder file %s: %w", path, err)
+	}
+	if len(rows) < 2 {
+		return nil, fmt.Errorf("feeder file %s must have a header and at least one row", path)
+	}
+	header := rows[0]
+	records := make([]map[string]any, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		rec := make(map[string]any, len(header))
+		for i, name := range header {
+			rec[name] = row[i]
+		}
+		records = append(records, rec)
+	}
+	return NewSliceFeeder(records, random)
+}
+
+// Next returns the next record
+func (m *SliceFeeder) Next() (map[string]any, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.random {
+		//nolint
+		return m.records[rand.Intn(len(m.records))], nil
+	}
+	rec := m.records[m.idx]
+	m.idx = (m.idx + 1) % len(m.records)
+	return rec, nil
+}
@@ -0,0 +1,111 @@
+package wasp
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DefaultGRPCMethod is the standard health check method, it can be called without any generated code
+	DefaultGRPCMethod = "/grpc.health.v1.Health/Check"
+)
+
+// GRPCGunConfig configures a built-in gRPC gun calling a unary method with a pre-encoded protobuf payload
+type GRPCGunConfig struct {
+	// Target is a gRPC server address, ex.: "localhost:50051"
+	Target string `toml:"target" yaml:"target"`
+	// Method is a full method name, ex.: "/package.Service/Method", defaults to DefaultGRPCMethod
+	Method string `toml:"method" yaml:"method"`
+	// PayloadHex is a hex encoded protobuf request message, empty message if not set
+	PayloadHex string `toml:"payload_hex" yaml:"payload_hex"`
+	// Metadata is gRPC request metadata
+	Metadata map[string]string `toml:"metadata" yaml:"metadata"`
+	// Group is a Response.Group for all calls of this gun
+	Group string `toml:"group" yaml:"group"`
+}
+
+// Validate checks required fields and sets defaults
+func (c *GRPCGunConfig) Validate() error {
+	if c.Target == "" {
+		return fmt.Errorf("grpc gun target is empty")
+	}
+	if c.Method == "" {
+		c.Method = DefaultGRPCMethod
+	}
+	if _, err := hex.DecodeString(c.PayloadHex); err != nil {
+		return fmt.Errorf("grpc gun payload_hex is invalid: %w", err)
+	}
+	return nil
+}
+
+// rawCodec passes already encoded protobuf messages through as is
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return nil, fmt.Errorf("raw codec: unexpected type %T", v)
+	}
+	return *b, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("raw codec: unexpected type %T", v)
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+func (rawCodec) Name() string { return "proto" }
+
+// GRPCGun is a built-in Gun calling a unary gRPC method without generated code
+type GRPCGun struct {
+	conn    *grpc.ClientConn
+	cfg     *GRPCGunConfig
+	payload []byte
+}
+
+// NewGRPCGun creates a new GRPCGun with an insecure connection to the target
+func NewGRPCGun(cfg *GRPCGunConfig) (*GRPCGun, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	payload, _ := hex.DecodeString(cfg.PayloadHex)
+	conn, err := grpc.NewClient(cfg.Target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create grpc client: %w", err)
+	}
+	return &GRPCGun{conn: conn, cfg: cfg, payload: payload}, nil
+}
+
+// Call invokes the configured method and returns the raw response message as Data
+func (m *GRPCGun) Call(_ *Generator) *Response {
+	ctx := context.Background()
+	if len(m.cfg.Metadata) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(m.cfg.Metadata))
+	}
+	req := m.payload
+	var resp []byte
+	err := m.conn.Invoke(ctx, m.cfg.Method, &req, &resp, grpc.ForceCodec(rawCodec{}))
+	res := &Response{Group: m.cfg.Group, Path: m.cfg.Method, StatusCode: status.Code(err).String()}
+	if err != nil {
+		res.Failed = true
+		res.Error = err.Error()
+		return res
+	}
+	res.Data = hex.EncodeToString(resp)
+	return res
+}
+
+// Close closes the gRPC connection
+func (m *GRPCGun) Close() error {
+	return m.conn.Close()
+}
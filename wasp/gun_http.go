@@ -0,0 +1,316 @@
+package wasp
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/google/uuid"
+)
+
+// HTTPGunConfig configures a built-in HTTP gun.
+// URL, header values and Body are Go text/template strings, see HTTPTemplateData for available fields and
+// httpTemplateFuncs for helper functions.
+type HTTPGunConfig struct {
+	// Method is an HTTP method, defaults to GET
+	Method string `toml:"method" yaml:"method"`
+	// URL is a target URL template
+	URL string `toml:"url" yaml:"url"`
+	// Headers are request header templates
+	Headers map[string]string `toml:"headers" yaml:"headers"`
+	// Body is a request body template
+	Body string `toml:"body" yaml:"body"`
+	// Group is a Response.Group for all calls of this gun
+	Group string `toml:"group" yaml:"group"`
+	// Feeder provides per-call variables available as .Vars in templates
+	Feeder DataFeeder `toml:"-" yaml:"-"`
+	// FeederCSV is a path to a CSV file used as a Feeder if Feeder is not set
+	FeederCSV string `toml:"feeder_csv" yaml:"feeder_csv"`
+	// FeederRandom picks feeder records randomly instead of sequentially
+	FeederRandom bool `toml:"feeder_random" yaml:"feeder_random"`
+	// Pool controls HTTP connection pooling
+	Pool *HTTPPoolConfig `toml:"pool" yaml:"pool"`
+	// Assertions are checked for every response, the first failed assertion marks the Response as failed
+	Assertions []*HTTPAssertion `toml:"assertions" yaml:"assertions"`
+	// StoreBody keeps response bodies in Response.Data, they are dropped by default since every response is kept for the whole run
+	StoreBody bool `toml:"store_body" yaml:"store_body"`
+}
+
+// HTTPPoolConfig controls HTTP client connection pooling, zero values keep net/http defaults
+type HTTPPoolConfig struct {
+	MaxIdleConns        int          `toml:"max_idle_conns" yaml:"max_idle_conns"`
+	MaxIdleConnsPerHost int          `toml:"max_idle_conns_per_host" yaml:"max_idle_conns_per_host"`
+	MaxConnsPerHost     int          `toml:"max_conns_per_host" yaml:"max_conns_per_host"`
+	IdleConnTimeout     FileDuration `toml:"idle_conn_timeout" yaml:"idle_conn_timeout"`
+	DisableKeepAlives   bool         `toml:"disable_keep_alives" yaml:"disable_keep_alives"`
+	InsecureSkipVerify  bool         `toml:"insecure_skip_verify" yaml:"insecure_skip_verify"`
+}
+
+// HTTPAssertion is a response check, only non-empty fields are checked
+type HTTPAssertion struct {
+	// Status is a list of allowed status codes, by default any 2xx status is allowed
+	Status []int `toml:"status" yaml:"status"`
+	// JSONPath is a dot separated path in a JSON response body, ex.: "data.items.0.id"
+	JSONPath string `toml:"json_path" yaml:"json_path"`
+	// Equals is an expected value at JSONPath, compared as a string, if empty only presence of the path is checked
+	Equals string `toml:"equals" yaml:"equals"`
+	// BodyContains is a substring the response body must contain
+	BodyContains string `toml:"body_contains" yaml:"body_contains"`
+	// MaxLatency is the maximum allowed request latency
+	MaxLatency FileDuration `toml:"max_latency" yaml:"max_latency"`
+}
+
+// HTTPTemplateData is available in HTTPGunConfig templates
+type HTTPTemplateData struct {
+	// Seq is a call sequence number starting from 1
+	Seq int64
+	// Vars is the current DataFeeder record
+	Vars map[string]any
+	// Shared is the Generator SharedData
+	Shared any
+}
+
+// httpTemplateFuncs are helper functions available in HTTPGunConfig templates
+var httpTemplateFuncs = template.FuncMap{
+	"uuid": func() string { return uuid.NewString() },
+	//nolint
+	"randInt": func(minV, maxV int) int { return minV + rand.Intn(maxV-minV+1) },
+	"now":     func() time.Time { return time.Now() },
+	"unixMs":  func() int64 { return time.Now().UnixMilli() },
+	"env":     os.Getenv,
+}
+
+// Validate checks required fields and sets defaults
+func (c *HTTPGunConfig) Validate() error {
+	if c.URL == "" {
+		return fmt.Errorf("http gun url is empty")
+	}
+	if c.Method == "" {
+		c.Method = http.MethodGet
+	}
+	c.Method = strings.ToUpper(c.Method)
+	for _, a := range c.Assertions {
+		if a.Equals != "" && a.JSONPath == "" {
+			return fmt.Errorf("http gun assertion has equals but no json_path")
+		}
+	}
+	return nil
+}
+
+// HTTPGun is a built-in Gun sending one templated HTTP request per call and checking the response with assertions
+type HTTPGun struct {
+	client  *resty.Client
+	cfg     *HTTPGunConfig
+	seq     atomic.Int64
+	url     *template.Template
+	body    *template.Template
+	headers map[string]*template.Template
+}
+
+// NewHTTPGun creates a new HTTPGun, templates are parsed once here
+func NewHTTPGun(cfg *HTTPGunConfig) (*HTTPGun, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Feeder == nil && cfg.FeederCSV != "" {
+		f, err := NewCSVFeeder(cfg.FeederCSV, cfg.FeederRandom)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Feeder = f
+	}
+	m := &HTTPGun{
+		client:  resty.New(),
+		cfg:     cfg,
+		headers: make(map[string]*template.Template),
+	}
+	if cfg.Pool != nil {
+		m.client.SetTransport(newHTTPTransport(cfg.Pool))
+	}
+	var err error
+	if m.url, err = parseHTTPTemplate("url", cfg.URL); err != nil {
+		return nil, err
+	}
+	if m.body, err = parseHTTPTemplate("body", cfg.Body); err != nil {
+		return nil, err
+	}
+	for k, v := range cfg.Headers {
+		if m.headers[k], err = parseHTTPTemplate("header "+k, v); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// newHTTPTransport creates a transport with pooling settings applied over net/http defaults
+func newHTTPTransport(p *HTTPPoolConfig) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if p.MaxIdleConns > 0 {
+		t.MaxIdleConns = p.MaxIdleConns
+	}
+	if p.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = p.MaxIdleConnsPerHost
+	}
+	if p.MaxConnsPerHost > 0 {
+		t.MaxConnsPerHost = p.MaxConnsPerHost
+	}
+	if p.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = time.Duration(p.IdleConnTimeout)
+	}
+	t.DisableKeepAlives = p.DisableKeepAlives
+	if p.InsecureSkipVerify {
+		//nolint
+		t.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	return t
+}
+
+func parseHTTPTemplate(name, text string) (*template.Template, error) {
+	t, err := template.New(name).Funcs(httpTemplateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse http gun %s template: %w", name, err)
+	}
+	return t, nil
+}
+
+func execHTTPTemplate(t *template.Template, data *HTTPTemplateData) (string, error) {
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", t.Name(), err)
+	}
+	return b.String(), nil
+}
+
+// Call renders request templates, sends the request and checks assertions.
+// The request is cancelled after the generator CallTimeout so hung requests don't hold connections
+func (m *HTTPGun) Call(l *Generator) *Response {
+	data := &HTTPTemplateData{Seq: m.seq.Add(1), Shared: l.InputSharedData()}
+	if m.cfg.Feeder != nil {
+		vars, err := m.cfg.Feeder.Next()
+		if err != nil {
+			return &Response{Group: m.cfg.Group, Failed: true, Error: fmt.Sprintf("feeder error: %s", err)}
+		}
+		data.Vars = vars
+	}
+	url, err := execHTTPTemplate(m.url, data)
+	if err != nil {
+		return &Response{Group: m.cfg.Group, Failed: true, Error: err.Error()}
+	}
+	body, err := execHTTPTemplate(m.body, data)
+	if err != nil {
+		return &Response{Group: m.cfg.Group, Path: url, Failed: true, Error: err.Error()}
+	}
+	req := m.client.R()
+	for k, t := range m.headers {
+		v, err := execHTTPTemplate(t, data)
+		if err != nil {
+			return &Response{Group: m.cfg.Group, Path: url, Failed: true, Error: err.Error()}
+		}
+		req.SetHeader(k, v)
+	}
+	if body != "" {
+		req.SetBody(body)
+	}
+	if l.Cfg.CallTimeout > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), l.Cfg.CallTimeout)
+		defer cancel()
+		req.SetContext(ctx)
+	}
+	start := time.Now()
+	r, err := req.Execute(m.cfg.Method, url)
+	latency := time.Since(start)
+	if err != nil {
+		return &Response{Group: m.cfg.Group, Path: url, Failed: true, Error: err.Error()}
+	}
+	res := &Response{
+		Group:      m.cfg.Group,
+		Path:       url,
+		StatusCode: strconv.Itoa(r.StatusCode()),
+	}
+	if m.cfg.StoreBody {
+		res.Data = r.String()
+	}
+	if err := m.check(r, latency); err != nil {
+		res.Failed = true
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// check verifies the response against assertions, any non 2xx status is a failure unless allowed by an assertion
+func (m *HTTPGun) check(r *resty.Response, latency time.Duration) error {
+	statusChecked := false
+	var parsed any
+	for _, a := range m.cfg.Assertions {
+		if len(a.Status) > 0 {
+			statusChecked = true
+			if !slices.Contains(a.Status, r.StatusCode()) {
+				return fmt.Errorf("assertion failed: status %d not in %v", r.StatusCode(), a.Status)
+			}
+		}
+		if a.BodyContains != "" && !strings.Contains(r.String(), a.BodyContains) {
+			return fmt.Errorf("assertion failed: body does not contain %q", a.BodyContains)
+		}
+		if a.MaxLatency > 0 && latency > time.Duration(a.MaxLatency) {
+			return fmt.Errorf("assertion failed: latency %s > %s", latency, time.Duration(a.MaxLatency))
+		}
+		if a.JSONPath != "" {
+			if parsed == nil {
+				if err := json.Unmarshal(r.Body(), &parsed); err != nil {
+					return fmt.Errorf("assertion failed: response body is not JSON: %w", err)
+				}
+			}
+			v, ok := JSONPathLookup(parsed, a.JSONPath)
+			if !ok {
+				return fmt.Errorf("assertion failed: json path %q not found", a.JSONPath)
+			}
+			if a.Equals != "" && fmt.Sprint(v) != a.Equals {
+				return fmt.Errorf("assertion failed: json path %q is %v, expected %s", a.JSONPath, v, a.Equals)
+			}
+		}
+	}
+	if !statusChecked && !r.IsSuccess() {
+		return fmt.Errorf("unexpected status code: %d", r.StatusCode())
+	}
+	return nil
+}
+
+// JSONPathLookup returns a value from decoded JSON by a dot separated path, ex.: "$.data.items.0.id",
+// numeric path elements index arrays
+func JSONPathLookup(v any, path string) (any, bool) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return v, true
+	}
+	for _, p := range strings.Split(path, ".") {
+		switch cur := v.(type) {
+		case map[string]any:
+			next, ok := cur[p]
+			if !ok {
+				return nil, false
+			}
+			v = next
+		case []any:
+			i, err := strconv.Atoi(p)
+			if err != nil || i < 0 || i >= len(cur) {
+				return nil, false
+			}
+			v = cur[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
@@ -0,0 +1,175 @@
+package wasp
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newHTTPGunTestServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/slow":
+			time.Sleep(100 * time.Millisecond)
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"path":   r.URL.Path,
+			"query":  r.URL.RawQuery,
+			"header": r.Header.Get("X-Id"),
+			"body":   string(body),
+			"items":  []any{map[string]any{"id": 42}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newHTTPGunTestGenerator(t *testing.T, gun Gun, shared any) *Generator {
+	g, err := NewGenerator(&Config{
+		T:          t,
+		LoadType:   RPS,
+		Schedule:   Plain(1, 1*time.Second),
+		Gun:        gun,
+		SharedData: shared,
+	})
+	require.NoError(t, err)
+	return g
+}
+
+func TestHTTPGunTemplates(t *testing.T) {
+	t.Parallel()
+	srv := newHTTPGunTestServer(t)
+	feeder, err := NewSliceFeeder([]map[string]any{{"user": "alice"}, {"user": "bob"}}, false)
+	require.NoError(t, err)
+	gun, err := NewHTTPGun(&HTTPGunConfig{
+		Method:    "post",
+		URL:       srv.URL + "/users/{{ .Vars.user }}?seq={{ .Seq }}",
+		Headers:   map[string]string{"X-Id": "{{ .Shared.Token }}"},
+		Body:      `{"user":"{{ .Vars.user }}"}`,
+		Group:     "users",
+		Feeder:    feeder,
+		StoreBody: true,
+	})
+	require.NoError(t, err)
+	g := newHTTPGunTestGenerator(t, gun, struct{ Token string }{Token: "secret"})
+
+	for _, user := range []string{"alice", "bob", "alice"} {
+		res := gun.Call(g)
+		require.False(t, res.Failed, res.Error)
+		require.Equal(t, "users", res.Group)
+		require.Equal(t, "200", res.StatusCode)
+		var body map[string]any
+		require.NoError(t, json.Unmarshal([]byte(res.Data.(string)), &body))
+		require.Equal(t, "/users/"+user, body["path"])
+		require.Equal(t, "secret", body["header"])
+		require.Equal(t, `{"user":"`+user+`"}`, body["body"])
+	}
+	res := gun.Call(g)
+	require.Contains(t, res.Path, "seq=4")
+}
+
+func TestHTTPGunBodyNotStoredByDefault(t *testing.T) {
+	t.Parallel()
+	srv := newHTTPGunTestServer(t)
+	gun, err := NewHTTPGun(&HTTPGunConfig{
+		URL:        srv.URL,
+		Assertions: []*HTTPAssertion{{JSONPath: "items.0.id", Equals: "42"}},
+	})
+	require.NoError(t, err)
+	res := gun.Call(newHTTPGunTestGenerator(t, gun, nil))
+	require.False(t, res.Failed, res.Error)
+	require.Nil(t, res.Data)
+}
+
+func TestHTTPGunCallTimeout(t *testing.T) {
+	t.Parallel()
+	srv := newHTTPGunTestServer(t)
+	gun, err := NewHTTPGun(&HTTPGunConfig{URL: srv.URL + "/slow"})
+	require.NoError(t, err)
+	g, err := NewGenerator(&Config{
+		T:           t,
+		LoadType:    RPS,
+		Schedule:    Plain(1, 1*time.Second),
+		CallTimeout: 10 * time.Millisecond,
+		Gun:         gun,
+	})
+	require.NoError(t, err)
+	start := time.Now()
+	res := gun.Call(g)
+	require.True(t, res.Failed)
+	require.Contains(t, res.Error, "context deadline exceeded")
+	require.Less(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestHTTPGunTemplateErrors(t *testing.T) {
+	t.Parallel()
+	_, err := NewHTTPGun(&HTTPGunConfig{URL: "http://localhost/{{ .Vars.x "})
+	require.ErrorContains(t, err, "failed to parse http gun url template")
+
+	srv := newHTTPGunTestServer(t)
+	gun, err := NewHTTPGun(&HTTPGunConfig{URL: srv.URL + "/{{ .Vars.missing }}"})
+	require.NoError(t, err)
+	res := gun.Call(newHTTPGunTestGenerator(t, gun, nil))
+	require.True(t, res.Failed)
+	require.Contains(t, res.Error, "failed to render url template")
+}
+
+func TestHTTPGunAssertions(t *testing.T) {
+	t.Parallel()
+	srv := newHTTPGunTestServer(t)
+	tests := []struct {
+		name       string
+		path       string
+		assertions []*HTTPAssertion
+		err        string
+	}{
+		{name: "default non 2xx fails", path: "/missing", err: "unexpected status code: 404"},
+		{name: "allowed status", path: "/missing", assertions: []*HTTPAssertion{{Status: []int{404}}}},
+		{name: "status mismatch", path: "/ok", assertions: []*HTTPAssertion{{Status: []int{201}}}, err: "status 200 not in [201]"},
+		{name: "json path equals", path: "/ok", assertions: []*HTTPAssertion{{JSONPath: "$.items.0.id", Equals: "42"}}},
+		{name: "json path mismatch", path: "/ok", assertions: []*HTTPAssertion{{JSONPath: "path", Equals: "/other"}}, err: `json path "path" is /ok, expected /other`},
+		{name: "json path missing", path: "/ok", assertions: []*HTTPAssertion{{JSONPath: "items.1.id"}}, err: `json path "items.1.id" not found`},
+		{name: "body contains", path: "/ok", assertions: []*HTTPAssertion{{BodyContains: `"path":"/ok"`}}},
+		{name: "latency", path: "/slow", assertions: []*HTTPAssertion{{MaxLatency: FileDuration(10 * time.Millisecond)}}, err: "assertion failed: latency"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			gun, err := NewHTTPGun(&HTTPGunConfig{URL: srv.URL + tc.path, Assertions: tc.assertions})
+			require.NoError(t, err)
+			res := gun.Call(newHTTPGunTestGenerator(t, gun, nil))
+			if tc.err == "" {
+				require.False(t, res.Failed, res.Error)
+				return
+			}
+			require.True(t, res.Failed)
+			require.Contains(t, res.Error, tc.err)
+		})
+	}
+}
+
+func TestHTTPGunCSVFeederAndPool(t *testing.T) {
+	t.Parallel()
+	srv := newHTTPGunTestServer(t)
+	p := filepath.Join(t.TempDir(), "feed.csv")
+	require.NoError(t, os.WriteFile(p, []byte("id,name\n1,a\n2,b\n"), 0o600))
+	gun, err := NewHTTPGun(&HTTPGunConfig{
+		URL:       srv.URL + "/{{ .Vars.id }}/{{ .Vars.name }}",
+		FeederCSV: p,
+		Pool:      &HTTPPoolConfig{MaxConnsPerHost: 1, MaxIdleConnsPerHost: 1, IdleConnTimeout: FileDuration(time.Second)},
+	})
+	require.NoError(t, err)
+	g := newHTTPGunTestGenerator(t, gun, nil)
+	require.Equal(t, srv.URL+"/1/a", gun.Call(g).Path)
+	require.Equal(t, srv.URL+"/2/b", gun.Call(g).Path)
+	require.Equal(t, srv.URL+"/1/a", gun.Call(g).Path)
+}
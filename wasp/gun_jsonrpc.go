@@ -0,0 +1,283 @@
+package wasp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	MethodEthSendRawTransaction = "eth_sendRawTransaction"
+)
+
+var (
+	ErrTxPoolExhausted = errors.New("pre-signed transactions pool is exhausted")
+	ErrNoTxSource      = errors.New("eth_sendRawTransaction without params requires a TxSource")
+)
+
+// RawTxSource provides signed raw transactions for eth_sendRawTransaction calls, implementations must be safe for concurrent use
+type RawTxSource interface {
+	NextRawTx() (string, error)
+}
+
+// PreSignedTxPool hands out pre-signed raw transactions, picking keys round-robin and keeping nonce order for every key.
+// Every transaction is used only once.
+type PreSignedTxPool struct {
+	mu    *sync.Mutex
+	keys  [][]string
+	key   int
+	total int
+}
+
+// NewPreSignedTxPool creates a pool from hex encoded raw transactions grouped by signing key, ordered by nonce
+func NewPreSignedTxPool(txsByKey [][]string) *PreSignedTxPool {
+	keys := make([][]string, 0, len(txsByKey))
+	total := 0
+	for _, txs := range txsByKey {
+		if len(txs) > 0 {
+			keys = append(keys, append([]string{}, txs...))
+			total += len(txs)
+		}
+	}
+	return &PreSignedTxPool{mu: &sync.Mutex{}, keys: keys, total: total}
+}
+
+// NewPreSignedTxPoolFromFile reads hex encoded raw transactions, one per line, blank lines separate keys
+func NewPreSignedTxPoolFromFile(path string) (*PreSignedTxPool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open raw transactions file: %w", err)
+	}
+	defer f.Close()
+	txsByKey := [][]string{{}}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			txsByKey = append(txsByKey, []string{})
+			continue
+		}
+		txsByKey[len(txsByKey)-1] = append(txsByKey[len(txsByKey)-1], line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read raw transactions file %s: %w", path, err)
+	}
+	return NewPreSignedTxPool(txsByKey), nil
+}
+
+// NextRawTx returns the next transaction of the next key
+func (m *PreSignedTxPool) NextRawTx() (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for range m.keys {
+		k := m.key
+		m.key = (m.key + 1) % len(m.keys)
+		if len(m.keys[k]) > 0 {
+			tx := m.keys[k][0]
+			m.keys[k] = m.keys[k][1:]
+			m.total--
+			return tx, nil
+		}
+	}
+	return "", ErrTxPoolExhausted
+}
+
+// Remaining returns the amount of unused transactions
+func (m *PreSignedTxPool) Remaining() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.total
+}
+
+// JSONRPCMethod is a JSON-RPC call definition in a weighted mix
+type JSONRPCMethod struct {
+	// Method is a JSON-RPC method name, ex.: "eth_call"
+	Method string `toml:"method" yaml:"method"`
+	// Params is a JSON array of params, ex.: `[{"to": "0x..", "data": "0x.."}, "latest"]`,
+	// for eth_sendRawTransaction it can be empty to use the gun TxSource
+	Params string `toml:"params" yaml:"params"`
+	// Weight is a relative frequency of the method in the mix, defaults to 1
+	Weight int `toml:"weight" yaml:"weight"`
+	// Group is a Response.Group, defaults to Method
+	Group string `toml:"group" yaml:"group"`
+}
+
+// JSONRPCGunConfig configures a built-in JSON-RPC gun
+type JSONRPCGunConfig struct {
+	// URL is a JSON-RPC HTTP endpoint
+	URL string `toml:"url" yaml:"url"`
+	// Headers are request headers
+	Headers map[string]string `toml:"headers" yaml:"headers"`
+	// Methods is a weighted mix of calls
+	Methods []*JSONRPCMethod `toml:"methods" yaml:"methods"`
+	// TxSource provides raw transactions for eth_sendRawTransaction without params
+	TxSource RawTxSource `toml:"-" yaml:"-"`
+	// RawTxsFile is a path to a file with pre-signed transactions used as a TxSource if TxSource is not set,
+	// see NewPreSignedTxPoolFromFile
+	RawTxsFile string `toml:"raw_txs_file" yaml:"raw_txs_file"`
+	// Pool controls HTTP connection pooling
+	Pool *HTTPPoolConfig `toml:"pool" yaml:"pool"`
+	// StoreBody keeps JSON-RPC results in Response.Data, they are dropped by default since every response is kept for the whole run
+	StoreBody bool `toml:"store_body" yaml:"store_body"`
+}
+
+// Validate checks required fields and sets defaults
+func (c *JSONRPCGunConfig) Validate() error {
+	if c.URL == "" {
+		return fmt.Errorf("jsonrpc gun url is empty")
+	}
+	if len(c.Methods) == 0 {
+		return fmt.Errorf("jsonrpc gun has no methods")
+	}
+	for _, m := range c.Methods {
+		if m.Method == "" {
+			return fmt.Errorf("jsonrpc gun method name is empty")
+		}
+		if m.Weight < 0 {
+			return fmt.Errorf("jsonrpc gun method %s weight must be >= 0", m.Method)
+		}
+		if m.Weight == 0 {
+			m.Weight = 1
+		}
+		if m.Group == "" {
+			m.Group = m.Method
+		}
+		if m.Params != "" {
+			var params []any
+			if err := json.Unmarshal([]byte(m.Params), &params); err != nil {
+				return fmt.Errorf("jsonrpc gun method %s params must be a JSON array: %w", m.Method, err)
+			}
+		}
+	}
+	return nil
+}
+
+type jsonRPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int64           `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type jsonRPCResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// JSONRPCGun is a built-in Gun sending a weighted mix of JSON-RPC calls, every method is reported as a separate Response.Group
+type JSONRPCGun struct {
+	client      *resty.Client
+	cfg         *JSONRPCGunConfig
+	id          atomic.Int64
+	totalWeight int
+}
+
+// NewJSONRPCGun creates a new JSONRPCGun
+func NewJSONRPCGun(cfg *JSONRPCGunConfig) (*JSONRPCGun, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.TxSource == nil && cfg.RawTxsFile != "" {
+		pool, err := NewPreSignedTxPoolFromFile(cfg.RawTxsFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.TxSource = pool
+	}
+	for _, m := range cfg.Methods {
+		if m.Method == MethodEthSendRawTransaction && m.Params == "" && cfg.TxSource == nil {
+			return nil, ErrNoTxSource
+		}
+	}
+	m := &JSONRPCGun{client: resty.New(), cfg: cfg}
+	if cfg.Pool != nil {
+		m.client.SetTransport(newHTTPTransport(cfg.Pool))
+	}
+	for _, method := range cfg.Methods {
+		m.totalWeight += method.Weight
+	}
+	return m, nil
+}
+
+// pick selects a method according to weights
+func (m *JSONRPCGun) pick() *JSONRPCMethod {
+	//nolint
+	r := rand.Intn(m.totalWeight)
+	for _, method := range m.cfg.Methods {
+		if r < method.Weight {
+			return method
+		}
+		r -= method.Weight
+	}
+	return m.cfg.Methods[len(m.cfg.Methods)-1]
+}
+
+// params returns JSON params for a method, raw transactions are taken from TxSource
+func (m *JSONRPCGun) params(method *JSONRPCMethod) (json.RawMessage, error) {
+	if method.Params != "" {
+		return json.RawMessage(method.Params), nil
+	}
+	if method.Method == MethodEthSendRawTransaction {
+		tx, err := m.cfg.TxSource.NextRawTx()
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal([]string{tx})
+	}
+	return json.RawMessage("[]"), nil
+}
+
+// Call sends one JSON-RPC call picked from the weighted mix.
+// The request is cancelled after the generator CallTimeout so hung nodes don't hold connections
+func (m *JSONRPCGun) Call(l *Generator) *Response {
+	method := m.pick()
+	params, err := m.params(method)
+	if err != nil {
+		return &Response{Group: method.Group, Path: method.Method, Failed: true, Error: err.Error()}
+	}
+	req := m.client.R().
+		SetHeaders(m.cfg.Headers).
+		SetHeader("Content-Type", "application/json").
+		SetBody(&jsonRPCRequest{JSONRPC: "2.0", ID: m.id.Add(1), Method: method.Method, Params: params})
+	if l.Cfg.CallTimeout > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), l.Cfg.CallTimeout)
+		defer cancel()
+		req.SetContext(ctx)
+	}
+	r, err := req.Post(m.cfg.URL)
+	if err != nil {
+		return &Response{Group: method.Group, Path: method.Method, Failed: true, Error: err.Error()}
+	}
+	res := &Response{Group: method.Group, Path: method.Method, StatusCode: strconv.Itoa(r.StatusCode())}
+	// decoded explicitly, nodes don't always set a JSON content type
+	var rpcResp jsonRPCResponse
+	decodeErr := json.Unmarshal(r.Body(), &rpcResp)
+	switch {
+	case rpcResp.Error != nil:
+		res.Failed = true
+		res.Error = fmt.Sprintf("jsonrpc error %d: %s", rpcResp.Error.Code, rpcResp.Error.Message)
+	case !r.IsSuccess():
+		res.Failed = true
+		res.Error = fmt.Sprintf("unexpected status code: %d", r.StatusCode())
+	case decodeErr != nil:
+		res.Failed = true
+		res.Error = fmt.Sprintf("failed to decode jsonrpc response: %s", decodeErr)
+	case m.cfg.StoreBody:
+		res.Data = string(rpcResp.Result)
+	}
+	return res
+}
@@ -0,0 +1,212 @@
+package wasp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newJSONRPCTestServer mocks an EVM node, eth_getLogs always fails, eth_syncing hangs until the request is cancelled,
+// received raw transactions are recorded
+func newJSONRPCTestServer(t *testing.T) (*httptest.Server, func() []string) {
+	mu := &sync.Mutex{}
+	var txs []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     int64             `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		resp := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+		switch req.Method {
+		case "eth_blockNumber":
+			resp["result"] = "0x10"
+		case "eth_getLogs":
+			resp["error"] = map[string]any{"code": -32005, "message": "query returned more than 10000 results"}
+		case "eth_syncing":
+			<-r.Context().Done()
+			return
+		case MethodEthSendRawTransaction:
+			var tx string
+			_ = json.Unmarshal(req.Params[0], &tx)
+			mu.Lock()
+			txs = append(txs, tx)
+			mu.Unlock()
+			resp["result"] = "0xhash"
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, txs...)
+	}
+}
+
+func TestJSONRPCGunMethodMix(t *testing.T) {
+	t.Parallel()
+	srv, sent := newJSONRPCTestServer(t)
+	gun, err := NewJSONRPCGun(&JSONRPCGunConfig{
+		URL: srv.URL,
+		Methods: []*JSONRPCMethod{
+			{Method: "eth_blockNumber", Weight: 3},
+			{Method: "eth_getLogs", Params: `[{"fromBlock": "0x0"}]`},
+			{Method: MethodEthSendRawTransaction, Group: "send"},
+		},
+		TxSource:  NewPreSignedTxPool([][]string{{"0xa0", "0xa1"}, {"0xb0"}}),
+		StoreBody: true,
+	})
+	require.NoError(t, err)
+	g := newHTTPGunTestGenerator(t, gun, nil)
+
+	groups := make(map[string]int)
+	for i := 0; i < 200; i++ {
+		res := gun.Call(g)
+		groups[res.Group]++
+		switch res.Group {
+		case "eth_blockNumber":
+			require.False(t, res.Failed)
+			require.Equal(t, `"0x10"`, res.Data)
+		case "eth_getLogs":
+			require.True(t, res.Failed)
+			require.Contains(t, res.Error, "-32005")
+		case "send":
+			if res.Failed {
+				require.Equal(t, ErrTxPoolExhausted.Error(), res.Error)
+			}
+		}
+	}
+	require.Greater(t, groups["eth_blockNumber"], groups["eth_getLogs"])
+	require.Greater(t, groups["eth_getLogs"], 0)
+	require.Greater(t, groups["send"], 3)
+	require.Equal(t, []string{"0xa0", "0xb0", "0xa1"}, sent())
+}
+
+func TestJSONRPCGunHTTPError(t *testing.T) {
+	t.Parallel()
+	srv, _ := newJSONRPCTestServer(t)
+	gun, err := NewJSONRPCGun(&JSONRPCGunConfig{
+		URL:     srv.URL,
+		Methods: []*JSONRPCMethod{{Method: "eth_unknown"}},
+	})
+	require.NoError(t, err)
+	res := gun.Call(newHTTPGunTestGenerator(t, gun, nil))
+	require.True(t, res.Failed)
+	require.Equal(t, "500", res.StatusCode)
+}
+
+func TestJSONRPCGunResultNotStoredByDefault(t *testing.T) {
+	t.Parallel()
+	srv, _ := newJSONRPCTestServer(t)
+	gun, err := NewJSONRPCGun(&JSONRPCGunConfig{
+		URL:     srv.URL,
+		Methods: []*JSONRPCMethod{{Method: "eth_blockNumber"}},
+	})
+	require.NoError(t, err)
+	res := gun.Call(newHTTPGunTestGenerator(t, gun, nil))
+	require.False(t, res.Failed, res.Error)
+	require.Nil(t, res.Data)
+}
+
+func TestJSONRPCGunCallTimeout(t *testing.T) {
+	t.Parallel()
+	srv, _ := newJSONRPCTestServer(t)
+	gun, err := NewJSONRPCGun(&JSONRPCGunConfig{
+		URL:     srv.URL,
+		Methods: []*JSONRPCMethod{{Method: "eth_syncing"}},
+	})
+	require.NoError(t, err)
+	g, err := NewGenerator(&Config{
+		T:           t,
+		LoadType:    RPS,
+		Schedule:    Plain(1, 1*time.Second),
+		CallTimeout: 10 * time.Millisecond,
+		Gun:         gun,
+	})
+	require.NoError(t, err)
+	start := time.Now()
+	res := gun.Call(g)
+	require.True(t, res.Failed)
+	require.Contains(t, res.Error, "context deadline exceeded")
+	require.Less(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestJSONRPCGunValidation(t *testing.T) {
+	t.Parallel()
+	_, err := NewJSONRPCGun(&JSONRPCGunConfig{URL: "http://localhost"})
+	require.Error(t, err)
+	_, err = NewJSONRPCGun(&JSONRPCGunConfig{URL: "http://localhost", Methods: []*JSONRPCMethod{{Method: "eth_call", Params: `{}`}}})
+	require.Error(t, err)
+	_, err = NewJSONRPCGun(&JSONRPCGunConfig{URL: "http://localhost", Methods: []*JSONRPCMethod{{Method: MethodEthSendRawTransaction}}})
+	require.ErrorIs(t, err, ErrNoTxSource)
+}
+
+func TestPreSignedTxPoolFromFile(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "txs.txt")
+	require.NoError(t, os.WriteFile(path, []byte("0xa0\n0xa1\n\n\n0xb0\n0xb1\n0xb2\n"), 0o600))
+	pool, err := NewPreSignedTxPoolFromFile(path)
+	require.NoError(t, err)
+	require.Equal(t, 5, pool.Remaining())
+	var got []string
+	for {
+		tx, err := pool.NextRawTx()
+		if err != nil {
+			require.ErrorIs(t, err, ErrTxPoolExhausted)
+			break
+		}
+		got = append(got, tx)
+	}
+	require.Equal(t, []string{"0xa0", "0xb0", "0xa1", "0xb1", "0xb2"}, got)
+	require.Equal(t, 0, pool.Remaining())
+}
+
+func TestSmokeJSONRPCGunProfileFile(t *testing.T) {
+	t.Parallel()
+	srv, sent := newJSONRPCTestServer(t)
+	txs := filepath.Join(t.TempDir(), "txs.txt")
+	require.NoError(t, os.WriteFile(txs, []byte("0x01\n0x02\n0x03\n"), 0o600))
+	path := writeProfileFile(t, "profile.toml", `
+name = "rpc"
+
+[[generator]]
+name = "rpc"
+load_type = "rps"
+schedule = [{ type = "plain", from = 10, duration = "1s" }]
+
+[generator.gun]
+type = "jsonrpc"
+
+[generator.gun.jsonrpc]
+url = "`+srv.URL+`"
+raw_txs_file = "`+txs+`"
+methods = [
+  { method = "eth_blockNumber", weight = 5 },
+  { method = "eth_sendRawTransaction", weight = 1 },
+]
+`)
+	p, err := LoadProfileFromFile(path)
+	require.NoError(t, err)
+	_, err = p.Run(true)
+	require.NoError(t, err)
+	ok := p.Generators[0].GetData().OKResponses.Data
+	require.NotEmpty(t, ok)
+	for _, r := range ok {
+		require.Contains(t, []string{"eth_blockNumber", MethodEthSendRawTransaction}, r.Group)
+	}
+	require.LessOrEqual(t, len(sent()), 3)
+}
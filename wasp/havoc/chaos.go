@@ -27,6 +27,11 @@ type Chaos struct {
 	endTime       time.Time
 	logger        *zerolog.Logger
 	remove        bool
+	// injected is closed when the fault is injected or fails to, injectErr is set before it's closed
+	injected  chan struct{}
+	injectErr error
+	// docker is set for experiments on Docker containers, see NewDockerChaos
+	docker *dockerInjector
 }
 
 // ChaosStatus represents the status of a chaos experiment.
@@ -82,6 +87,7 @@ func NewChaos(opts ChaosOpts) (*Chaos, error) {
 // It uses a timer based on `DelayCreate` and calls `create` method upon expiration unless preempted by deletion.
 func (c *Chaos) Create(ctx context.Context) {
 	done := make(chan struct{})
+	c.injected = make(chan struct{})
 
 	// Create the timer with the delay to create the chaos object
 	timer := time.NewTimer(c.DelayCreate)
@@ -95,8 +101,8 @@ func (c *Chaos) Create(ctx context.Context) {
 			}
 			close(done) // Signal that the operation was canceled
 		case <-timer.C:
-			// Timer expired, check if deletion was not requested
-			if c.Status != StatusDeleted {
+			// Timer expired, check if deletion was not requested, Docker experiments check it under their lock
+			if c.docker != nil || c.Status != StatusDeleted {
 				c.createNow(ctx)
 			}
 			close(done) // Signal that the creation process is either done or skipped
@@ -124,8 +130,14 @@ func (c *Chaos) Update(ctx context.Context) error {
 
 // createNow is a private method that encapsulates the chaos object creation logic.
 func (c *Chaos) createNow(ctx context.Context) {
+	if c.docker != nil {
+		c.createDockerNow(ctx)
+		return
+	}
 	if err := c.Client.Create(ctx, c.Object); err != nil {
+		c.Status = StatusCreationFailed
 		c.notifyListeners(string(StatusCreationFailed), err)
+		c.injectionDone(err)
 		return
 	}
 	c.notifyListeners(string(StatusCreated), nil)
@@ -137,6 +149,9 @@ func (c *Chaos) createNow(ctx context.Context) {
 }
 
 func (c *Chaos) Pause(ctx context.Context) error {
+	if c.docker != nil {
+		return c.pauseDocker(ctx)
+	}
 	err := c.updateChaosObject(ctx)
 	if err != nil {
 		return errors.Wrap(err, "could not update the chaos object")
@@ -159,6 +174,9 @@ func (c *Chaos) Pause(ctx context.Context) error {
 }
 
 func (c *Chaos) Resume(ctx context.Context) error {
+	if c.docker != nil {
+		return c.resumeDocker(ctx)
+	}
 	// Implement resume logic here
 	c.notifyListeners("resumed", nil)
 	return nil
@@ -167,6 +185,9 @@ func (c *Chaos) Resume(ctx context.Context) error {
 // Delete stops the chaos operation, updates its status, and removes the chaos object if specified.
 // It notifies listeners of the operation's completion and handles any errors encountered during the process.
 func (c *Chaos) Delete(ctx context.Context) error {
+	if c.docker != nil {
+		return c.deleteDocker(ctx)
+	}
 	defer func() {
 		// Cancel the monitoring goroutine
 		if c.cancelMonitor != nil {
@@ -219,6 +240,8 @@ func (c *Chaos) GetChaosTypeStr() string {
 		return "PodChaos"
 	case *v1alpha1.HTTPChaos:
 		return "HTTPChaos"
+	case *DockerChaos:
+		return TypeDockerChaos
 	default:
 		return "Unknown"
 	}
@@ -236,6 +259,8 @@ func (c *Chaos) GetChaosSpec() interface{} {
 		return spec.Spec
 	case *v1alpha1.HTTPChaos:
 		return spec.Spec
+	case *DockerChaos:
+		return spec.Spec
 	default:
 		return nil
 	}
@@ -254,6 +279,8 @@ func (c *Chaos) GetChaosDuration() (time.Duration, error) {
 		durationStr = spec.Spec.Duration
 	case *v1alpha1.HTTPChaos:
 		durationStr = spec.Spec.Duration
+	case *DockerChaos:
+		durationStr = &spec.Spec.Duration
 	}
 
 	if durationStr == nil {
@@ -267,6 +294,10 @@ func (c *Chaos) GetChaosDuration() (time.Duration, error) {
 }
 
 func (c *Chaos) GetChaosEvents() (*corev1.EventList, error) {
+	if _, ok := c.Object.(*DockerChaos); ok {
+		// Docker experiments don't produce Kubernetes events
+		return &corev1.EventList{}, nil
+	}
 	listOpts := []client.ListOption{
 		client.InNamespace(c.Object.GetNamespace()),
 		client.MatchingFields{"involvedObject.name": c.Object.GetName(), "involvedObject.kind": c.GetChaosKind()},
@@ -291,6 +322,8 @@ func (c *Chaos) GetChaosKind() string {
 		return "PodChaos"
 	case *v1alpha1.HTTPChaos:
 		return "HTTPChaos"
+	case *DockerChaos:
+		return TypeDockerChaos
 	default:
 		panic(fmt.Sprintf("could not get chaos kind for object: %v", c.Object))
 	}
@@ -308,6 +341,8 @@ func (c *Chaos) GetChaosStatus() (*v1alpha1.ChaosStatus, error) {
 		return obj.GetStatus(), nil
 	case *v1alpha1.HTTPChaos:
 		return obj.GetStatus(), nil
+	case *DockerChaos:
+		return &obj.Status, nil
 	default:
 		return nil, fmt.Errorf("could not get chaos status for %s", c.GetChaosKind())
 	}
@@ -325,6 +360,8 @@ func (c *Chaos) GetExperimentStatus() (v1alpha1.ExperimentStatus, error) {
 		return obj.Status.Experiment, nil
 	case *v1alpha1.HTTPChaos:
 		return obj.Status.Experiment, nil
+	case *DockerChaos:
+		return obj.Status.Experiment, nil
 	default:
 		return v1alpha1.ExperimentStatus{}, fmt.Errorf("could not experiment status for object: %v", c.Object)
 	}
@@ -393,6 +430,8 @@ func (c *Chaos) updateChaosObject(ctx context.Context) error {
 			return errors.Wrap(err, "could not get schedule object")
 		}
 		c.Object = objOut
+	case *DockerChaos:
+		// Docker experiments keep their state locally
 	default:
 		return fmt.Errorf("unsupported chaos object type: %T", obj)
 	}
@@ -427,6 +466,37 @@ func (c *Chaos) GetEndTime() time.Time {
 	return c.endTime
 }
 
+// WaitInjected blocks until the experiment is running, or returns an error if its creation failed or ctx is done.
+// Creation is asynchronous and starts after DelayCreate, use it to know when the fault is actually injected
+func (c *Chaos) WaitInjected(ctx context.Context) error {
+	if c.injected == nil {
+		return fmt.Errorf("experiment %s wasn't created", c.GetChaosName())
+	}
+	select {
+	case <-c.injected:
+		return c.injectErr
+	case <-ctx.Done():
+		return errors.Wrapf(ctx.Err(), "experiment %s wasn't injected", c.GetChaosName())
+	}
+}
+
+// injectionDone unblocks WaitInjected once, err is nil if the fault was injected. It has to be called
+// by the goroutine creating the experiment, or under the lock of Docker experiments, after the status and start time are set
+func (c *Chaos) injectionDone(err error) {
+	if c.injected == nil {
+		return
+	}
+	select {
+	case <-c.injected:
+		return
+	default:
+	}
+	if err != nil {
+		c.injectErr = errors.Wrapf(err, "experiment %s failed to inject", c.GetChaosName())
+	}
+	close(c.injected)
+}
+
 // GetExpectedEndTime returns the time when the chaos experiment is expected to end
 func (c *Chaos) GetExpectedEndTime() (time.Time, error) {
 	duration, err := c.GetChaosDuration()
@@ -526,11 +596,13 @@ func (c *Chaos) monitorStatus(ctx context.Context) {
 				case StatusRunning:
 					c.startTime = time.Now()
 					c.notifyListeners("started", nil)
+					c.injectionDone(nil)
 				case StatusPaused:
 					c.notifyListeners("paused", nil)
 				case StatusFinished:
 					c.endTime = time.Now()
 					c.notifyListeners("finished", nil)
+					c.injectionDone(nil)
 
 					err := c.Delete(ctx)
 					if err != nil {
@@ -0,0 +1,229 @@
+package wasp
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/wasp"
+)
+
+// Labels of time windows recorded by ChaosPlan
+const (
+	ChaosWindowLabelName    = "chaos_name"
+	ChaosWindowLabelType    = "chaos_type"
+	ChaosWindowLabelTargets = "targets"
+	ChaosWindowLabelError   = "error"
+)
+
+// ChaosPlanStep is an experiment of a ChaosPlan
+type ChaosPlanStep struct {
+	// Name identifies the window of the experiment in results, ex.: "node1-latency"
+	Name string
+	// Offset from the load start at which the experiment is created
+	Offset time.Duration
+	// Duration after which the experiment is deleted, the duration of the experiment is used if empty
+	Duration time.Duration
+	// Targets are services affected by the experiment, they are recorded in window labels
+	Targets []string
+	// Experiment creates the experiment, ex.: a DockerChaosRunner or NamespaceScopedChaosRunner method call
+	Experiment func(ctx context.Context) (*Chaos, error)
+}
+
+// ChaosPlan is a timeline of chaos experiments running along with the load. Attach it to a wasp.Profile
+// and experiments start and stop at their offsets from the load start, every experiment is recorded as a
+// wasp.TimeWindow available with wasp.Profile.Windows, ex.: to compare metrics per window with benchspy
+type ChaosPlan struct {
+	Steps   []ChaosPlanStep
+	l       zerolog.Logger
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	wg      *sync.WaitGroup
+	windows []wasp.TimeWindow
+	errs    []error
+}
+
+// NewChaosPlan creates a new chaos plan, steps may overlap
+func NewChaosPlan(l zerolog.Logger, steps ...ChaosPlanStep) (*ChaosPlan, error) {
+	if len(steps) == 0 {
+		return nil, errors.New("at least one step is required")
+	}
+	names := make(map[string]bool)
+	for i, s := range steps {
+		if s.Name == "" {
+			return nil, fmt.Errorf("step %d: name is required", i)
+		}
+		if names[s.Name] {
+			return nil, fmt.Errorf("step names must be unique, %s is used more than once", s.Name)
+		}
+		names[s.Name] = true
+		if s.Offset < 0 {
+			return nil, fmt.Errorf("step %s: offset can't be negative", s.Name)
+		}
+		if s.Duration < 0 {
+			return nil, fmt.Errorf("step %s: duration can't be negative", s.Name)
+		}
+		if s.Experiment == nil {
+			return nil, fmt.Errorf("step %s: experiment is required", s.Name)
+		}
+	}
+	return &ChaosPlan{
+		Steps: steps,
+		l:     l,
+		wg:    &sync.WaitGroup{},
+	}, nil
+}
+
+// SegmentOffset returns the offset from the load start at which the segment of the schedule starts,
+// ex.: to inject chaos when the load reaches its plateau
+func SegmentOffset(schedule []*wasp.Segment, segment int) (time.Duration, error) {
+	if segment < 0 || segment >= len(schedule) {
+		return 0, fmt.Errorf("schedule has %d segments, segment %d doesn't exist", len(schedule), segment)
+	}
+	var offset time.Duration
+	for _, s := range schedule[:segment] {
+		offset += s.Duration
+	}
+	return offset, nil
+}
+
+// Attach adds the plan to the profile, experiments are started when the profile runs
+func (p *ChaosPlan) Attach(profile *wasp.Profile) *wasp.Profile {
+	return profile.WithHooks(p)
+}
+
+// Start schedules experiments relative to the profile start time, it implements wasp.ProfileHook
+func (p *ChaosPlan) Start(profile *wasp.Profile) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cancel != nil {
+		return errors.New("chaos plan is already started")
+	}
+	start := profile.StartTime()
+	if start.IsZero() {
+		start = time.Now()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	for _, s := range p.Steps {
+		p.wg.Add(1)
+		go p.runStep(ctx, start, s)
+	}
+	p.l.Info().
+		Str("ProfileID", profile.ProfileID).
+		Int("Experiments", len(p.Steps)).
+		Msg("Chaos plan started")
+	return nil
+}
+
+// Stop deletes running experiments, skips the ones that didn't start yet, and returns windows of all experiments
+// that were created, it implements wasp.ProfileHook
+func (p *ChaosPlan) Stop(_ *wasp.Profile) ([]wasp.TimeWindow, error) {
+	p.mu.Lock()
+	cancel := p.cancel
+	p.mu.Unlock()
+	if cancel == nil {
+		return nil, errors.New("chaos plan wasn't started")
+	}
+	cancel()
+	p.wg.Wait()
+	windows := p.Windows()
+	p.l.Info().Int("Windows", len(windows)).Msg("Chaos plan stopped")
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return windows, goerrors.Join(p.errs...)
+}
+
+// Windows returns windows of experiments which already ended, ordered by their start time
+func (p *ChaosPlan) Windows() []wasp.TimeWindow {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	windows := append([]wasp.TimeWindow(nil), p.windows...)
+	sort.SliceStable(windows, func(i, j int) bool {
+		return windows[i].Start.Before(windows[j].Start)
+	})
+	return windows
+}
+
+// runStep creates the experiment at its offset and deletes it after its duration or when the plan is stopped,
+// the window spans from the injection to the deletion of the experiment. Experiments which fail to inject
+// are recorded with ChaosWindowLabelError, so they aren't compared as chaos windows
+func (p *ChaosPlan) runStep(ctx context.Context, start time.Time, s ChaosPlanStep) {
+	defer p.wg.Done()
+	select {
+	case <-time.After(time.Until(start.Add(s.Offset))):
+	case <-ctx.Done():
+		p.l.Warn().Str("Step", s.Name).Msg("Load ended before the experiment was started, skipping it")
+		return
+	}
+
+	window := wasp.TimeWindow{
+		Name:   s.Name,
+		Start:  time.Now(),
+		Labels: map[string]string{ChaosWindowLabelTargets: strings.Join(s.Targets, ",")},
+	}
+	chaos, err := s.Experiment(ctx)
+	if err != nil {
+		p.fail(window, errors.Wrapf(err, "failed to create experiment %s", s.Name))
+		return
+	}
+	window.Labels[ChaosWindowLabelName] = chaos.GetChaosName()
+	window.Labels[ChaosWindowLabelType] = chaos.GetChaosTypeStr()
+
+	// experiments without a duration run until the load ends
+	duration := s.Duration
+	if duration == 0 {
+		duration, err = chaos.GetChaosDuration()
+		if err != nil {
+			p.l.Warn().Err(err).Str("Step", s.Name).Msg("Experiment has no duration, it'll run until the load ends")
+		}
+	}
+	injectCtx := ctx
+	if duration > 0 {
+		var cancel context.CancelFunc
+		injectCtx, cancel = context.WithTimeout(ctx, chaos.DelayCreate+duration)
+		defer cancel()
+	}
+	if err := chaos.WaitInjected(injectCtx); err != nil {
+		_ = chaos.Delete(context.Background())
+		p.fail(window, err)
+		return
+	}
+	window.Start = chaos.GetStartTime()
+
+	var ended <-chan time.Time
+	if duration > 0 {
+		ended = time.After(time.Until(window.Start.Add(duration)))
+	}
+	select {
+	case <-ended:
+	case <-ctx.Done():
+	}
+
+	// the plan's context is already cancelled when the load ends
+	if err := chaos.Delete(context.Background()); err != nil {
+		p.fail(window, errors.Wrapf(err, "failed to delete experiment %s", s.Name))
+		return
+	}
+	window.End = time.Now()
+	p.mu.Lock()
+	p.windows = append(p.windows, window)
+	p.mu.Unlock()
+}
+
+func (p *ChaosPlan) fail(window wasp.TimeWindow, err error) {
+	p.l.Error().Err(err).Str("Step", window.Name).Msg("Chaos plan step failed")
+	window.End = time.Now()
+	window.Labels[ChaosWindowLabelError] = err.Error()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.windows = append(p.windows, window)
+	p.errs = append(p.errs, err)
+}
@@ -0,0 +1,139 @@
+package wasp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/wasp"
+)
+
+func TestChaosPlan(t *testing.T) {
+	l := zerolog.Nop()
+
+	t.Run("experiments follow the profile timeline", func(t *testing.T) {
+		fake := &fakeDockerClient{}
+		runner := NewDockerRunner(l, fake)
+		plan, err := NewChaosPlan(l,
+			ChaosPlanStep{
+				Name:     "pause-node1",
+				Offset:   200 * time.Millisecond,
+				Duration: 300 * time.Millisecond,
+				Targets:  []string{"node1"},
+				Experiment: func(ctx context.Context) (*Chaos, error) {
+					return runner.RunContainerPause(ctx, ContainerPauseCfg{Containers: []string{"node1"}, InjectionDuration: time.Hour})
+				},
+			},
+			ChaosPlanStep{
+				// runs until the load ends, since the experiment is longer than the load
+				Name:    "kill-node2",
+				Offset:  time.Second,
+				Targets: []string{"node2"},
+				Experiment: func(ctx context.Context) (*Chaos, error) {
+					return runner.RunContainerKill(ctx, ContainerKillCfg{Containers: []string{"node2"}, InjectionDuration: time.Hour})
+				},
+			},
+			ChaosPlanStep{
+				Name:   "after-load",
+				Offset: time.Hour,
+				Experiment: func(_ context.Context) (*Chaos, error) {
+					return nil, errors.New("shouldn't be created")
+				},
+			},
+		)
+		require.NoError(t, err)
+
+		gen, err := wasp.NewGenerator(&wasp.Config{
+			T:        t,
+			GenName:  "gen",
+			LoadType: wasp.RPS,
+			Schedule: wasp.Plain(10, 2*time.Second),
+			Gun:      wasp.NewMockGun(&wasp.MockGunConfig{CallSleep: 10 * time.Millisecond}),
+		})
+		require.NoError(t, err)
+		profile, err := plan.Attach(wasp.NewProfile()).Add(gen, nil).Run(true)
+		require.NoError(t, err)
+
+		windows := profile.Windows()
+		require.Len(t, windows, 2)
+		require.Equal(t, "pause-node1", windows[0].Name)
+		require.Equal(t, TypeDockerChaos, windows[0].Labels[ChaosWindowLabelType])
+		require.Equal(t, "node1", windows[0].Labels[ChaosWindowLabelTargets])
+		require.NotEmpty(t, windows[0].Labels[ChaosWindowLabelName])
+		require.InDelta(t, 200*time.Millisecond, windows[0].Start.Sub(profile.StartTime()), float64(100*time.Millisecond))
+		require.InDelta(t, 300*time.Millisecond, windows[0].End.Sub(windows[0].Start), float64(100*time.Millisecond))
+
+		require.Equal(t, "kill-node2", windows[1].Name)
+		require.True(t, windows[1].End.After(profile.StartTime().Add(2*time.Second)), "deleted when the load ended")
+		require.Equal(t, []string{"pause node1", "unpause node1", "kill node2", "start node2"}, fake.Calls())
+	})
+
+	t.Run("failed experiments are recorded", func(t *testing.T) {
+		plan, err := NewChaosPlan(l, ChaosPlanStep{
+			Name: "failing",
+			Experiment: func(_ context.Context) (*Chaos, error) {
+				return nil, errors.New("no cluster")
+			},
+		})
+		require.NoError(t, err)
+		profile := wasp.NewProfile()
+		require.NoError(t, plan.Start(profile))
+		require.Error(t, plan.Start(profile), "already started")
+		require.Eventually(t, func() bool {
+			return len(plan.Windows()) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+		windows, err := plan.Stop(profile)
+		require.ErrorContains(t, err, "no cluster")
+		require.Len(t, windows, 1)
+		require.Contains(t, windows[0].Labels[ChaosWindowLabelError], "no cluster")
+	})
+
+	t.Run("experiments which failed to inject are recorded", func(t *testing.T) {
+		fake := &fakeDockerClient{failOn: "pause node1"}
+		runner := NewDockerRunner(l, fake)
+		plan, err := NewChaosPlan(l, ChaosPlanStep{
+			Name:     "pause-node1",
+			Duration: time.Second,
+			Experiment: func(ctx context.Context) (*Chaos, error) {
+				return runner.RunContainerPause(ctx, ContainerPauseCfg{Containers: []string{"node1"}, InjectionDuration: time.Hour})
+			},
+		})
+		require.NoError(t, err)
+		profile := wasp.NewProfile()
+		require.NoError(t, plan.Start(profile))
+		require.Eventually(t, func() bool {
+			return len(plan.Windows()) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+		windows, err := plan.Stop(profile)
+		require.ErrorContains(t, err, "failed to inject")
+		require.Len(t, windows, 1)
+		require.Contains(t, windows[0].Labels[ChaosWindowLabelError], "fake failure")
+	})
+
+	t.Run("invalid steps", func(t *testing.T) {
+		experiment := func(_ context.Context) (*Chaos, error) { return nil, nil }
+		_, err := NewChaosPlan(l)
+		require.ErrorContains(t, err, "at least one step is required")
+		_, err = NewChaosPlan(l, ChaosPlanStep{Experiment: experiment})
+		require.ErrorContains(t, err, "name is required")
+		_, err = NewChaosPlan(l, ChaosPlanStep{Name: "a", Experiment: experiment}, ChaosPlanStep{Name: "a", Experiment: experiment})
+		require.ErrorContains(t, err, "a is used more than once")
+		_, err = NewChaosPlan(l, ChaosPlanStep{Name: "a", Offset: -time.Second, Experiment: experiment})
+		require.ErrorContains(t, err, "offset can't be negative")
+		_, err = NewChaosPlan(l, ChaosPlanStep{Name: "a"})
+		require.ErrorContains(t, err, "experiment is required")
+	})
+
+	t.Run("segment offset", func(t *testing.T) {
+		schedule := wasp.Combine(wasp.Steps(1, 1, 5, 10*time.Second), wasp.Plain(5, time.Minute))
+		offset, err := SegmentOffset(schedule, len(schedule)-1)
+		require.NoError(t, err)
+		require.Equal(t, 10*time.Second, offset)
+		_, err = SegmentOffset(schedule, len(schedule))
+		require.Error(t, err)
+	})
+}
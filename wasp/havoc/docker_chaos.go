@@ -0,0 +1,630 @@
+package wasp
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chaos-mesh/chaos-mesh/api/v1alpha1"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// DockerChaosAction is a fault injected into Docker containers
+type DockerChaosAction string
+
+const (
+	DockerNetworkDelayAction     DockerChaosAction = "network-delay"
+	DockerNetworkLossAction      DockerChaosAction = "network-loss"
+	DockerNetworkPartitionAction DockerChaosAction = "network-partition"
+	DockerPauseAction            DockerChaosAction = "pause"
+	DockerKillAction             DockerChaosAction = "kill"
+	DockerCPUStressAction        DockerChaosAction = "cpu-stress"
+	DockerMemoryStressAction     DockerChaosAction = "memory-stress"
+)
+
+const (
+	TypeDockerChaos = "DockerChaos"
+
+	// helperLabel marks containers started to inject faults
+	helperLabel = "havoc.chaos"
+)
+
+var (
+	// DockerNetworkToolsImage is used to run tc and iptables in the network namespace of target containers
+	DockerNetworkToolsImage = "ghcr.io/alexei-led/pumba-alpine-nettools:latest"
+	// DockerStressImage is used to run stress-ng next to target containers
+	DockerStressImage = "alexeiled/stress-ng:latest"
+)
+
+// DockerChaosSpec describes a fault injected into Docker containers for the duration of the experiment
+type DockerChaosSpec struct {
+	Action DockerChaosAction `json:"action"`
+	// Containers are names of target containers
+	Containers []string `json:"containers"`
+	Duration   string   `json:"duration"`
+	// Interface is the network interface tc rules are added to, defaults to eth0
+	Interface string `json:"interface,omitempty"`
+	// Latency and Jitter are used by network-delay, ex.: "200ms"
+	Latency string `json:"latency,omitempty"`
+	Jitter  string `json:"jitter,omitempty"`
+	// Loss is the percentage of packets dropped by network-loss
+	Loss string `json:"loss,omitempty"`
+	// Targets are names of containers network-partition cuts the target containers from
+	Targets []string `json:"targets,omitempty"`
+	// Signal is sent by kill, defaults to SIGKILL, killed containers are started again when the experiment ends
+	Signal string `json:"signal,omitempty"`
+	// Workers and Load are used by cpu-stress, load is in percent per worker
+	Workers int `json:"workers,omitempty"`
+	Load    int `json:"load,omitempty"`
+	// MemorySize is allocated by memory-stress, ex.: "256M"
+	MemorySize string `json:"memorySize,omitempty"`
+}
+
+// Validate checks that the spec has everything its action needs
+func (s *DockerChaosSpec) Validate() error {
+	if len(s.Containers) == 0 {
+		return errors.New("at least one container is required")
+	}
+	duration, err := time.ParseDuration(s.Duration)
+	if err != nil {
+		return fmt.Errorf("invalid duration: %w", err)
+	}
+	if duration <= 0 {
+		return errors.New("duration should be positive")
+	}
+	switch s.Action {
+	case DockerNetworkDelayAction:
+		latency, err := time.ParseDuration(s.Latency)
+		if err != nil {
+			return fmt.Errorf("invalid latency: %w", err)
+		}
+		if latency > 500*time.Millisecond {
+			return fmt.Errorf("duration should be less than 500ms")
+		}
+		if s.Jitter != "" {
+			if _, err := time.ParseDuration(s.Jitter); err != nil {
+				return fmt.Errorf("invalid jitter: %w", err)
+			}
+		}
+	case DockerNetworkLossAction:
+		loss, err := strconv.Atoi(s.Loss)
+		if err != nil {
+			return fmt.Errorf("invalid loss value: %s", err)
+		}
+		if loss <= 0 || loss > 100 {
+			return fmt.Errorf("loss should be between 1 and 100")
+		}
+	case DockerNetworkPartitionAction:
+		if len(s.Targets) == 0 {
+			return errors.New("at least one target container is required for partition")
+		}
+	case DockerCPUStressAction:
+		if s.Workers <= 0 {
+			return errors.New("at least one CPU worker is required")
+		}
+		if s.Load <= 0 || s.Load > 100 {
+			return errors.New("CPU load should be between 1 and 100")
+		}
+	case DockerMemoryStressAction:
+		if s.MemorySize == "" {
+			return errors.New("memory size is required")
+		}
+	case DockerPauseAction, DockerKillAction:
+	default:
+		return fmt.Errorf("unsupported docker chaos action: %s", s.Action)
+	}
+	return nil
+}
+
+// DockerChaos is a chaos experiment on local Docker containers. It implements client.Object, so Chaos and its listeners
+// handle it the same way as Chaos Mesh experiments, status records are updated for every target container.
+type DockerChaos struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DockerChaosSpec      `json:"spec"`
+	Status v1alpha1.ChaosStatus `json:"status,omitempty"`
+}
+
+// DeepCopyObject implements runtime.Object
+func (in *DockerChaos) DeepCopyObject() runtime.Object {
+	if in == nil {
+		return nil
+	}
+	out := &DockerChaos{
+		TypeMeta: in.TypeMeta,
+		Spec:     in.Spec,
+	}
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec.Containers = append([]string(nil), in.Spec.Containers...)
+	out.Spec.Targets = append([]string(nil), in.Spec.Targets...)
+	in.Status.DeepCopyInto(&out.Status)
+	return out
+}
+
+// DockerChaosOpts are options of a Docker chaos experiment
+type DockerChaosOpts struct {
+	Object      *DockerChaos
+	Description string
+	DelayCreate time.Duration
+	Client      client.APIClient
+	Listeners   []ChaosListener
+	Logger      *zerolog.Logger
+}
+
+// NewDockerChaos creates a new Chaos injecting faults into Docker containers instead of creating Chaos Mesh objects.
+// Experiment lifecycle and listener events are the same, the fault is recovered after the spec duration or on Delete.
+func NewDockerChaos(opts DockerChaosOpts) (*Chaos, error) {
+	if opts.Client == nil {
+		return nil, errors.New("client is required")
+	}
+	if opts.Object == nil {
+		return nil, errors.New("chaos object is required")
+	}
+	if opts.Logger == nil {
+		return nil, errors.New("logger is required")
+	}
+	if err := opts.Object.Spec.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid docker chaos %s", opts.Object.GetName())
+	}
+	if opts.Object.Kind == "" {
+		opts.Object.Kind = TypeDockerChaos
+	}
+
+	return &Chaos{
+		Object:      opts.Object,
+		Description: opts.Description,
+		DelayCreate: opts.DelayCreate,
+		listeners:   opts.Listeners,
+		logger:      opts.Logger,
+		docker: &dockerInjector{
+			client:  opts.Client,
+			mu:      &sync.Mutex{},
+			helpers: make(map[string]string),
+			killed:  make(map[string]bool),
+		},
+	}, nil
+}
+
+// NewDockerChaosClient creates a Docker client configured from the environment, ex.: DOCKER_HOST
+func NewDockerChaosClient() (client.APIClient, error) {
+	c, err := client.New(client.FromEnv)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create a Docker client")
+	}
+	return c, nil
+}
+
+// dockerInjector injects and recovers faults of a single Docker experiment, mu serializes lifecycle changes
+// of the experiment, which can be triggered by the duration timer and by the user at the same time
+type dockerInjector struct {
+	client client.APIClient
+	mu     *sync.Mutex
+	// helpers are long-running helper containers keyed by target container, ex.: stress-ng
+	helpers map[string]string
+	// killed are target containers that have to be started again on recovery
+	killed  map[string]bool
+	deleted bool
+}
+
+func (c *Chaos) createDockerNow(ctx context.Context) {
+	c.docker.mu.Lock()
+	defer c.docker.mu.Unlock()
+	if c.docker.deleted {
+		return
+	}
+
+	obj := c.Object.(*DockerChaos)
+	if err := c.docker.inject(ctx, obj); err != nil {
+		if recoverErr := c.docker.recover(context.Background(), obj); recoverErr != nil {
+			c.logger.Error().Err(recoverErr).Str("name", obj.GetName()).Msg("failed to recover partially injected docker chaos")
+		}
+		c.Status = StatusCreationFailed
+		c.notifyListeners(string(StatusCreationFailed), err)
+		c.injectionDone(err)
+		return
+	}
+	c.Status = StatusCreated
+	c.notifyListeners("created", nil)
+
+	c.Status = StatusRunning
+	c.startTime = time.Now()
+	c.notifyListeners("started", nil)
+	c.injectionDone(nil)
+
+	duration, _ := c.GetChaosDuration()
+	monitorCtx, cancel := context.WithCancel(ctx)
+	c.cancelMonitor = cancel
+	go c.monitorDocker(monitorCtx, duration)
+}
+
+// monitorDocker recovers the fault when the experiment duration elapses, or when the context is cancelled,
+// since nothing else would recover Docker containers
+func (c *Chaos) monitorDocker(ctx context.Context, duration time.Duration) {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+	if err := c.finishDocker(context.Background()); err != nil {
+		c.logger.Error().Err(err).Str("name", c.GetChaosName()).Msg("failed to recover docker chaos")
+	}
+}
+
+// finishDocker recovers a running or paused experiment and notifies listeners that it ended
+func (c *Chaos) finishDocker(ctx context.Context) error {
+	c.docker.mu.Lock()
+	defer c.docker.mu.Unlock()
+	if c.Status != StatusRunning && c.Status != StatusPaused {
+		return nil
+	}
+	err := c.docker.recover(ctx, c.Object.(*DockerChaos))
+	c.Status = StatusFinished
+	c.endTime = time.Now()
+	c.notifyListeners("finished", nil)
+	return err
+}
+
+func (c *Chaos) pauseDocker(ctx context.Context) error {
+	c.docker.mu.Lock()
+	defer c.docker.mu.Unlock()
+	if c.Status != StatusRunning {
+		return fmt.Errorf("chaos %s is not running, status: %s", c.GetChaosName(), c.Status)
+	}
+	if err := c.docker.recover(ctx, c.Object.(*DockerChaos)); err != nil {
+		return errors.Wrap(err, "could not recover containers to pause the chaos experiment")
+	}
+	c.Status = StatusPaused
+	c.notifyListeners("paused", nil)
+	return nil
+}
+
+func (c *Chaos) resumeDocker(ctx context.Context) error {
+	c.docker.mu.Lock()
+	defer c.docker.mu.Unlock()
+	if c.Status != StatusPaused {
+		return fmt.Errorf("chaos %s is not paused, status: %s", c.GetChaosName(), c.Status)
+	}
+	if err := c.docker.inject(ctx, c.Object.(*DockerChaos)); err != nil {
+		return errors.Wrap(err, "could not inject the chaos experiment again")
+	}
+	c.Status = StatusRunning
+	c.notifyListeners("resumed", nil)
+	return nil
+}
+
+// deleteDocker recovers the experiment and marks it deleted, WaitInjected fails if it wasn't injected yet
+func (c *Chaos) deleteDocker(ctx context.Context) error {
+	c.docker.mu.Lock()
+	// prevents delayed creation
+	c.docker.deleted = true
+	cancel := c.cancelMonitor
+	c.docker.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	err := c.finishDocker(ctx)
+
+	c.docker.mu.Lock()
+	defer c.docker.mu.Unlock()
+	c.Status = StatusDeleted
+	c.injectionDone(errors.New("it was deleted before the injection"))
+	return errors.Wrap(err, "failed to recover docker chaos")
+}
+
+// inject applies the fault to every target container, records of injected containers are updated
+func (d *dockerInjector) inject(ctx context.Context, obj *DockerChaos) error {
+	var peers []string
+	if obj.Spec.Action == DockerNetworkPartitionAction {
+		var err error
+		peers, err = d.containerIPs(ctx, obj.Spec.Targets)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range obj.Spec.Containers {
+		record := obj.record(name)
+		if record.Phase == v1alpha1.Injected {
+			continue
+		}
+		if err := d.injectContainer(ctx, obj, name, peers); err != nil {
+			return errors.Wrapf(err, "failed to inject %s into container %s", obj.Spec.Action, name)
+		}
+		record.Phase = v1alpha1.Injected
+		record.InjectedCount++
+		record.Events = append(record.Events, newRecordEvent(v1alpha1.TypeSucceeded, v1alpha1.Apply))
+	}
+	obj.setConditions(true)
+	return nil
+}
+
+// recover removes the fault from every injected container, it tries all containers and returns the first error
+func (d *dockerInjector) recover(ctx context.Context, obj *DockerChaos) error {
+	var firstErr error
+	for _, record := range obj.Status.Experiment.Records {
+		if record.Phase != v1alpha1.Injected {
+			continue
+		}
+		if err := d.recoverContainer(ctx, obj, record.Id); err != nil {
+			record.Events = append(record.Events, newRecordEvent(v1alpha1.TypeFailed, v1alpha1.Recover))
+			if firstErr == nil {
+				firstErr = errors.Wrapf(err, "failed to recover container %s from %s", record.Id, obj.Spec.Action)
+			}
+			continue
+		}
+		record.Phase = v1alpha1.NotInjected
+		record.RecoveredCount++
+		record.Events = append(record.Events, newRecordEvent(v1alpha1.TypeSucceeded, v1alpha1.Recover))
+	}
+	obj.setConditions(false)
+	return firstErr
+}
+
+func (d *dockerInjector) injectContainer(ctx context.Context, obj *DockerChaos, name string, peers []string) error {
+	spec := obj.Spec
+	switch spec.Action {
+	case DockerPauseAction:
+		_, err := d.client.ContainerPause(ctx, name, client.ContainerPauseOptions{})
+		return err
+	case DockerKillAction:
+		if _, err := d.client.ContainerKill(ctx, name, client.ContainerKillOptions{Signal: spec.Signal}); err != nil {
+			return err
+		}
+		d.killed[name] = true
+		return nil
+	case DockerNetworkDelayAction:
+		cmd := fmt.Sprintf("tc qdisc add dev %s root netem delay %s", spec.networkInterface(), spec.Latency)
+		if spec.Jitter != "" {
+			cmd += " " + spec.Jitter
+		}
+		return d.runNetworkTools(ctx, obj, name, cmd)
+	case DockerNetworkLossAction:
+		return d.runNetworkTools(ctx, obj, name, fmt.Sprintf("tc qdisc add dev %s root netem loss %s%%", spec.networkInterface(), spec.Loss))
+	case DockerNetworkPartitionAction:
+		return d.runNetworkTools(ctx, obj, name, iptablesRules("-I", peers))
+	case DockerCPUStressAction:
+		return d.startStress(ctx, obj, name, []string{"--cpu", strconv.Itoa(spec.Workers), "--cpu-load", strconv.Itoa(spec.Load)})
+	case DockerMemoryStressAction:
+		return d.startStress(ctx, obj, name, []string{"--vm", "1", "--vm-bytes", spec.MemorySize, "--vm-keep"})
+	default:
+		return fmt.Errorf("unsupported docker chaos action: %s", spec.Action)
+	}
+}
+
+func (d *dockerInjector) recoverContainer(ctx context.Context, obj *DockerChaos, name string) error {
+	spec := obj.Spec
+	switch spec.Action {
+	case DockerPauseAction:
+		_, err := d.client.ContainerUnpause(ctx, name, client.ContainerUnpauseOptions{})
+		return err
+	case DockerKillAction:
+		if !d.killed[name] {
+			return nil
+		}
+		if _, err := d.client.ContainerStart(ctx, name, client.ContainerStartOptions{}); err != nil {
+			return err
+		}
+		delete(d.killed, name)
+		return nil
+	case DockerNetworkDelayAction, DockerNetworkLossAction:
+		return d.runNetworkTools(ctx, obj, name, fmt.Sprintf("tc qdisc del dev %s root netem", spec.networkInterface()))
+	case DockerNetworkPartitionAction:
+		peers, err := d.containerIPs(ctx, spec.Targets)
+		if err != nil {
+			return err
+		}
+		return d.runNetworkTools(ctx, obj, name, iptablesRules("-D", peers))
+	case DockerCPUStressAction, DockerMemoryStressAction:
+		id, ok := d.helpers[name]
+		if !ok {
+			return nil
+		}
+		if _, err := d.client.ContainerRemove(ctx, id, client.ContainerRemoveOptions{Force: true}); err != nil {
+			return err
+		}
+		delete(d.helpers, name)
+		return nil
+	default:
+		return fmt.Errorf("unsupported docker chaos action: %s", spec.Action)
+	}
+}
+
+// iptablesRules drops traffic from and to peers, op is -I to add rules or -D to delete them
+func iptablesRules(op string, peers []string) string {
+	rules := make([]string, 0, len(peers)*2)
+	for _, ip := range peers {
+		rules = append(rules,
+			fmt.Sprintf("iptables %s INPUT -s %s -j DROP", op, ip),
+			fmt.Sprintf("iptables %s OUTPUT -d %s -j DROP", op, ip),
+		)
+	}
+	return strings.Join(rules, " && ")
+}
+
+// containerIPs returns addresses of containers in all networks they are connected to
+func (d *dockerInjector) containerIPs(ctx context.Context, names []string) ([]string, error) {
+	var ips []string
+	for _, name := range names {
+		res, err := d.client.ContainerInspect(ctx, name, client.ContainerInspectOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to inspect container %s", name)
+		}
+		if res.Container.NetworkSettings == nil {
+			continue
+		}
+		for _, n := range res.Container.NetworkSettings.Networks {
+			if n != nil && n.IPAddress.IsValid() {
+				ips = append(ips, n.IPAddress.String())
+			}
+		}
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no network addresses found for containers %s, they must be on a network", strings.Join(names, ", "))
+	}
+	return ips, nil
+}
+
+// runNetworkTools runs the command in the network namespace of the target container and waits for it to succeed
+func (d *dockerInjector) runNetworkTools(ctx context.Context, obj *DockerChaos, name, cmd string) error {
+	id, err := d.startHelper(ctx, obj, name, DockerNetworkToolsImage, &container.Config{
+		Entrypoint: []string{"sh", "-c"},
+		Cmd:        []string{cmd},
+	}, &container.HostConfig{
+		NetworkMode: container.NetworkMode("container:" + name),
+		CapAdd:      []string{"NET_ADMIN"},
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = d.client.ContainerRemove(context.Background(), id, client.ContainerRemoveOptions{Force: true})
+	}()
+
+	wait := d.client.ContainerWait(ctx, id, client.ContainerWaitOptions{Condition: container.WaitConditionNotRunning})
+	select {
+	case err := <-wait.Error:
+		return errors.Wrapf(err, "failed to wait for command %q", cmd)
+	case res := <-wait.Result:
+		if res.StatusCode != 0 {
+			return fmt.Errorf("command %q exited with code %d", cmd, res.StatusCode)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// startStress runs stress-ng in the PID namespace of the target container until the fault is recovered
+func (d *dockerInjector) startStress(ctx context.Context, obj *DockerChaos, name string, args []string) error {
+	res, err := d.client.ContainerInspect(ctx, name, client.ContainerInspectOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to inspect container %s", name)
+	}
+	hostConfig := &container.HostConfig{PidMode: container.PidMode("container:" + name)}
+	if res.Container.HostConfig != nil {
+		// compete for the same CPUs as the target
+		hostConfig.CpusetCpus = res.Container.HostConfig.CpusetCpus
+	}
+	hostConfig.CgroupParent, err = d.stressCgroupParent(ctx, res.Container)
+	if err != nil {
+		return err
+	}
+	id, err := d.startHelper(ctx, obj, name, DockerStressImage, &container.Config{Cmd: args}, hostConfig)
+	if err != nil {
+		return err
+	}
+	d.helpers[name] = id
+	return nil
+}
+
+// stressCgroupParent returns the cgroup of the target container, so stress-ng is charged to the target and limited
+// by its CPU and memory limits, as Pumba does. Docker can nest containers only with the cgroupfs driver on cgroup v1,
+// otherwise an empty parent is returned and the stress is host-level
+func (d *dockerInjector) stressCgroupParent(ctx context.Context, target container.InspectResponse) (string, error) {
+	info, err := d.client.Info(ctx, client.InfoOptions{})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get the Docker cgroup driver")
+	}
+	if info.Info.CgroupDriver != "cgroupfs" || info.Info.CgroupVersion != "1" {
+		return "", nil
+	}
+	parent := "/docker" // default cgroup parent of the cgroupfs driver
+	if target.HostConfig != nil && target.HostConfig.CgroupParent != "" {
+		parent = target.HostConfig.CgroupParent
+	}
+	return path.Join(parent, target.ID), nil
+}
+
+func (d *dockerInjector) startHelper(ctx context.Context, obj *DockerChaos, name, image string, cfg *container.Config, hostConfig *container.HostConfig) (string, error) {
+	if err := d.ensureImage(ctx, image); err != nil {
+		return "", err
+	}
+	cfg.Image = image
+	cfg.Labels = map[string]string{helperLabel: obj.GetName()}
+	created, err := d.client.ContainerCreate(ctx, client.ContainerCreateOptions{
+		Config:     cfg,
+		HostConfig: hostConfig,
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create helper container for %s", name)
+	}
+	if _, err := d.client.ContainerStart(ctx, created.ID, client.ContainerStartOptions{}); err != nil {
+		_, _ = d.client.ContainerRemove(context.Background(), created.ID, client.ContainerRemoveOptions{Force: true})
+		return "", errors.Wrapf(err, "failed to start helper container for %s", name)
+	}
+	return created.ID, nil
+}
+
+func (d *dockerInjector) ensureImage(ctx context.Context, image string) error {
+	if _, err := d.client.ImageInspect(ctx, image); err == nil {
+		return nil
+	}
+	res, err := d.client.ImagePull(ctx, image, client.ImagePullOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to pull image %s", image)
+	}
+	defer func() { _ = res.Close() }()
+	return errors.Wrapf(res.Wait(ctx), "failed to pull image %s", image)
+}
+
+func (s DockerChaosSpec) networkInterface() string {
+	if s.Interface == "" {
+		return "eth0"
+	}
+	return s.Interface
+}
+
+// record returns the status record of the container, creating it if needed
+func (in *DockerChaos) record(name string) *v1alpha1.Record {
+	for _, r := range in.Status.Experiment.Records {
+		if r.Id == name {
+			return r
+		}
+	}
+	r := &v1alpha1.Record{Id: name, SelectorKey: ".", Phase: v1alpha1.NotInjected}
+	in.Status.Experiment.Records = append(in.Status.Experiment.Records, r)
+	return r
+}
+
+// setConditions mirrors Chaos Mesh conditions, so status checks work the same way for both backends
+func (in *DockerChaos) setConditions(injected bool) {
+	allInjected, allRecovered := corev1.ConditionTrue, corev1.ConditionTrue
+	for _, r := range in.Status.Experiment.Records {
+		if r.Phase == v1alpha1.Injected {
+			allRecovered = corev1.ConditionFalse
+		} else {
+			allInjected = corev1.ConditionFalse
+		}
+	}
+	desired := v1alpha1.StoppedPhase
+	if injected {
+		desired = v1alpha1.RunningPhase
+	}
+	in.Status.Experiment.DesiredPhase = desired
+	in.Status.Conditions = []v1alpha1.ChaosCondition{
+		{Type: v1alpha1.ConditionSelected, Status: corev1.ConditionTrue},
+		{Type: v1alpha1.ConditionAllInjected, Status: allInjected},
+		{Type: v1alpha1.ConditionAllRecovered, Status: allRecovered},
+		{Type: v1alpha1.ConditionPaused, Status: corev1.ConditionFalse},
+	}
+}
+
+func newRecordEvent(eventType v1alpha1.RecordEventType, operation v1alpha1.RecordEventOperation) v1alpha1.RecordEvent {
+	return v1alpha1.RecordEvent{
+		Type:      eventType,
+		Operation: operation,
+		Timestamp: &metav1.Time{Time: time.Now()},
+	}
+}
@@ -0,0 +1,307 @@
+package wasp
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chaos-mesh/chaos-mesh/api/v1alpha1"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/network"
+	"github.com/moby/moby/api/types/system"
+	"github.com/moby/moby/client"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDockerClient records calls, methods which aren't overridden panic
+type fakeDockerClient struct {
+	client.APIClient
+
+	mu            sync.Mutex
+	calls         []string
+	commands      []string
+	cgroupParents []string
+	failOn        string
+	// cgroupDriver and cgroupVersion are reported by Info
+	cgroupDriver  string
+	cgroupVersion string
+}
+
+func (f *fakeDockerClient) record(call string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call)
+	if call == f.failOn {
+		return errors.New("fake failure")
+	}
+	return nil
+}
+
+func (f *fakeDockerClient) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+func (f *fakeDockerClient) ContainerPause(_ context.Context, name string, _ client.ContainerPauseOptions) (client.ContainerPauseResult, error) {
+	return client.ContainerPauseResult{}, f.record("pause " + name)
+}
+
+func (f *fakeDockerClient) ContainerUnpause(_ context.Context, name string, _ client.ContainerUnpauseOptions) (client.ContainerUnpauseResult, error) {
+	return client.ContainerUnpauseResult{}, f.record("unpause " + name)
+}
+
+func (f *fakeDockerClient) ContainerKill(_ context.Context, name string, _ client.ContainerKillOptions) (client.ContainerKillResult, error) {
+	return client.ContainerKillResult{}, f.record("kill " + name)
+}
+
+func (f *fakeDockerClient) ContainerStart(_ context.Context, name string, _ client.ContainerStartOptions) (client.ContainerStartResult, error) {
+	return client.ContainerStartResult{}, f.record("start " + name)
+}
+
+func (f *fakeDockerClient) ContainerInspect(_ context.Context, name string, _ client.ContainerInspectOptions) (client.ContainerInspectResult, error) {
+	res := client.ContainerInspectResult{Container: container.InspectResponse{
+		ID: name,
+		NetworkSettings: &container.NetworkSettings{Networks: map[string]*network.EndpointSettings{
+			"ctf": {IPAddress: netip.MustParseAddr("10.0.0.2")},
+		}},
+	}}
+	return res, f.record("inspect " + name)
+}
+
+func (f *fakeDockerClient) ImageInspect(_ context.Context, image string, _ ...client.ImageInspectOption) (client.ImageInspectResult, error) {
+	return client.ImageInspectResult{}, f.record("image " + image)
+}
+
+func (f *fakeDockerClient) ContainerCreate(_ context.Context, options client.ContainerCreateOptions) (client.ContainerCreateResult, error) {
+	f.mu.Lock()
+	f.commands = append(f.commands, append(options.Config.Entrypoint, options.Config.Cmd...)...)
+	f.cgroupParents = append(f.cgroupParents, options.HostConfig.CgroupParent)
+	f.mu.Unlock()
+	return client.ContainerCreateResult{ID: "helper"}, f.record("create " + string(options.HostConfig.NetworkMode))
+}
+
+func (f *fakeDockerClient) Info(_ context.Context, _ client.InfoOptions) (client.SystemInfoResult, error) {
+	res := client.SystemInfoResult{Info: system.Info{CgroupDriver: f.cgroupDriver, CgroupVersion: f.cgroupVersion}}
+	return res, f.record("info")
+}
+
+func (f *fakeDockerClient) ContainerWait(_ context.Context, _ string, _ client.ContainerWaitOptions) client.ContainerWaitResult {
+	result := make(chan container.WaitResponse, 1)
+	result <- container.WaitResponse{StatusCode: 0}
+	return client.ContainerWaitResult{Result: result, Error: make(chan error)}
+}
+
+func (f *fakeDockerClient) ContainerRemove(_ context.Context, id string, _ client.ContainerRemoveOptions) (client.ContainerRemoveResult, error) {
+	return client.ContainerRemoveResult{}, f.record("remove " + id)
+}
+
+type recordingListener struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (l *recordingListener) add(event string, chaos Chaos) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event+" "+string(chaos.Status))
+}
+
+func (l *recordingListener) Events() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.events...)
+}
+
+func (l *recordingListener) OnChaosCreated(chaos Chaos) { l.add("created", chaos) }
+func (l *recordingListener) OnChaosCreationFailed(chaos Chaos, _ error) {
+	l.add("creation_failed", chaos)
+}
+func (l *recordingListener) OnChaosStarted(chaos Chaos)       { l.add("started", chaos) }
+func (l *recordingListener) OnChaosPaused(chaos Chaos)        { l.add("paused", chaos) }
+func (l *recordingListener) OnChaosEnded(chaos Chaos)         { l.add("ended", chaos) }
+func (l *recordingListener) OnChaosStatusUnknown(chaos Chaos) { l.add("unknown", chaos) }
+
+func TestDockerChaos(t *testing.T) {
+	l := zerolog.Nop()
+
+	t.Run("pause until duration elapses", func(t *testing.T) {
+		fake := &fakeDockerClient{}
+		listener := &recordingListener{}
+		chaos, err := NewDockerRunner(l, fake, listener).RunContainerPause(context.Background(), ContainerPauseCfg{
+			Containers:        []string{"node1", "node2"},
+			InjectionDuration: 100 * time.Millisecond,
+		})
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			return len(listener.Events()) == 3
+		}, 5*time.Second, 10*time.Millisecond)
+		require.Equal(t, []string{"created created", "started running", "ended finished"}, listener.Events())
+		require.Equal(t, []string{"pause node1", "pause node2", "unpause node1", "unpause node2"}, fake.Calls())
+
+		require.Equal(t, TypeDockerChaos, chaos.GetChaosTypeStr())
+		require.False(t, chaos.GetStartTime().IsZero())
+		require.True(t, chaos.GetEndTime().After(chaos.GetStartTime()))
+		experiment, err := chaos.GetExperimentStatus()
+		require.NoError(t, err)
+		require.Len(t, experiment.Records, 2)
+		for _, r := range experiment.Records {
+			require.Equal(t, v1alpha1.NotInjected, r.Phase)
+			require.Equal(t, 1, r.InjectedCount)
+			require.Equal(t, 1, r.RecoveredCount)
+		}
+		events, err := chaos.GetChaosEvents()
+		require.NoError(t, err)
+		require.Empty(t, events.Items)
+
+		// already finished
+		require.NoError(t, chaos.Delete(context.Background()))
+		require.Len(t, listener.Events(), 3)
+	})
+
+	t.Run("pause, resume and delete network delay", func(t *testing.T) {
+		fake := &fakeDockerClient{}
+		listener := &recordingListener{}
+		chaos, err := NewDockerRunner(l, fake, listener).RunContainerDelay(context.Background(), ContainerDelayCfg{
+			Containers:        []string{"node1"},
+			Latency:           200 * time.Millisecond,
+			InjectionDuration: time.Hour,
+		})
+		require.NoError(t, err)
+		require.Eventually(t, func() bool {
+			return len(listener.Events()) == 2
+		}, 5*time.Second, 10*time.Millisecond)
+
+		require.NoError(t, chaos.Pause(context.Background()))
+		require.Error(t, chaos.Pause(context.Background()), "already paused")
+		require.NoError(t, chaos.Resume(context.Background()))
+		require.NoError(t, chaos.Delete(context.Background()))
+
+		require.Equal(t, []string{"created created", "started running", "paused paused", "started running", "ended finished"}, listener.Events())
+		require.Equal(t, []string{
+			"sh", "-c", "tc qdisc add dev eth0 root netem delay 200ms",
+			"sh", "-c", "tc qdisc del dev eth0 root netem",
+			"sh", "-c", "tc qdisc add dev eth0 root netem delay 200ms",
+			"sh", "-c", "tc qdisc del dev eth0 root netem",
+		}, fake.commands)
+		require.Contains(t, fake.Calls(), "create container:node1")
+	})
+
+	t.Run("partition", func(t *testing.T) {
+		fake := &fakeDockerClient{}
+		listener := &recordingListener{}
+		chaos, err := NewDockerRunner(l, fake, listener).RunContainerPartition(context.Background(), ContainerPartitionCfg{
+			ContainersFrom:    []string{"node1"},
+			ContainersTo:      []string{"node2"},
+			InjectionDuration: time.Hour,
+		})
+		require.NoError(t, err)
+		require.Eventually(t, func() bool {
+			return len(listener.Events()) == 2
+		}, 5*time.Second, 10*time.Millisecond)
+		require.NoError(t, chaos.Delete(context.Background()))
+		require.Equal(t, []string{
+			"sh", "-c", "iptables -I INPUT -s 10.0.0.2 -j DROP && iptables -I OUTPUT -d 10.0.0.2 -j DROP",
+			"sh", "-c", "iptables -D INPUT -s 10.0.0.2 -j DROP && iptables -D OUTPUT -d 10.0.0.2 -j DROP",
+		}, fake.commands)
+	})
+
+	t.Run("stress runs in the target cgroup", func(t *testing.T) {
+		for _, tc := range []struct {
+			driver, version, parent string
+		}{
+			{driver: "cgroupfs", version: "1", parent: "/docker/node1"},
+			{driver: "systemd", version: "2", parent: ""},
+			{driver: "cgroupfs", version: "2", parent: ""},
+		} {
+			fake := &fakeDockerClient{cgroupDriver: tc.driver, cgroupVersion: tc.version}
+			listener := &recordingListener{}
+			chaos, err := NewDockerRunner(l, fake, listener).RunContainerStressMemory(context.Background(), ContainerMemoryStressCfg{
+				Containers:        []string{"node1"},
+				Size:              "256M",
+				InjectionDuration: time.Hour,
+			})
+			require.NoError(t, err)
+			require.NoError(t, chaos.WaitInjected(context.Background()))
+			require.NoError(t, chaos.Delete(context.Background()))
+			require.Equal(t, []string{"--vm", "1", "--vm-bytes", "256M", "--vm-keep"}, fake.commands)
+			require.Equal(t, []string{tc.parent}, fake.cgroupParents, "%s cgroup v%s", tc.driver, tc.version)
+			require.Contains(t, fake.Calls(), "remove helper")
+		}
+	})
+
+	t.Run("kill restarts containers", func(t *testing.T) {
+		fake := &fakeDockerClient{}
+		listener := &recordingListener{}
+		chaos, err := NewDockerRunner(l, fake, listener).RunContainerKill(context.Background(), ContainerKillCfg{
+			Containers:        []string{"node1"},
+			InjectionDuration: time.Hour,
+		})
+		require.NoError(t, err)
+		require.Eventually(t, func() bool {
+			return len(listener.Events()) == 2
+		}, 5*time.Second, 10*time.Millisecond)
+		require.NoError(t, chaos.Delete(context.Background()))
+		require.Equal(t, []string{"kill node1", "start node1"}, fake.Calls())
+	})
+
+	t.Run("creation failure recovers injected containers", func(t *testing.T) {
+		fake := &fakeDockerClient{failOn: "pause node2"}
+		listener := &recordingListener{}
+		_, err := NewDockerRunner(l, fake, listener).RunContainerPause(context.Background(), ContainerPauseCfg{
+			Containers:        []string{"node1", "node2"},
+			InjectionDuration: time.Hour,
+		})
+		require.NoError(t, err)
+		require.Eventually(t, func() bool {
+			return len(listener.Events()) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+		require.Equal(t, []string{"creation_failed creation_failed"}, listener.Events())
+		require.Equal(t, []string{"pause node1", "pause node2", "unpause node1"}, fake.Calls())
+	})
+
+	t.Run("delete before delayed creation", func(t *testing.T) {
+		fake := &fakeDockerClient{}
+		listener := &recordingListener{}
+		chaos, err := NewDockerRunner(l, fake, listener).RunContainerPause(context.Background(), ContainerPauseCfg{
+			Containers:            []string{"node1"},
+			InjectionDuration:     time.Hour,
+			ExperimentCreateDelay: 50 * time.Millisecond,
+		})
+		require.NoError(t, err)
+		require.NoError(t, chaos.Delete(context.Background()))
+
+		// fails right away instead of waiting for the injection
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		start := time.Now()
+		require.ErrorContains(t, chaos.WaitInjected(ctx), "deleted before the injection")
+		require.Less(t, time.Since(start), time.Second)
+		require.Equal(t, StatusDeleted, chaos.Status)
+
+		time.Sleep(100 * time.Millisecond)
+		require.Empty(t, fake.Calls())
+		require.Empty(t, listener.Events())
+	})
+
+	t.Run("invalid specs", func(t *testing.T) {
+		runner := NewDockerRunner(l, &fakeDockerClient{})
+		_, err := runner.RunContainerPause(context.Background(), ContainerPauseCfg{InjectionDuration: time.Minute})
+		require.ErrorContains(t, err, "at least one container is required")
+		_, err = runner.RunContainerDelay(context.Background(), ContainerDelayCfg{Containers: []string{"node1"}, Latency: time.Second, InjectionDuration: time.Minute})
+		require.ErrorContains(t, err, "less than 500ms")
+		_, err = runner.RunContainerLoss(context.Background(), ContainerLossCfg{Containers: []string{"node1"}, Loss: "101", InjectionDuration: time.Minute})
+		require.ErrorContains(t, err, "loss should be between 1 and 100")
+		_, err = runner.RunContainerPartition(context.Background(), ContainerPartitionCfg{ContainersFrom: []string{"node1"}, InjectionDuration: time.Minute})
+		require.ErrorContains(t, err, "at least one target container is required")
+		_, err = runner.RunContainerStressCPU(context.Background(), ContainerCPUStressCfg{Containers: []string{"node1"}, Workers: 1, InjectionDuration: time.Minute})
+		require.ErrorContains(t, err, "CPU load should be between 1 and 100")
+		_, err = runner.RunContainerStressMemory(context.Background(), ContainerMemoryStressCfg{Containers: []string{"node1"}})
+		require.ErrorContains(t, err, "duration should be positive")
+	})
+}
@@ -0,0 +1,189 @@
+package wasp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moby/moby/client"
+	"github.com/rs/zerolog"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DockerChaosRunner runs chaos experiments on local Docker containers, ex.: CTF environments,
+// experiments emit the same listener events as NamespaceScopedChaosRunner experiments
+type DockerChaosRunner struct {
+	l         zerolog.Logger
+	c         client.APIClient
+	listeners []ChaosListener
+}
+
+// NewDockerRunner creates a new Docker chaos runner, listeners are added to every experiment next to the default logger
+func NewDockerRunner(l zerolog.Logger, c client.APIClient, listeners ...ChaosListener) *DockerChaosRunner {
+	return &DockerChaosRunner{
+		l:         l,
+		c:         c,
+		listeners: listeners,
+	}
+}
+
+type ContainerDelayCfg struct {
+	Containers            []string
+	Description           string
+	Interface             string
+	Latency               time.Duration
+	Jitter                time.Duration
+	InjectionDuration     time.Duration
+	ExperimentCreateDelay time.Duration
+}
+
+// RunContainerDelay adds network latency to the containers
+func (cr *DockerChaosRunner) RunContainerDelay(ctx context.Context, cfg ContainerDelayCfg) (*Chaos, error) {
+	spec := DockerChaosSpec{
+		Action:     DockerNetworkDelayAction,
+		Containers: cfg.Containers,
+		Duration:   cfg.InjectionDuration.String(),
+		Interface:  cfg.Interface,
+		Latency:    cfg.Latency.String(),
+	}
+	if cfg.Jitter > 0 {
+		spec.Jitter = cfg.Jitter.String()
+	}
+	return cr.run(ctx, "delay", cfg.Description, cfg.ExperimentCreateDelay, spec)
+}
+
+type ContainerLossCfg struct {
+	Containers            []string
+	Description           string
+	Interface             string
+	Loss                  string // 1-100
+	InjectionDuration     time.Duration
+	ExperimentCreateDelay time.Duration
+}
+
+// RunContainerLoss drops a percentage of network packets of the containers
+func (cr *DockerChaosRunner) RunContainerLoss(ctx context.Context, cfg ContainerLossCfg) (*Chaos, error) {
+	return cr.run(ctx, "loss", cfg.Description, cfg.ExperimentCreateDelay, DockerChaosSpec{
+		Action:     DockerNetworkLossAction,
+		Containers: cfg.Containers,
+		Duration:   cfg.InjectionDuration.String(),
+		Interface:  cfg.Interface,
+		Loss:       cfg.Loss,
+	})
+}
+
+type ContainerPartitionCfg struct {
+	ContainersFrom        []string
+	ContainersTo          []string
+	Description           string
+	InjectionDuration     time.Duration
+	ExperimentCreateDelay time.Duration
+}
+
+// RunContainerPartition drops all traffic between two groups of containers, containers must be on a network
+func (cr *DockerChaosRunner) RunContainerPartition(ctx context.Context, cfg ContainerPartitionCfg) (*Chaos, error) {
+	return cr.run(ctx, "partition", cfg.Description, cfg.ExperimentCreateDelay, DockerChaosSpec{
+		Action:     DockerNetworkPartitionAction,
+		Containers: cfg.ContainersFrom,
+		Targets:    cfg.ContainersTo,
+		Duration:   cfg.InjectionDuration.String(),
+	})
+}
+
+type ContainerPauseCfg struct {
+	Containers            []string
+	Description           string
+	InjectionDuration     time.Duration
+	ExperimentCreateDelay time.Duration
+}
+
+// RunContainerPause pauses the containers
+func (cr *DockerChaosRunner) RunContainerPause(ctx context.Context, cfg ContainerPauseCfg) (*Chaos, error) {
+	return cr.run(ctx, "pause", cfg.Description, cfg.ExperimentCreateDelay, DockerChaosSpec{
+		Action:     DockerPauseAction,
+		Containers: cfg.Containers,
+		Duration:   cfg.InjectionDuration.String(),
+	})
+}
+
+type ContainerKillCfg struct {
+	Containers            []string
+	Description           string
+	Signal                string // defaults to SIGKILL
+	InjectionDuration     time.Duration
+	ExperimentCreateDelay time.Duration
+}
+
+// RunContainerKill kills the containers and starts them again after the injection duration
+func (cr *DockerChaosRunner) RunContainerKill(ctx context.Context, cfg ContainerKillCfg) (*Chaos, error) {
+	return cr.run(ctx, "kill", cfg.Description, cfg.ExperimentCreateDelay, DockerChaosSpec{
+		Action:     DockerKillAction,
+		Containers: cfg.Containers,
+		Duration:   cfg.InjectionDuration.String(),
+		Signal:     cfg.Signal,
+	})
+}
+
+type ContainerCPUStressCfg struct {
+	Containers            []string
+	Description           string
+	Workers               int
+	LoadPercentage        int // 0-100
+	InjectionDuration     time.Duration
+	ExperimentCreateDelay time.Duration
+}
+
+// RunContainerStressCPU runs CPU workers next to the containers. Stress runs in a separate container sharing
+// the PID namespace and CPU set of the target, so it competes for the same CPUs but isn't limited by the target's quota
+func (cr *DockerChaosRunner) RunContainerStressCPU(ctx context.Context, cfg ContainerCPUStressCfg) (*Chaos, error) {
+	return cr.run(ctx, "cpu-stress", cfg.Description, cfg.ExperimentCreateDelay, DockerChaosSpec{
+		Action:     DockerCPUStressAction,
+		Containers: cfg.Containers,
+		Duration:   cfg.InjectionDuration.String(),
+		Workers:    cfg.Workers,
+		Load:       cfg.LoadPercentage,
+	})
+}
+
+type ContainerMemoryStressCfg struct {
+	Containers            []string
+	Description           string
+	Size                  string // ex.: 256M
+	InjectionDuration     time.Duration
+	ExperimentCreateDelay time.Duration
+}
+
+// RunContainerStressMemory allocates memory next to the containers, see RunContainerStressCPU
+func (cr *DockerChaosRunner) RunContainerStressMemory(ctx context.Context, cfg ContainerMemoryStressCfg) (*Chaos, error) {
+	return cr.run(ctx, "memory-stress", cfg.Description, cfg.ExperimentCreateDelay, DockerChaosSpec{
+		Action:     DockerMemoryStressAction,
+		Containers: cfg.Containers,
+		Duration:   cfg.InjectionDuration.String(),
+		MemorySize: cfg.Size,
+	})
+}
+
+func (cr *DockerChaosRunner) run(ctx context.Context, prefix, description string, delay time.Duration, spec DockerChaosSpec) (*Chaos, error) {
+	experiment, err := NewDockerChaos(DockerChaosOpts{
+		Description: description,
+		DelayCreate: delay,
+		Object: &DockerChaos{
+			TypeMeta: metav1.TypeMeta{
+				Kind: TypeDockerChaos,
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: fmt.Sprintf("%s-%s", prefix, uuid.NewString()[0:5]),
+			},
+			Spec: spec,
+		},
+		Listeners: append(defaultListeners(cr.l), cr.listeners...),
+		Logger:    &cr.l,
+		Client:    cr.c,
+	})
+	if err != nil {
+		return nil, err
+	}
+	experiment.Create(ctx)
+	return experiment, nil
+}
@@ -0,0 +1,184 @@
+package wasp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+
+	"github.com/smartcontractkit/chainlink-testing-framework/lib/client"
+)
+
+// Probe checks a part of the steady-state hypothesis of the system, ex.: a health endpoint responds with 200
+type Probe interface {
+	// Name identifies the probe in results
+	Name() string
+	// Check returns an error if the system isn't in the expected state
+	Check(ctx context.Context) error
+}
+
+// HTTPProbe checks that the URL responds with the expected status code
+type HTTPProbe struct {
+	ProbeName      string
+	URL            string
+	Method         string // defaults to GET
+	ExpectedStatus int    // defaults to 200
+	Client         *http.Client
+}
+
+// NewHTTPProbe creates a probe checking that GET of the URL responds with 200
+func NewHTTPProbe(name, url string) *HTTPProbe {
+	return &HTTPProbe{
+		ProbeName:      name,
+		URL:            url,
+		Method:         http.MethodGet,
+		ExpectedStatus: http.StatusOK,
+		Client:         &http.Client{},
+	}
+}
+
+func (p *HTTPProbe) Name() string {
+	return p.ProbeName
+}
+
+func (p *HTTPProbe) Check(ctx context.Context) error {
+	method, expected, c := p.Method, p.ExpectedStatus, p.Client
+	if method == "" {
+		method = http.MethodGet
+	}
+	if expected == 0 {
+		expected = http.StatusOK
+	}
+	if c == nil {
+		c = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, method, p.URL, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create request to %s", p.URL)
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "request to %s failed", p.URL)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != expected {
+		return fmt.Errorf("%s %s responded with %d, expected %d", method, p.URL, resp.StatusCode, expected)
+	}
+	return nil
+}
+
+// ThresholdOperator compares a value with a threshold
+type ThresholdOperator string
+
+const (
+	LessThan           ThresholdOperator = "<"
+	LessThanOrEqual    ThresholdOperator = "<="
+	GreaterThan        ThresholdOperator = ">"
+	GreaterThanOrEqual ThresholdOperator = ">="
+	Equal              ThresholdOperator = "=="
+)
+
+func (o ThresholdOperator) compare(value, threshold float64) (bool, error) {
+	switch o {
+	case LessThan:
+		return value < threshold, nil
+	case LessThanOrEqual:
+		return value <= threshold, nil
+	case GreaterThan:
+		return value > threshold, nil
+	case GreaterThanOrEqual:
+		return value >= threshold, nil
+	case Equal:
+		return value == threshold, nil
+	default:
+		return false, fmt.Errorf("unknown threshold operator %q", o)
+	}
+}
+
+// PrometheusProbe checks that the instant query result compares with the threshold, every series of a vector must match,
+// ex.: error rate of all services is below 1%
+type PrometheusProbe struct {
+	ProbeName string
+	Query     string
+	Operator  ThresholdOperator
+	Threshold float64
+	API       v1.API
+}
+
+// NewPrometheusProbe creates a probe querying Prometheus at the URL
+func NewPrometheusProbe(name, url, query string, operator ThresholdOperator, threshold float64) (*PrometheusProbe, error) {
+	if _, err := operator.compare(0, threshold); err != nil {
+		return nil, err
+	}
+	c, err := client.NewPrometheusClient(url)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create Prometheus client")
+	}
+	return &PrometheusProbe{
+		ProbeName: name,
+		Query:     query,
+		Operator:  operator,
+		Threshold: threshold,
+		API:       c.API,
+	}, nil
+}
+
+func (p *PrometheusProbe) Name() string {
+	return p.ProbeName
+}
+
+func (p *PrometheusProbe) Check(ctx context.Context) error {
+	value, _, err := p.API.Query(ctx, p.Query, time.Now())
+	if err != nil {
+		return errors.Wrapf(err, "failed to execute query %s", p.Query)
+	}
+	var samples []float64
+	switch v := value.(type) {
+	case *model.Scalar:
+		samples = append(samples, float64(v.Value))
+	case model.Vector:
+		for _, s := range v {
+			samples = append(samples, float64(s.Value))
+		}
+	default:
+		return fmt.Errorf("query %s returned %s, only scalars and vectors are supported", p.Query, value.Type())
+	}
+	if len(samples) == 0 {
+		return fmt.Errorf("query %s returned no data", p.Query)
+	}
+	for _, s := range samples {
+		ok, err := p.Operator.compare(s, p.Threshold)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("query %s returned %f, expected %s %f", p.Query, s, p.Operator, p.Threshold)
+		}
+	}
+	return nil
+}
+
+// FuncProbe checks the system with a custom function
+type FuncProbe struct {
+	ProbeName string
+	Fn        func(ctx context.Context) error
+}
+
+// NewFuncProbe creates a probe calling the function
+func NewFuncProbe(name string, fn func(ctx context.Context) error) *FuncProbe {
+	return &FuncProbe{ProbeName: name, Fn: fn}
+}
+
+func (p *FuncProbe) Name() string {
+	return p.ProbeName
+}
+
+func (p *FuncProbe) Check(ctx context.Context) error {
+	return p.Fn(ctx)
+}
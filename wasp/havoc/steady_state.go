@@ -0,0 +1,244 @@
+package wasp
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+const (
+	DefaultProbeInterval = 5 * time.Second
+	DefaultProbeTimeout  = 10 * time.Second
+)
+
+// ProbePhase is the part of the experiment a probe was checked in
+type ProbePhase string
+
+const (
+	ProbePhaseBefore ProbePhase = "before"
+	ProbePhaseDuring ProbePhase = "during"
+	ProbePhaseAfter  ProbePhase = "after"
+)
+
+// SteadyStateHypothesis defines probes checked around a chaos experiment
+type SteadyStateHypothesis struct {
+	// Before are checked once before the experiment, it isn't created if any of them fails
+	Before []Probe
+	// During are checked every Interval while the experiment is running
+	During []Probe
+	// After are checked when the experiment is deleted, every Interval until they pass or RecoveryTimeout elapses
+	After []Probe
+	// Interval between checks, defaults to DefaultProbeInterval
+	Interval time.Duration
+	// RecoveryTimeout is how long the system has to pass After probes, they are checked only once if empty
+	RecoveryTimeout time.Duration
+	// ProbeTimeout limits a single check, defaults to DefaultProbeTimeout
+	ProbeTimeout time.Duration
+}
+
+// ProbeResult is the outcome of a single check
+type ProbeResult struct {
+	Probe string     `json:"probe"`
+	Phase ProbePhase `json:"phase"`
+	Time  time.Time  `json:"time"`
+	Error string     `json:"error,omitempty"`
+}
+
+// Passed checks whether the check succeeded
+func (r ProbeResult) Passed() bool {
+	return r.Error == ""
+}
+
+// SteadyStateResult is the outcome of a steady-state experiment
+type SteadyStateResult struct {
+	Name string `json:"name"`
+	// Experiment is the name of the chaos object, empty if the experiment wasn't created
+	Experiment string        `json:"experiment,omitempty"`
+	Passed     bool          `json:"passed"`
+	ChaosStart time.Time     `json:"chaos_start"`
+	ChaosEnd   time.Time     `json:"chaos_end"`
+	Probes     []ProbeResult `json:"probes"`
+	// Recovery is how long After probes took to pass once the experiment was deleted
+	Recovery time.Duration `json:"recovery"`
+}
+
+// Failures returns failed checks
+func (r *SteadyStateResult) Failures() []ProbeResult {
+	var failures []ProbeResult
+	for _, p := range r.Probes {
+		if !p.Passed() {
+			failures = append(failures, p)
+		}
+	}
+	return failures
+}
+
+// Err returns failed checks as an error, or nil if the hypothesis holds
+func (r *SteadyStateResult) Err() error {
+	var errs []error
+	for _, f := range r.Failures() {
+		errs = append(errs, fmt.Errorf("[%s] probe %s failed at %s: %s", f.Phase, f.Probe, f.Time.Format(time.RFC3339), f.Error))
+	}
+	return goerrors.Join(errs...)
+}
+
+// SteadyStateExperiment runs a chaos experiment and verifies the steady-state hypothesis before, during and after it
+type SteadyStateExperiment struct {
+	Name       string
+	Hypothesis SteadyStateHypothesis
+	// Experiment creates the experiment, ex.: a NamespaceScopedChaosRunner or DockerChaosRunner method call
+	Experiment func(ctx context.Context) (*Chaos, error)
+	l          zerolog.Logger
+}
+
+// NewSteadyStateExperiment creates a new steady-state experiment
+func NewSteadyStateExperiment(l zerolog.Logger, name string, hypothesis SteadyStateHypothesis, experiment func(ctx context.Context) (*Chaos, error)) (*SteadyStateExperiment, error) {
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	if experiment == nil {
+		return nil, errors.New("experiment is required")
+	}
+	if len(hypothesis.Before)+len(hypothesis.During)+len(hypothesis.After) == 0 {
+		return nil, errors.New("at least one probe is required")
+	}
+	if hypothesis.Interval < 0 || hypothesis.RecoveryTimeout < 0 || hypothesis.ProbeTimeout < 0 {
+		return nil, errors.New("interval and timeouts can't be negative")
+	}
+	if hypothesis.Interval == 0 {
+		hypothesis.Interval = DefaultProbeInterval
+	}
+	if hypothesis.ProbeTimeout == 0 {
+		hypothesis.ProbeTimeout = DefaultProbeTimeout
+	}
+	return &SteadyStateExperiment{
+		Name:       name,
+		Hypothesis: hypothesis,
+		Experiment: experiment,
+		l:          l,
+	}, nil
+}
+
+// Run checks Before probes, creates the experiment, waits until it's injected, checks During probes until the experiment's
+// duration elapses, deletes the experiment and checks After probes. Failed probes are reported in the result, errors are returned
+// if the experiment couldn't be run or injected, the result isn't passed then
+func (e *SteadyStateExperiment) Run(ctx context.Context) (res *SteadyStateResult, err error) {
+	h := e.Hypothesis
+	res = &SteadyStateResult{Name: e.Name}
+	defer func() {
+		res.Passed = err == nil && len(res.Failures()) == 0
+		e.l.Info().
+			Str("Name", e.Name).
+			Bool("Passed", res.Passed).
+			Int("Failures", len(res.Failures())).
+			Msg("Steady-state experiment finished")
+	}()
+
+	for _, p := range h.Before {
+		res.Probes = append(res.Probes, e.check(ctx, ProbePhaseBefore, p))
+	}
+	if len(res.Failures()) > 0 {
+		e.l.Warn().Str("Name", e.Name).Msg("System isn't in the steady state, skipping the experiment")
+		return res, nil
+	}
+
+	chaos, err := e.Experiment(ctx)
+	if err != nil {
+		return res, errors.Wrapf(err, "failed to create experiment %s", e.Name)
+	}
+	res.Experiment = chaos.GetChaosName()
+	duration, err := chaos.GetChaosDuration()
+	if err != nil {
+		_ = chaos.Delete(context.Background())
+		return res, errors.Wrapf(err, "experiment %s must have a duration", e.Name)
+	}
+
+	injectCtx, cancelInject := context.WithTimeout(ctx, chaos.DelayCreate+duration)
+	err = chaos.WaitInjected(injectCtx)
+	cancelInject()
+	if err != nil {
+		_ = chaos.Delete(context.Background())
+		return res, err
+	}
+	res.ChaosStart = chaos.GetStartTime()
+
+	end := time.NewTimer(time.Until(res.ChaosStart.Add(duration)))
+	defer end.Stop()
+	ticker := time.NewTicker(h.Interval)
+	defer ticker.Stop()
+during:
+	for {
+		select {
+		case <-ticker.C:
+			for _, p := range h.During {
+				res.Probes = append(res.Probes, e.check(ctx, ProbePhaseDuring, p))
+			}
+		case <-end.C:
+			break during
+		case <-ctx.Done():
+			_ = chaos.Delete(context.Background())
+			return res, ctx.Err()
+		}
+	}
+
+	if err := chaos.Delete(ctx); err != nil {
+		return res, errors.Wrapf(err, "failed to delete experiment %s", e.Name)
+	}
+	res.ChaosEnd = time.Now()
+
+	res.Probes = append(res.Probes, e.recover(ctx, res.ChaosEnd)...)
+	for _, p := range res.Probes {
+		if p.Phase == ProbePhaseAfter && p.Passed() && p.Time.Sub(res.ChaosEnd) > res.Recovery {
+			res.Recovery = p.Time.Sub(res.ChaosEnd)
+		}
+	}
+	return res, nil
+}
+
+// recover checks After probes until all of them pass or the recovery timeout elapses, only the last check of every probe is recorded
+func (e *SteadyStateExperiment) recover(ctx context.Context, chaosEnd time.Time) []ProbeResult {
+	h := e.Hypothesis
+	deadline := chaosEnd.Add(h.RecoveryTimeout)
+	pending := h.After
+	var results []ProbeResult
+	for {
+		var failed []Probe
+		var failures []ProbeResult
+		for _, p := range pending {
+			r := e.check(ctx, ProbePhaseAfter, p)
+			if r.Passed() {
+				results = append(results, r)
+			} else {
+				failed = append(failed, p)
+				failures = append(failures, r)
+			}
+		}
+		if len(failed) == 0 || !time.Now().Add(h.Interval).Before(deadline) || ctx.Err() != nil {
+			return append(results, failures...)
+		}
+		pending = failed
+		select {
+		case <-time.After(h.Interval):
+		case <-ctx.Done():
+		}
+	}
+}
+
+func (e *SteadyStateExperiment) check(ctx context.Context, phase ProbePhase, p Probe) ProbeResult {
+	checkCtx, cancel := context.WithTimeout(ctx, e.Hypothesis.ProbeTimeout)
+	defer cancel()
+	r := ProbeResult{Probe: p.Name(), Phase: phase}
+	err := p.Check(checkCtx)
+	r.Time = time.Now()
+	if err != nil {
+		r.Error = err.Error()
+		e.l.Warn().Str("Name", e.Name).Str("Probe", p.Name()).Str("Phase", string(phase)).Err(err).Msg("Probe failed")
+	} else {
+		e.l.Debug().Str("Name", e.Name).Str("Probe", p.Name()).Str("Phase", string(phase)).Msg("Probe passed")
+	}
+	return r
+}
@@ -0,0 +1,180 @@
+package wasp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSteadyStateExperiment(t *testing.T) {
+	l := zerolog.Nop()
+	var healthy atomic.Bool
+	healthy.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	pause := func(fake *fakeDockerClient) func(ctx context.Context) (*Chaos, error) {
+		return func(ctx context.Context) (*Chaos, error) {
+			return NewDockerRunner(l, fake).RunContainerPause(ctx, ContainerPauseCfg{
+				Containers:        []string{"node1"},
+				InjectionDuration: 300 * time.Millisecond,
+			})
+		}
+	}
+
+	t.Run("hypothesis holds", func(t *testing.T) {
+		fake := &fakeDockerClient{}
+		var duringChecks atomic.Int32
+		e, err := NewSteadyStateExperiment(l, "pause", SteadyStateHypothesis{
+			Before: []Probe{NewHTTPProbe("health", srv.URL)},
+			During: []Probe{NewFuncProbe("during", func(_ context.Context) error {
+				duringChecks.Add(1)
+				return nil
+			})},
+			After:    []Probe{NewHTTPProbe("health", srv.URL)},
+			Interval: 50 * time.Millisecond,
+		}, pause(fake))
+		require.NoError(t, err)
+
+		res, err := e.Run(context.Background())
+		require.NoError(t, err)
+		require.True(t, res.Passed)
+		require.NoError(t, res.Err())
+		require.NotEmpty(t, res.Experiment)
+		require.True(t, res.ChaosEnd.After(res.ChaosStart))
+		require.GreaterOrEqual(t, duringChecks.Load(), int32(3))
+		require.Equal(t, ProbePhaseBefore, res.Probes[0].Phase)
+		require.Equal(t, ProbePhaseAfter, res.Probes[len(res.Probes)-1].Phase)
+		require.Equal(t, []string{"pause node1", "unpause node1"}, fake.Calls())
+	})
+
+	t.Run("experiment is skipped if the system isn't in the steady state", func(t *testing.T) {
+		healthy.Store(false)
+		defer healthy.Store(true)
+		fake := &fakeDockerClient{}
+		e, err := NewSteadyStateExperiment(l, "pause", SteadyStateHypothesis{
+			Before: []Probe{NewHTTPProbe("health", srv.URL)},
+		}, pause(fake))
+		require.NoError(t, err)
+
+		res, err := e.Run(context.Background())
+		require.NoError(t, err)
+		require.False(t, res.Passed)
+		require.Empty(t, res.Experiment)
+		require.ErrorContains(t, res.Err(), "[before] probe health failed")
+		require.ErrorContains(t, res.Err(), "responded with 503, expected 200")
+		require.Empty(t, fake.Calls())
+	})
+
+	t.Run("failures during chaos are reported", func(t *testing.T) {
+		e, err := NewSteadyStateExperiment(l, "pause", SteadyStateHypothesis{
+			During: []Probe{NewFuncProbe("during", func(_ context.Context) error {
+				return errors.New("node is down")
+			})},
+			Interval: 100 * time.Millisecond,
+		}, pause(&fakeDockerClient{}))
+		require.NoError(t, err)
+
+		res, err := e.Run(context.Background())
+		require.NoError(t, err)
+		require.False(t, res.Passed)
+		require.NotEmpty(t, res.Failures())
+		require.ErrorContains(t, res.Err(), "[during] probe during failed")
+	})
+
+	t.Run("system recovers after chaos", func(t *testing.T) {
+		var checks atomic.Int32
+		e, err := NewSteadyStateExperiment(l, "pause", SteadyStateHypothesis{
+			After: []Probe{NewFuncProbe("recovered", func(_ context.Context) error {
+				if checks.Add(1) < 3 {
+					return errors.New("not yet")
+				}
+				return nil
+			})},
+			Interval:        50 * time.Millisecond,
+			RecoveryTimeout: 5 * time.Second,
+		}, pause(&fakeDockerClient{}))
+		require.NoError(t, err)
+
+		res, err := e.Run(context.Background())
+		require.NoError(t, err)
+		require.True(t, res.Passed)
+		require.Len(t, res.Probes, 1, "only the last check is recorded")
+		require.Equal(t, int32(3), checks.Load())
+		require.GreaterOrEqual(t, res.Recovery, 100*time.Millisecond)
+	})
+
+	t.Run("experiment creation failure", func(t *testing.T) {
+		e, err := NewSteadyStateExperiment(l, "failing", SteadyStateHypothesis{
+			After: []Probe{NewHTTPProbe("health", srv.URL)},
+		}, func(_ context.Context) (*Chaos, error) {
+			return nil, errors.New("no cluster")
+		})
+		require.NoError(t, err)
+		_, err = e.Run(context.Background())
+		require.ErrorContains(t, err, "no cluster")
+	})
+
+	t.Run("experiment injection failure", func(t *testing.T) {
+		fake := &fakeDockerClient{failOn: "pause node1"}
+		e, err := NewSteadyStateExperiment(l, "pause", SteadyStateHypothesis{
+			During:   []Probe{NewHTTPProbe("health", srv.URL)},
+			Interval: 50 * time.Millisecond,
+		}, pause(fake))
+		require.NoError(t, err)
+		res, err := e.Run(context.Background())
+		require.ErrorContains(t, err, "failed to inject")
+		require.ErrorContains(t, err, "fake failure")
+		require.False(t, res.Passed)
+		require.True(t, res.ChaosStart.IsZero())
+		require.Empty(t, res.Probes)
+	})
+
+	t.Run("invalid experiments", func(t *testing.T) {
+		experiment := func(_ context.Context) (*Chaos, error) { return nil, nil }
+		_, err := NewSteadyStateExperiment(l, "", SteadyStateHypothesis{}, experiment)
+		require.ErrorContains(t, err, "name is required")
+		_, err = NewSteadyStateExperiment(l, "a", SteadyStateHypothesis{}, experiment)
+		require.ErrorContains(t, err, "at least one probe is required")
+		_, err = NewSteadyStateExperiment(l, "a", SteadyStateHypothesis{Before: []Probe{NewHTTPProbe("health", srv.URL)}}, nil)
+		require.ErrorContains(t, err, "experiment is required")
+	})
+}
+
+func TestPrometheusProbe(t *testing.T) {
+	var response atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(response.Load().(string)))
+	}))
+	defer srv.Close()
+
+	probe, err := NewPrometheusProbe("error-rate", srv.URL, "rate(errors[1m])", LessThan, 1)
+	require.NoError(t, err)
+
+	response.Store(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{"app":"a"},"value":[1700000000,"0.5"]},{"metric":{"app":"b"},"value":[1700000000,"0.1"]}]}}`)
+	require.NoError(t, probe.Check(context.Background()))
+
+	response.Store(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{"app":"a"},"value":[1700000000,"0.5"]},{"metric":{"app":"b"},"value":[1700000000,"2"]}]}}`)
+	require.ErrorContains(t, probe.Check(context.Background()), "returned 2.000000, expected < 1.000000")
+
+	response.Store(`{"status":"success","data":{"resultType":"scalar","result":[1700000000,"0"]}}`)
+	require.NoError(t, probe.Check(context.Background()))
+
+	response.Store(`{"status":"success","data":{"resultType":"vector","result":[]}}`)
+	require.ErrorContains(t, probe.Check(context.Background()), "returned no data")
+
+	_, err = NewPrometheusProbe("invalid", srv.URL, "up", "!=", 1)
+	require.ErrorContains(t, err, `unknown threshold operator "!="`)
+}
@@ -0,0 +1,312 @@
+package wasp
+
+import (
+	"encoding/json"
+	"math/bits"
+	"sort"
+	"sync"
+	"time"
+)
+
+/* In-process streaming latency histograms, available without any log backend */
+
+const (
+	// histSubBucketBits defines histogram precision, 7 bits gives 64 linear sub-buckets per power of two, < 1.6% relative error
+	histSubBucketBits = 7
+	histSubBucketHalf = 1 << (histSubBucketBits - 1)
+)
+
+// LatencySnapshot is a point-in-time view of a latency histogram
+type LatencySnapshot struct {
+	Count int64         `json:"count"`
+	P50   time.Duration `json:"p50"`
+	P90   time.Duration `json:"p90"`
+	P95   time.Duration `json:"p95"`
+	P99   time.Duration `json:"p99"`
+	Max   time.Duration `json:"max"`
+}
+
+// LatencyHistogram is a log-linear (HdrHistogram style) histogram of durations with bounded relative error
+// and constant memory, so it can record every call of a long running test
+type LatencyHistogram struct {
+	mu     *sync.Mutex
+	counts []int64
+	count  int64
+	max    int64
+}
+
+// NewLatencyHistogram creates an empty LatencyHistogram
+func NewLatencyHistogram() *LatencyHistogram {
+	return &LatencyHistogram{mu: &sync.Mutex{}, counts: make([]int64, 0)}
+}
+
+// histBucketIndex returns the bucket index for a value
+func histBucketIndex(v int64) int {
+	if v < 1<<histSubBucketBits {
+		return int(v)
+	}
+	exp := bits.Len64(uint64(v)) - histSubBucketBits
+	return exp*histSubBucketHalf + int(v>>exp)
+}
+
+// histBucketValue returns the highest value that falls into a bucket
+func histBucketValue(idx int) int64 {
+	if idx < 1<<histSubBucketBits {
+		return int64(idx)
+	}
+	exp := idx/histSubBucketHalf - 1
+	sub := int64(idx%histSubBucketHalf + histSubBucketHalf)
+	return (sub+1)<<exp - 1
+}
+
+// Record adds a duration to the histogram, negative durations are recorded as 0
+func (h *LatencyHistogram) Record(d time.Duration) {
+	v := max(int64(d), 0)
+	idx := histBucketIndex(v)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if idx >= len(h.counts) {
+		h.counts = append(h.counts, make([]int64, idx-len(h.counts)+1)...)
+	}
+	h.counts[idx]++
+	h.count++
+	h.max = max(h.max, v)
+}
+
+// Count returns the amount of recorded values
+func (h *LatencyHistogram) Count() int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+// Max returns the highest recorded value
+func (h *LatencyHistogram) Max() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return time.Duration(h.max)
+}
+
+// Percentile returns the value below which p percent (0-100) of recorded values fall
+func (h *LatencyHistogram) Percentile(p float64) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.percentile(p)
+}
+
+func (h *LatencyHistogram) percentile(p float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	rank := int64(p / 100 * float64(h.count))
+	if rank < 1 {
+		rank = 1
+	}
+	var acc int64
+	for idx, c := range h.counts {
+		acc += c
+		if acc >= rank {
+			return time.Duration(min(histBucketValue(idx), h.max))
+		}
+	}
+	return time.Duration(h.max)
+}
+
+// Snapshot returns the count, common percentiles and max of the histogram
+func (h *LatencyHistogram) Snapshot() LatencySnapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return LatencySnapshot{
+		Count: h.count,
+		P50:   h.percentile(50),
+		P90:   h.percentile(90),
+		P95:   h.percentile(95),
+		P99:   h.percentile(99),
+		Max:   time.Duration(h.max),
+	}
+}
+
+// Merge adds all values recorded by another histogram
+func (h *LatencyHistogram) Merge(other *LatencyHistogram) {
+	other.mu.Lock()
+	counts := append([]int64{}, other.counts...)
+	count, maxV := other.count, other.max
+	other.mu.Unlock()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(counts) > len(h.counts) {
+		h.counts = append(h.counts, make([]int64, len(counts)-len(h.counts))...)
+	}
+	for i, c := range counts {
+		h.counts[i] += c
+	}
+	h.count += count
+	h.max = max(h.max, maxV)
+}
+
+// LatencyBucket is a non-empty histogram bucket, Value is the highest duration that falls into it
+type LatencyBucket struct {
+	Value time.Duration `json:"value"`
+	Count int64         `json:"count"`
+}
+
+// Buckets returns non-empty buckets in ascending order, values are capped by the recorded max
+func (h *LatencyHistogram) Buckets() []LatencyBucket {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	buckets := make([]LatencyBucket, 0)
+	for idx, c := range h.counts {
+		if c > 0 {
+			buckets = append(buckets, LatencyBucket{Value: time.Duration(min(histBucketValue(idx), h.max)), Count: c})
+		}
+	}
+	return buckets
+}
+
+// latencyHistogramJSON is a sparse serialized form of LatencyHistogram, bucket index to count
+type latencyHistogramJSON struct {
+	Buckets map[int]int64 `json:"buckets"`
+	Max     int64         `json:"max"`
+}
+
+// MarshalJSON encodes non-empty buckets, so histograms can be sent between processes and merged
+func (h *LatencyHistogram) MarshalJSON() ([]byte, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hj := latencyHistogramJSON{Buckets: make(map[int]int64), Max: h.max}
+	for idx, c := range h.counts {
+		if c > 0 {
+			hj.Buckets[idx] = c
+		}
+	}
+	return json.Marshal(hj)
+}
+
+// UnmarshalJSON decodes a histogram encoded by MarshalJSON
+func (h *LatencyHistogram) UnmarshalJSON(data []byte) error {
+	var hj latencyHistogramJSON
+	if err := json.Unmarshal(data, &hj); err != nil {
+		return err
+	}
+	*h = *NewLatencyHistogram()
+	for idx, c := range hj.Buckets {
+		if idx < 0 || c < 0 {
+			continue
+		}
+		if idx >= len(h.counts) {
+			h.counts = append(h.counts, make([]int64, idx-len(h.counts)+1)...)
+		}
+		h.counts[idx] += c
+		h.count += c
+	}
+	h.max = hj.Max
+	return nil
+}
+
+// LatencyStats keeps latency histograms of a Generator for the whole run and for the last completed time window,
+// both in total and per Response.Group
+type LatencyStats struct {
+	mu               *sync.Mutex
+	total            *LatencyHistogram
+	window           *LatencyHistogram
+	lastWindow       LatencySnapshot
+	groups           map[string]*LatencyHistogram
+	groupWindows     map[string]*LatencyHistogram
+	lastGroupWindows map[string]LatencySnapshot
+}
+
+// NewLatencyStats creates empty LatencyStats
+func NewLatencyStats() *LatencyStats {
+	return &LatencyStats{
+		mu:               &sync.Mutex{},
+		total:            NewLatencyHistogram(),
+		window:           NewLatencyHistogram(),
+		groups:           make(map[string]*LatencyHistogram),
+		groupWindows:     make(map[string]*LatencyHistogram),
+		lastGroupWindows: make(map[string]LatencySnapshot),
+	}
+}
+
+// Record adds a Response latency, coordinated omission corrected duration is used when available
+func (ls *LatencyStats) Record(r *Response) {
+	d := r.Duration
+	if r.IntendedStartedAt != nil {
+		d = r.CorrectedDuration
+	}
+	ls.mu.Lock()
+	window := ls.window
+	var group, groupWindow *LatencyHistogram
+	if r.Group != "" {
+		if _, ok := ls.groups[r.Group]; !ok {
+			ls.groups[r.Group] = NewLatencyHistogram()
+			ls.groupWindows[r.Group] = NewLatencyHistogram()
+		}
+		group, groupWindow = ls.groups[r.Group], ls.groupWindows[r.Group]
+	}
+	ls.mu.Unlock()
+	ls.total.Record(d)
+	window.Record(d)
+	if group != nil {
+		group.Record(d)
+		groupWindow.Record(d)
+	}
+}
+
+// Rotate completes the current time window and starts a new one
+func (ls *LatencyStats) Rotate() {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.lastWindow = ls.window.Snapshot()
+	ls.window = NewLatencyHistogram()
+	for name, h := range ls.groupWindows {
+		ls.lastGroupWindows[name] = h.Snapshot()
+		ls.groupWindows[name] = NewLatencyHistogram()
+	}
+}
+
+// Total returns latency percentiles for the whole run
+func (ls *LatencyStats) Total() LatencySnapshot {
+	return ls.total.Snapshot()
+}
+
+// Window returns latency percentiles for the last completed time window
+func (ls *LatencyStats) Window() LatencySnapshot {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	return ls.lastWindow
+}
+
+// Histogram returns the histogram for the whole run
+func (ls *LatencyStats) Histogram() *LatencyHistogram {
+	return ls.total
+}
+
+// Group returns latency percentiles of a Response.Group for the whole run
+func (ls *LatencyStats) Group(name string) LatencySnapshot {
+	ls.mu.Lock()
+	h, ok := ls.groups[name]
+	ls.mu.Unlock()
+	if !ok {
+		return LatencySnapshot{}
+	}
+	return h.Snapshot()
+}
+
+// GroupWindow returns latency percentiles of a Response.Group for the last completed time window
+func (ls *LatencyStats) GroupWindow(name string) LatencySnapshot {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	return ls.lastGroupWindows[name]
+}
+
+// Groups returns sorted names of all recorded Response.Group values
+func (ls *LatencyStats) Groups() []string {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	names := make([]string, 0, len(ls.groups))
+	for name := range ls.groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,78 @@
+package wasp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyHistogram(t *testing.T) {
+	t.Parallel()
+	t.Run("empty", func(t *testing.T) {
+		h := NewLatencyHistogram()
+		require.Equal(t, LatencySnapshot{}, h.Snapshot())
+	})
+	t.Run("percentiles are within precision", func(t *testing.T) {
+		h := NewLatencyHistogram()
+		for i := 1; i <= 10000; i++ {
+			h.Record(time.Duration(i) * time.Microsecond)
+		}
+		s := h.Snapshot()
+		require.Equal(t, int64(10000), s.Count)
+		require.InEpsilon(t, 5*time.Millisecond, s.P50, 0.02)
+		require.InEpsilon(t, 9*time.Millisecond, s.P90, 0.02)
+		require.InEpsilon(t, 9500*time.Microsecond, s.P95, 0.02)
+		require.InEpsilon(t, 9900*time.Microsecond, s.P99, 0.02)
+		require.Equal(t, 10*time.Millisecond, s.Max)
+	})
+	t.Run("small values are exact", func(t *testing.T) {
+		h := NewLatencyHistogram()
+		for i := 0; i < 100; i++ {
+			h.Record(time.Duration(i))
+		}
+		require.Equal(t, time.Duration(49), h.Percentile(50))
+		require.Equal(t, time.Duration(99), h.Max())
+	})
+	t.Run("merge", func(t *testing.T) {
+		a, b := NewLatencyHistogram(), NewLatencyHistogram()
+		a.Record(1 * time.Millisecond)
+		b.Record(1 * time.Second)
+		a.Merge(b)
+		require.Equal(t, int64(2), a.Count())
+		require.Equal(t, 1*time.Second, a.Max())
+	})
+	t.Run("buckets", func(t *testing.T) {
+		h := NewLatencyHistogram()
+		h.Record(3)
+		h.Record(3)
+		h.Record(time.Second)
+		b := h.Buckets()
+		require.Len(t, b, 2)
+		require.Equal(t, LatencyBucket{Value: 3, Count: 2}, b[0])
+		require.Equal(t, LatencyBucket{Value: time.Second, Count: 1}, b[1])
+	})
+}
+
+func TestLatencyStatsWindowsAndGroups(t *testing.T) {
+	t.Parallel()
+	ls := NewLatencyStats()
+	ls.Record(&Response{Duration: 10 * time.Millisecond, Group: "a"})
+	ls.Record(&Response{Duration: 20 * time.Millisecond, Group: "b"})
+	require.Equal(t, LatencySnapshot{}, ls.Window())
+	ls.Rotate()
+	require.Equal(t, int64(2), ls.Window().Count)
+	ls.Record(&Response{Duration: 30 * time.Millisecond, Group: "a"})
+	ls.Rotate()
+	require.Equal(t, int64(1), ls.Window().Count)
+	require.Equal(t, int64(1), ls.GroupWindow("a").Count)
+	require.Equal(t, int64(0), ls.GroupWindow("b").Count)
+	require.Equal(t, int64(3), ls.Total().Count)
+	require.Equal(t, int64(2), ls.Group("a").Count)
+	require.InEpsilon(t, 30*time.Millisecond, ls.Group("a").Max, 0.02)
+	require.Equal(t, []string{"a", "b"}, ls.Groups())
+	// corrected duration is preferred when coordinated omission correction is enabled
+	now := time.Now()
+	ls.Record(&Response{Duration: 1 * time.Millisecond, CorrectedDuration: 1 * time.Second, IntendedStartedAt: &now})
+	require.Equal(t, 1*time.Second, ls.Total().Max)
+}
@@ -2,6 +2,7 @@ package wasp
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -25,6 +26,31 @@ type Profile struct {
 	grafanaOpts  GrafanaOpts
 	startTime    time.Time
 	endTime      time.Time
+	hooks        []ProfileHook
+	stopHooks    *sync.Once
+	hooksErr     error
+	windows      []TimeWindow
+}
+
+// TimeWindow is a named time range within a profile run, ex.: a chaos experiment injected during the load
+type TimeWindow struct {
+	Name   string            `json:"name"`
+	Start  time.Time         `json:"start"`
+	End    time.Time         `json:"end"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// Contains checks whether the time is within the window, end excluded
+func (w TimeWindow) Contains(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
+}
+
+// ProfileHook runs along with the profile, ex.: havoc.ChaosPlan starts chaos experiments at offsets from the load start
+type ProfileHook interface {
+	// Start is called right before generators are started, an error stops the run
+	Start(p *Profile) error
+	// Stop is called once all generators are finished, returned windows are recorded on the profile
+	Stop(p *Profile) ([]TimeWindow, error)
 }
 
 // Run executes the profile's generators, manages Grafana annotations, and handles alert checks.
@@ -38,6 +64,16 @@ func (m *Profile) Run(wait bool) (*Profile, error) {
 		return m, err
 	}
 	m.startTime = time.Now()
+	for i, h := range m.hooks {
+		if err := h.Start(m); err != nil {
+			for _, started := range m.hooks[:i] {
+				if _, stopErr := started.Stop(m); stopErr != nil {
+					log.Warn().Err(stopErr).Msg("could not stop profile hook")
+				}
+			}
+			return m, fmt.Errorf("failed to start profile hook: %w", err)
+		}
+	}
 	if len(m.grafanaOpts.AnnotateDashboardUID) > 0 {
 		m.annotateRunStartOnGrafana()
 	}
@@ -46,6 +82,9 @@ func (m *Profile) Run(wait bool) (*Profile, error) {
 	}
 	if wait {
 		m.Wait()
+		if m.hooksErr != nil {
+			return m, m.hooksErr
+		}
 	}
 	if m.grafanaOpts.WaitBeforeAlertCheck > 0 {
 		log.Info().Msgf("Waiting %s before checking for alerts..", m.grafanaOpts.WaitBeforeAlertCheck)
@@ -190,6 +229,39 @@ func (m *Profile) Wait() {
 		}()
 	}
 	m.testEndedWg.Wait()
+	m.stopHooks.Do(func() {
+		var errs []error
+		for _, h := range m.hooks {
+			windows, err := h.Stop(m)
+			if err != nil {
+				log.Error().Err(err).Msg("profile hook failed")
+				errs = append(errs, err)
+			}
+			m.windows = append(m.windows, windows...)
+		}
+		m.hooksErr = errors.Join(errs...)
+	})
+}
+
+// StartTime returns the time the profile was started at
+func (m *Profile) StartTime() time.Time {
+	return m.startTime
+}
+
+// Windows returns time windows recorded by profile hooks, they are available after Wait
+func (m *Profile) Windows() []TimeWindow {
+	return m.windows
+}
+
+// HooksErr returns errors of profile hooks that were stopped in Wait
+func (m *Profile) HooksErr() error {
+	return m.hooksErr
+}
+
+// WithHooks adds hooks running along with the profile, see ProfileHook
+func (m *Profile) WithHooks(hooks ...ProfileHook) *Profile {
+	m.hooks = append(m.hooks, hooks...)
+	return m
 }
 
 // NewProfile creates and returns a new Profile instance.
@@ -201,6 +273,7 @@ func NewProfile() *Profile {
 		ProfileID:   uuid.NewString()[0:5],
 		Generators:  make([]*Generator, 0),
 		testEndedWg: &sync.WaitGroup{},
+		stopHooks:   &sync.Once{},
 	}
 }
 
@@ -0,0 +1,403 @@
+package wasp
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+/* Declarative load test definitions loaded from TOML or YAML files */
+
+const (
+	FileLoadTypeRPS = "rps"
+	FileLoadTypeVU  = "vu"
+
+	FileSegmentPlain   = "plain"
+	FileSegmentSteps   = "steps"
+	FileSegmentPoisson = "poisson"
+	FileSegmentRamp    = "ramp"
+	FileSegmentSine    = "sine"
+	FileSegmentSpike   = "spike"
+	FileSegmentRepeat  = "repeat"
+
+	FileGunHTTP    = "http"
+	FileGunGRPC    = "grpc"
+	FileGunJSONRPC = "jsonrpc"
+	FileVUWS       = "ws"
+
+	FileVUWSSubscription = "ws_subscription"
+	FileVUGRPCStream     = "grpc_stream"
+
+	// FileLogBackendEnv configures Loki or OTEL from environment variables, see LogSendMethodEnvVar
+	FileLogBackendEnv = "env"
+)
+
+// FileDuration is a time.Duration that can be decoded from strings like "30s" or "1m30s"
+type FileDuration time.Duration
+
+// UnmarshalText parses a duration string
+func (d *FileDuration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = FileDuration(v)
+	return nil
+}
+
+// MarshalText formats a duration as a string
+func (d FileDuration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// ProfileFile is a declarative definition of a Profile
+type ProfileFile struct {
+	// Name is an informative name of the load test
+	Name string `toml:"name" yaml:"name"`
+	// Labels are added to every generator
+	Labels map[string]string `toml:"labels" yaml:"labels"`
+	// LogBackend is either empty, no log backend is used, or "env" to configure Loki or OTEL from environment variables
+	LogBackend string `toml:"log_backend" yaml:"log_backend"`
+	// Prometheus enables a /metrics endpoint for all generators
+	Prometheus *PrometheusConfig `toml:"prometheus" yaml:"prometheus"`
+	// Generators are generator definitions
+	Generators []*GeneratorFile `toml:"generator" yaml:"generators"`
+}
+
+// GeneratorFile is a declarative definition of a Generator
+type GeneratorFile struct {
+	Name                       string            `toml:"name" yaml:"name"`
+	LoadType                   string            `toml:"load_type" yaml:"load_type"`
+	Labels                     map[string]string `toml:"labels" yaml:"labels"`
+	Schedule                   []*SegmentFile    `toml:"schedule" yaml:"schedule"`
+	CallTimeout                FileDuration      `toml:"call_timeout" yaml:"call_timeout"`
+	SetupTimeout               FileDuration      `toml:"setup_timeout" yaml:"setup_timeout"`
+	TeardownTimeout            FileDuration      `toml:"teardown_timeout" yaml:"teardown_timeout"`
+	RateLimitUnitDuration      FileDuration      `toml:"rate_limit_unit_duration" yaml:"rate_limit_unit_duration"`
+	StatsPollInterval          FileDuration      `toml:"stats_poll_interval" yaml:"stats_poll_interval"`
+	FailOnErr                  bool              `toml:"fail_on_err" yaml:"fail_on_err"`
+	CorrectCoordinatedOmission bool              `toml:"correct_coordinated_omission" yaml:"correct_coordinated_omission"`
+	RecordPath                 string            `toml:"record_path" yaml:"record_path"`
+	Sampler                    *SamplerFile      `toml:"sampler" yaml:"sampler"`
+	Gun                        *GunFile          `toml:"gun" yaml:"gun"`
+	VU                         *VUFile           `toml:"vu" yaml:"vu"`
+}
+
+// SamplerFile is a declarative definition of a SamplerConfig
+type SamplerFile struct {
+	SuccessfulCallResultRecordRatio int `toml:"successful_call_result_record_ratio" yaml:"successful_call_result_record_ratio"`
+}
+
+// SegmentFile is a declarative definition of one or more schedule segments
+type SegmentFile struct {
+	// Type is one of: plain, steps, poisson, ramp, sine, spike, repeat
+	Type string `toml:"type" yaml:"type"`
+	// From is a starting rate (plain, steps, poisson, ramp), a base rate (sine, spike)
+	From int64 `toml:"from" yaml:"from"`
+	// To is a final rate (ramp) or a peak rate (spike)
+	To int64 `toml:"to" yaml:"to"`
+	// Increase is a rate change per step (steps)
+	Increase int64 `toml:"increase" yaml:"increase"`
+	// Steps is an amount of steps (steps)
+	Steps int `toml:"steps" yaml:"steps"`
+	// Amplitude is a sine amplitude (sine)
+	Amplitude int64 `toml:"amplitude" yaml:"amplitude"`
+	// Period is a sine period (sine)
+	Period FileDuration `toml:"period" yaml:"period"`
+	// SpikeAt is a spike start offset (spike)
+	SpikeAt FileDuration `toml:"spike_at" yaml:"spike_at"`
+	// SpikeDuration is a spike duration (spike)
+	SpikeDuration FileDuration `toml:"spike_duration" yaml:"spike_duration"`
+	// Duration is a segment duration, for steps it is the duration of all steps
+	Duration FileDuration `toml:"duration" yaml:"duration"`
+	// Times is an amount of repetitions of Segments (repeat)
+	Times int `toml:"times" yaml:"times"`
+	// Segments are repeated segments (repeat)
+	Segments []*SegmentFile `toml:"segments" yaml:"segments"`
+}
+
+// GunFile is a declarative definition of a built-in Gun
+type GunFile struct {
+	// Type is one of: http, grpc, jsonrpc
+	Type    string            `toml:"type" yaml:"type"`
+	HTTP    *HTTPGunConfig    `toml:"http" yaml:"http"`
+	GRPC    *GRPCGunConfig    `toml:"grpc" yaml:"grpc"`
+	JSONRPC *JSONRPCGunConfig `toml:"jsonrpc" yaml:"jsonrpc"`
+}
+
+// VUFile is a declarative definition of a built-in VirtualUser
+type VUFile struct {
+	// Type is one of: ws, ws_subscription, grpc_stream
+	Type           string                  `toml:"type" yaml:"type"`
+	WS             *WSVUConfig             `toml:"ws" yaml:"ws"`
+	WSSubscription *WSSubscriptionVUConfig `toml:"ws_subscription" yaml:"ws_subscription"`
+	GRPCStream     *GRPCStreamVUConfig     `toml:"grpc_stream" yaml:"grpc_stream"`
+}
+
+// ParseProfileFile reads a TOML (.toml) or YAML (.yaml, .yml) load test definition, unknown fields are rejected
+func ParseProfileFile(path string) (*ProfileFile, error) {
+	d, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile file: %w", err)
+	}
+	pf := &ProfileFile{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		dec := toml.NewDecoder(bytes.NewReader(d))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(pf)
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(d))
+		dec.KnownFields(true)
+		err = dec.Decode(pf)
+	default:
+		return nil, fmt.Errorf("unsupported profile file extension: %s, use .toml, .yaml or .yml", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode profile file %s: %w", path, err)
+	}
+	if len(pf.Generators) == 0 {
+		return nil, fmt.Errorf("profile file %s has no generators", path)
+	}
+	return pf, nil
+}
+
+// LoadProfileFromFile reads a load test definition and creates a Profile with all its generators, ready to Run
+func LoadProfileFromFile(path string) (*Profile, error) {
+	pf, err := ParseProfileFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return pf.NewProfile(nil)
+}
+
+// NewProfile creates a Profile from the definition, t is optional and is used for logging and labels
+func (pf *ProfileFile) NewProfile(t *testing.T) (*Profile, error) {
+	p := NewProfile()
+	for i, gf := range pf.Generators {
+		cfg, err := pf.generatorConfig(t, gf)
+		if err != nil {
+			return nil, fmt.Errorf("generator #%d (%s): %w", i, gf.Name, err)
+		}
+		g, err := NewGenerator(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("generator #%d (%s): %w", i, gf.Name, err)
+		}
+		p.Add(g, nil)
+	}
+	return p, nil
+}
+
+// ForWorker returns a copy of the definition with all rates and VUs divided between workers, worker is a 0-based index.
+// Remainders go to the workers with lower indexes, so the sum of all parts is equal to the original load.
+func (pf *ProfileFile) ForWorker(worker, workers int) *ProfileFile {
+	cp := *pf
+	cp.Generators = make([]*GeneratorFile, 0, len(pf.Generators))
+	for _, gf := range pf.Generators {
+		g := *gf
+		g.Schedule = segmentsForWorker(gf.Schedule, worker, workers)
+		cp.Generators = append(cp.Generators, &g)
+	}
+	return &cp
+}
+
+// Duration returns the duration of the longest generator schedule, generators of a profile run at the same time
+func (pf *ProfileFile) Duration() (time.Duration, error) {
+	var longest time.Duration
+	for _, gf := range pf.Generators {
+		schedule, err := scheduleFromFile(gf.Schedule)
+		if err != nil {
+			return 0, fmt.Errorf("generator %s: %w", gf.Name, err)
+		}
+		var d time.Duration
+		for _, seg := range schedule {
+			d += seg.Duration
+		}
+		longest = max(longest, d)
+	}
+	return longest, nil
+}
+
+func segmentsForWorker(sf []*SegmentFile, worker, workers int) []*SegmentFile {
+	out := make([]*SegmentFile, 0, len(sf))
+	for _, s := range sf {
+		seg := *s
+		seg.From = workerShare(s.From, worker, workers)
+		seg.To = workerShare(s.To, worker, workers)
+		seg.Increase = workerShare(s.Increase, worker, workers)
+		seg.Amplitude = workerShare(s.Amplitude, worker, workers)
+		seg.Segments = segmentsForWorker(s.Segments, worker, workers)
+		out = append(out, &seg)
+	}
+	return out
+}
+
+// workerShare returns a part of v for a worker
+func workerShare(v int64, worker, workers int) int64 {
+	if v < 0 {
+		return -workerShare(-v, worker, workers)
+	}
+	n := int64(workers)
+	share := v / n
+	if int64(worker) < v%n {
+		share++
+	}
+	return share
+}
+
+// generatorConfig converts a GeneratorFile to a generator Config
+func (pf *ProfileFile) generatorConfig(t *testing.T, gf *GeneratorFile) (*Config, error) {
+	labels := make(map[string]string)
+	for k, v := range pf.Labels {
+		labels[k] = v
+	}
+	for k, v := range gf.Labels {
+		labels[k] = v
+	}
+	cfg := &Config{
+		T:                          t,
+		GenName:                    gf.Name,
+		Labels:                     labels,
+		CallTimeout:                time.Duration(gf.CallTimeout),
+		SetupTimeout:               time.Duration(gf.SetupTimeout),
+		TeardownTimeout:            time.Duration(gf.TeardownTimeout),
+		RateLimitUnitDuration:      time.Duration(gf.RateLimitUnitDuration),
+		StatsPollInterval:          time.Duration(gf.StatsPollInterval),
+		FailOnErr:                  gf.FailOnErr,
+		CorrectCoordinatedOmission: gf.CorrectCoordinatedOmission,
+		PrometheusConfig:           pf.Prometheus,
+	}
+	if gf.RecordPath != "" {
+		cfg.Recorder = &RecorderConfig{Path: gf.RecordPath}
+	}
+	switch pf.LogBackend {
+	case "":
+	case FileLogBackendEnv:
+		cfg.LokiConfig = NewEnvLokiConfig()
+		cfg.OTELConfig = NewEnvOTELConfig()
+	default:
+		return nil, fmt.Errorf("unknown log_backend: %s", pf.LogBackend)
+	}
+	if gf.Sampler != nil {
+		cfg.SamplerConfig = &SamplerConfig{SuccessfulCallResultRecordRatio: gf.Sampler.SuccessfulCallResultRecordRatio}
+	}
+	switch gf.LoadType {
+	case FileLoadTypeRPS:
+		cfg.LoadType = RPS
+	case FileLoadTypeVU:
+		cfg.LoadType = VU
+	default:
+		return nil, fmt.Errorf("unknown load_type: %q, use %q or %q", gf.LoadType, FileLoadTypeRPS, FileLoadTypeVU)
+	}
+	var err error
+	cfg.Schedule, err = scheduleFromFile(gf.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	if gf.Gun != nil {
+		cfg.Gun, err = gunFromFile(gf.Gun)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if gf.VU != nil {
+		cfg.VU, err = vuFromFile(gf.VU)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// scheduleFromFile converts segment definitions to schedule segments
+func scheduleFromFile(sf []*SegmentFile) ([]*Segment, error) {
+	if len(sf) == 0 {
+		return nil, ErrNoSchedule
+	}
+	acc := make([][]*Segment, 0)
+	for _, s := range sf {
+		d := time.Duration(s.Duration)
+		switch s.Type {
+		case FileSegmentPlain:
+			acc = append(acc, Plain(s.From, d))
+		case FileSegmentSteps:
+			if s.Steps <= 0 {
+				return nil, fmt.Errorf("steps segment must have steps > 0")
+			}
+			acc = append(acc, Steps(s.From, s.Increase, s.Steps, d))
+		case FileSegmentPoisson:
+			acc = append(acc, Poisson(s.From, d))
+		case FileSegmentRamp:
+			acc = append(acc, Ramp(s.From, s.To, d))
+		case FileSegmentSine:
+			if s.Period == 0 {
+				return nil, fmt.Errorf("sine segment must have a period")
+			}
+			acc = append(acc, Sine(s.From, s.Amplitude, time.Duration(s.Period), d))
+		case FileSegmentSpike:
+			acc = append(acc, Spike(s.From, s.To, time.Duration(s.SpikeAt), time.Duration(s.SpikeDuration), d))
+		case FileSegmentRepeat:
+			inner, err := scheduleFromFile(s.Segments)
+			if err != nil {
+				return nil, fmt.Errorf("repeat segment: %w", err)
+			}
+			acc = append(acc, CombineAndRepeat(s.Times, inner))
+		default:
+			return nil, fmt.Errorf("unknown segment type: %q", s.Type)
+		}
+	}
+	return Combine(acc...), nil
+}
+
+// gunFromFile creates a built-in Gun
+func gunFromFile(gf *GunFile) (Gun, error) {
+	switch gf.Type {
+	case FileGunHTTP:
+		if gf.HTTP == nil {
+			return nil, fmt.Errorf("http gun requires an http section")
+		}
+		return NewHTTPGun(gf.HTTP)
+	case FileGunGRPC:
+		if gf.GRPC == nil {
+			return nil, fmt.Errorf("grpc gun requires a grpc section")
+		}
+		return NewGRPCGun(gf.GRPC)
+	case FileGunJSONRPC:
+		if gf.JSONRPC == nil {
+			return nil, fmt.Errorf("jsonrpc gun requires a jsonrpc section")
+		}
+		return NewJSONRPCGun(gf.JSONRPC)
+	default:
+		return nil, fmt.Errorf("unknown gun type: %q", gf.Type)
+	}
+}
+
+// vuFromFile creates a built-in VirtualUser
+func vuFromFile(vf *VUFile) (VirtualUser, error) {
+	switch vf.Type {
+	case FileVUWS:
+		if vf.WS == nil {
+			return nil, fmt.Errorf("ws vu requires a ws section")
+		}
+		return NewWSVU(vf.WS)
+	case FileVUWSSubscription:
+		if vf.WSSubscription == nil {
+			return nil, fmt.Errorf("ws_subscription vu requires a ws_subscription section")
+		}
+		return NewWSSubscriptionVU(vf.WSSubscription)
+	case FileVUGRPCStream:
+		if vf.GRPCStream == nil {
+			return nil, fmt.Errorf("grpc_stream vu requires a grpc_stream section")
+		}
+		return NewGRPCStreamVU(vf.GRPCStream)
+	default:
+		return nil, fmt.Errorf("unknown vu type: %q", vf.Type)
+	}
+}
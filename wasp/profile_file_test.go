@@ -0,0 +1,248 @@
+package wasp
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func writeProfileFile(t *testing.T, name, content string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(p, []byte(content), 0o600))
+	return p
+}
+
+func TestProfileFileSchedules(t *testing.T) {
+	t.Parallel()
+	p := writeProfileFile(t, "profile.yaml", `
+name: schedules
+labels:
+  branch: main
+generators:
+  - name: gen
+    load_type: rps
+    call_timeout: 5s
+    sampler:
+      successful_call_result_record_ratio: 10
+    schedule:
+      - type: plain
+        from: 10
+        duration: 10s
+      - type: steps
+        from: 10
+        increase: 10
+        steps: 2
+        duration: 20s
+      - type: repeat
+        times: 2
+        segments:
+          - type: poisson
+            from: 5
+            duration: 1s
+          - type: ramp
+            from: 5
+            to: 10
+            duration: 1s
+    gun:
+      type: http
+      http:
+        url: http://localhost:8080
+`)
+	pf, err := ParseProfileFile(p)
+	require.NoError(t, err)
+	cfg, err := pf.generatorConfig(nil, pf.Generators[0])
+	require.NoError(t, err)
+	require.Equal(t, RPS, cfg.LoadType)
+	require.Equal(t, 5*time.Second, cfg.CallTimeout)
+	require.Equal(t, 10, cfg.SamplerConfig.SuccessfulCallResultRecordRatio)
+	require.Equal(t, map[string]string{"branch": "main"}, cfg.Labels)
+	require.Len(t, cfg.Schedule, 7)
+	require.Equal(t, SegmentType_Plain, cfg.Schedule[0].Type)
+	require.Equal(t, int64(20), cfg.Schedule[2].From)
+	require.Equal(t, SegmentType_Poisson, cfg.Schedule[3].Type)
+	require.Equal(t, SegmentType_Curve, cfg.Schedule[6].Type)
+	require.IsType(t, &HTTPGun{}, cfg.Gun)
+	d, err := pf.Duration()
+	require.NoError(t, err)
+	require.Equal(t, 34*time.Second, d)
+}
+
+func TestProfileFileValidation(t *testing.T) {
+	t.Parallel()
+	t.Run("unknown fields are rejected", func(t *testing.T) {
+		p := writeProfileFile(t, "profile.toml", `
+[[generator]]
+name = "gen"
+load_tpye = "rps"
+`)
+		_, err := ParseProfileFile(p)
+		require.Error(t, err)
+	})
+	t.Run("unknown extension", func(t *testing.T) {
+		p := writeProfileFile(t, "profile.json", `{}`)
+		_, err := ParseProfileFile(p)
+		require.ErrorContains(t, err, "unsupported profile file extension")
+	})
+	t.Run("unknown segment type", func(t *testing.T) {
+		p := writeProfileFile(t, "profile.toml", `
+[[generator]]
+name = "gen"
+load_type = "rps"
+[[generator.schedule]]
+type = "zigzag"
+`)
+		_, err := LoadProfileFromFile(p)
+		require.ErrorContains(t, err, `unknown segment type: "zigzag"`)
+	})
+}
+
+func TestSmokeProfileFileHTTPGun(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Header.Get("X-Test") != "1" || r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(srv.Close)
+	p := writeProfileFile(t, "profile.toml", `
+name = "http"
+
+[[generator]]
+name = "http_gen"
+load_type = "rps"
+call_timeout = "1s"
+
+[[generator.schedule]]
+type = "plain"
+from = 10
+duration = "2s"
+
+[generator.gun]
+type = "http"
+
+[generator.gun.http]
+method = "post"
+url = "`+srv.URL+`"
+body = "{}"
+group = "post"
+
+[generator.gun.http.headers]
+X-Test = "1"
+`)
+	profile, err := LoadProfileFromFile(p)
+	require.NoError(t, err)
+	_, err = profile.Run(true)
+	require.NoError(t, err)
+	g := profile.Generators[0]
+	require.Equal(t, "http_gen", g.Cfg.GenName)
+	require.Equal(t, int64(0), g.Stats().Failed.Load())
+	require.GreaterOrEqual(t, g.Stats().Success.Load(), int64(18))
+	require.Equal(t, calls.Load(), g.Stats().Success.Load())
+	require.Equal(t, g.Stats().Success.Load(), g.Stats().Latency.Group("post").Count)
+}
+
+func TestSmokeProfileFileGRPCGun(t *testing.T) {
+	t.Parallel()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer()
+	healthpb.RegisterHealthServer(s, health.NewServer())
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+
+	p := writeProfileFile(t, "profile.yaml", `
+generators:
+  - name: grpc_gen
+    load_type: rps
+    schedule:
+      - type: plain
+        from: 10
+        duration: 1s
+    gun:
+      type: grpc
+      grpc:
+        target: `+lis.Addr().String()+`
+`)
+	profile, err := LoadProfileFromFile(p)
+	require.NoError(t, err)
+	_, err = profile.Run(true)
+	require.NoError(t, err)
+	g := profile.Generators[0]
+	require.Equal(t, int64(0), g.Stats().Failed.Load())
+	require.GreaterOrEqual(t, g.Stats().Success.Load(), int64(9))
+	_, okResponses, _ := convertResponsesData(g)
+	// serialized HealthCheckResponse{Status: SERVING}
+	require.Equal(t, "0801", okResponses[0].Data)
+}
+
+func TestSmokeProfileFileWSVU(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(MockWSServer{Logf: t.Logf, Sleep: 50 * time.Millisecond})
+	t.Cleanup(srv.Close)
+	p := writeProfileFile(t, "profile.yml", `
+generators:
+  - name: ws_gen
+    load_type: vu
+    schedule:
+      - type: plain
+        from: 2
+        duration: 2s
+    vu:
+      type: ws
+      ws:
+        url: `+strings.Replace(srv.URL, "http", "ws", 1)+`
+        group: ws
+`)
+	profile, err := LoadProfileFromFile(p)
+	require.NoError(t, err)
+	_, err = profile.Run(true)
+	require.NoError(t, err)
+	g := profile.Generators[0]
+	require.GreaterOrEqual(t, g.Stats().Latency.Group("ws").Count, int64(20))
+}
+
+func TestSmokeProfileFileWSSubscriptionVU(t *testing.T) {
+	t.Parallel()
+	srv := newHeadsServer(t, 20*time.Millisecond, -1, 0)
+	t.Cleanup(srv.Close)
+	p := writeProfileFile(t, "profile.yml", `
+generators:
+  - name: heads_gen
+    load_type: vu
+    schedule:
+      - type: plain
+        from: 1
+        duration: 1s
+    vu:
+      type: ws_subscription
+      ws_subscription:
+        url: `+strings.Replace(srv.URL, "http", "ws", 1)+`
+        subscribe_message: '{"jsonrpc":"2.0","id":1,"method":"eth_subscribe","params":["newHeads"]}'
+        subscribe_reply: true
+        max_gap: 500ms
+        group: heads
+`)
+	profile, err := LoadProfileFromFile(p)
+	require.NoError(t, err)
+	_, err = profile.Run(true)
+	require.NoError(t, err)
+	g := profile.Generators[0]
+	require.GreaterOrEqual(t, g.Stats().Latency.Group("heads_message").Count, int64(20))
+	require.Equal(t, int64(0), g.Stats().Failed.Load())
+}
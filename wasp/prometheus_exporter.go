@@ -0,0 +1,251 @@
+package wasp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/common/model"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	DefaultPrometheusListenAddr = ":2112"
+	DefaultPrometheusPath       = "/metrics"
+	PrometheusNamespace         = "wasp"
+	// ResponseStatusLabel is the label name for response status in responses counter
+	ResponseStatusLabel = "status"
+)
+
+// DefaultPrometheusBuckets are the default response duration histogram buckets, in seconds
+var DefaultPrometheusBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60}
+
+// PrometheusConfig configures a pull-based /metrics endpoint for a Generator.
+// Generators sharing the same ListenAddr are served by one HTTP server and must use the same set of label names.
+type PrometheusConfig struct {
+	// ListenAddr is the address the /metrics HTTP server listens on, ex.: ":2112"
+	ListenAddr string `toml:"listen_addr" yaml:"listen_addr"`
+	// Path is the HTTP path for metrics (defaults to "/metrics")
+	Path string `toml:"path" yaml:"path"`
+	// Buckets are response duration histogram buckets in seconds
+	Buckets []float64 `toml:"buckets" yaml:"buckets"`
+	// Registerer, if set, metrics are registered there and no HTTP server is started
+	Registerer prometheus.Registerer `toml:"-" yaml:"-"`
+}
+
+// DefaultPrometheusConfig returns a PrometheusConfig serving metrics on DefaultPrometheusListenAddr
+func DefaultPrometheusConfig() *PrometheusConfig {
+	return &PrometheusConfig{
+		ListenAddr: DefaultPrometheusListenAddr,
+		Path:       DefaultPrometheusPath,
+		Buckets:    DefaultPrometheusBuckets,
+	}
+}
+
+// Validate sets defaults for unset fields
+func (c *PrometheusConfig) Validate() error {
+	if c.ListenAddr == "" {
+		c.ListenAddr = DefaultPrometheusListenAddr
+	}
+	if c.Path == "" {
+		c.Path = DefaultPrometheusPath
+	}
+	if len(c.Buckets) == 0 {
+		c.Buckets = DefaultPrometheusBuckets
+	}
+	return nil
+}
+
+// prometheusServer is a metrics HTTP server shared by all generators using the same address
+type prometheusServer struct {
+	registry *prometheus.Registry
+	srv      *http.Server
+	addr     string
+}
+
+var (
+	promServersMu = &sync.Mutex{}
+	promServers   = map[string]*prometheusServer{}
+)
+
+// prometheusServerFor returns a running metrics server for the config address, starting it if needed
+func prometheusServerFor(cfg *PrometheusConfig) (*prometheusServer, error) {
+	promServersMu.Lock()
+	defer promServersMu.Unlock()
+	key := cfg.ListenAddr + cfg.Path
+	if s, ok := promServers[key]; ok {
+		return s, nil
+	}
+	ln, err := net.Listen("tcp", cfg.ListenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", cfg.ListenAddr, err)
+	}
+	reg := prometheus.NewRegistry()
+	mux := http.NewServeMux()
+	mux.Handle(cfg.Path, promhttp.HandlerFor(reg, promhttp.HandlerOpts{Registry: reg}))
+	s := &prometheusServer{
+		registry: reg,
+		srv:      &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second},
+		addr:     ln.Addr().String(),
+	}
+	go func() {
+		if err := s.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Err(err).Str("Addr", s.addr).Msg("Prometheus metrics server failed")
+		}
+	}()
+	log.Info().Str("Addr", s.addr).Str("Path", cfg.Path).Msg("Prometheus metrics server started")
+	promServers[key] = s
+	return s, nil
+}
+
+// ShutdownPrometheusServers stops all metrics servers started by generators.
+// Servers are kept alive after generators finish so the final values can be scraped.
+func ShutdownPrometheusServers() {
+	promServersMu.Lock()
+	defer promServersMu.Unlock()
+	for key, s := range promServers {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_ = s.srv.Shutdown(ctx)
+		cancel()
+		delete(promServers, key)
+	}
+}
+
+// PrometheusExporter exposes Generator counters, gauges and latency histograms as Prometheus metrics
+type PrometheusExporter struct {
+	// Addr is the actual address of the metrics server, empty if metrics are registered in a custom Registerer
+	Addr         string
+	labelNames   []string
+	labelValues  []string
+	responses    *prometheus.CounterVec
+	duration     *prometheus.HistogramVec
+	currentRPS   *prometheus.GaugeVec
+	currentVUs   *prometheus.GaugeVec
+	segment      *prometheus.GaugeVec
+	samplesTotal *prometheus.CounterVec
+}
+
+// NewPrometheusExporter creates an exporter for a generator with the given labels,
+// registering metrics in the configured Registerer or in a shared metrics server
+func NewPrometheusExporter(cfg *PrometheusConfig, ls model.LabelSet) (*PrometheusExporter, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	e := &PrometheusExporter{}
+	reg := cfg.Registerer
+	if reg == nil {
+		s, err := prometheusServerFor(cfg)
+		if err != nil {
+			return nil, err
+		}
+		reg = s.registry
+		e.Addr = s.addr
+	}
+	for name := range ls {
+		e.labelNames = append(e.labelNames, string(name))
+	}
+	sort.Strings(e.labelNames)
+	for _, name := range e.labelNames {
+		e.labelValues = append(e.labelValues, string(ls[model.LabelName(name)]))
+	}
+	withGroup := append(append([]string{}, e.labelNames...), CallGroupLabel)
+	withStatus := append(append([]string{}, withGroup...), ResponseStatusLabel)
+
+	var err error
+	if e.responses, err = registerOrExisting(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: PrometheusNamespace,
+		Name:      "responses_total",
+		Help:      "Total amount of responses by call group and status: success, failed or timeout",
+	}, withStatus)); err != nil {
+		return nil, err
+	}
+	if e.duration, err = registerOrExisting(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: PrometheusNamespace,
+		Name:      "response_duration_seconds",
+		Help:      "Response duration, coordinated omission corrected if enabled",
+		Buckets:   cfg.Buckets,
+	}, withGroup)); err != nil {
+		return nil, err
+	}
+	if e.currentRPS, err = registerOrExisting(reg, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: PrometheusNamespace,
+		Name:      "current_rps",
+		Help:      "Current scheduled requests per rate limit unit",
+	}, e.labelNames)); err != nil {
+		return nil, err
+	}
+	if e.currentVUs, err = registerOrExisting(reg, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: PrometheusNamespace,
+		Name:      "current_vus",
+		Help:      "Current amount of virtual users",
+	}, e.labelNames)); err != nil {
+		return nil, err
+	}
+	if e.segment, err = registerOrExisting(reg, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: PrometheusNamespace,
+		Name:      "current_segment",
+		Help:      "Current schedule segment number",
+	}, e.labelNames)); err != nil {
+		return nil, err
+	}
+	if e.samplesTotal, err = registerOrExisting(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: PrometheusNamespace,
+		Name:      "samples_skipped_total",
+		Help:      "Total amount of successful responses skipped by the sampler",
+	}, e.labelNames)); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// registerOrExisting registers a collector or returns an already registered one with the same descriptor,
+// so multiple generators can share metric families
+func registerOrExisting[T prometheus.Collector](reg prometheus.Registerer, c T) (T, error) {
+	if err := reg.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(T); ok {
+				return existing, nil
+			}
+		}
+		return c, fmt.Errorf("failed to register Prometheus metric: %w", err)
+	}
+	return c, nil
+}
+
+// ObserveResponse records a Response in the responses counter and the duration histogram
+func (e *PrometheusExporter) ObserveResponse(r *Response) {
+	status := "success"
+	switch {
+	case r.Timeout:
+		status = "timeout"
+	case r.Failed:
+		status = "failed"
+	}
+	d := r.Duration
+	if r.IntendedStartedAt != nil {
+		d = r.CorrectedDuration
+	}
+	withGroup := append(append([]string{}, e.labelValues...), r.Group)
+	e.responses.WithLabelValues(append(withGroup, status)...).Inc()
+	e.duration.WithLabelValues(withGroup...).Observe(d.Seconds())
+}
+
+// ObserveSkipped records a response skipped by the sampler
+func (e *PrometheusExporter) ObserveSkipped() {
+	e.samplesTotal.WithLabelValues(e.labelValues...).Inc()
+}
+
+// ObserveStats updates gauges from the current generator Stats
+func (e *PrometheusExporter) ObserveStats(s *Stats) {
+	e.currentRPS.WithLabelValues(e.labelValues...).Set(float64(s.CurrentRPS.Load()))
+	e.currentVUs.WithLabelValues(e.labelValues...).Set(float64(s.CurrentVUs.Load()))
+	e.segment.WithLabelValues(e.labelValues...).Set(float64(s.CurrentSegment.Load()))
+}
@@ -0,0 +1,60 @@
+package wasp
+
+import (
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSmokePrometheusExporterServesMetrics(t *testing.T) {
+	t.Cleanup(ShutdownPrometheusServers)
+	gen, err := NewGenerator(&Config{
+		T:                t,
+		GenName:          "prom",
+		LoadType:         RPS,
+		Labels:           map[string]string{"branch": "test"},
+		PrometheusConfig: &PrometheusConfig{ListenAddr: "127.0.0.1:0"},
+		Schedule:         Plain(10, 2*time.Second),
+		Gun: NewMockGun(&MockGunConfig{
+			CallSleep: 10 * time.Millisecond,
+		}),
+	})
+	require.NoError(t, err)
+	_, failed := gen.Run(true)
+	require.Equal(t, false, failed)
+
+	resp, err := http.Get("http://" + gen.prom.Addr + DefaultPrometheusPath)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), `wasp_responses_total{branch="test",call_group="",gen_name="prom",go_test_name="TestSmokePrometheusExporterServesMetrics",status="success"}`)
+	require.Contains(t, string(body), `wasp_response_duration_seconds_bucket`)
+	require.Contains(t, string(body), `wasp_current_rps{branch="test",gen_name="prom",go_test_name="TestSmokePrometheusExporterServesMetrics"} 10`)
+}
+
+func TestPrometheusExporterSharedRegistry(t *testing.T) {
+	t.Parallel()
+	reg := prometheus.NewRegistry()
+	a, err := NewPrometheusExporter(&PrometheusConfig{Registerer: reg}, LabelsMapToModel(map[string]string{"gen_name": "a"}))
+	require.NoError(t, err)
+	b, err := NewPrometheusExporter(&PrometheusConfig{Registerer: reg}, LabelsMapToModel(map[string]string{"gen_name": "b"}))
+	require.NoError(t, err)
+	require.Empty(t, a.Addr)
+
+	a.ObserveResponse(&Response{Group: "g1", Duration: 10 * time.Millisecond})
+	a.ObserveResponse(&Response{Group: "g1", Failed: true})
+	b.ObserveResponse(&Response{Group: "g2", Timeout: true})
+	b.ObserveSkipped()
+
+	require.Equal(t, float64(1), testutil.ToFloat64(a.responses.WithLabelValues("a", "g1", "success")))
+	require.Equal(t, float64(1), testutil.ToFloat64(a.responses.WithLabelValues("a", "g1", "failed")))
+	require.Equal(t, float64(1), testutil.ToFloat64(b.responses.WithLabelValues("b", "g2", "timeout")))
+	require.Equal(t, float64(1), testutil.ToFloat64(b.samplesTotal.WithLabelValues("b")))
+	require.Equal(t, 3, testutil.CollectAndCount(reg, "wasp_responses_total"))
+}
@@ -0,0 +1,248 @@
+package wasp
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+/* Run recorder, persists responses and stats snapshots so runs can be analyzed without Loki */
+
+const (
+	RecordTypeMeta     = "meta"
+	RecordTypeResponse = "response"
+	RecordTypeStats    = "stats"
+)
+
+var (
+	ErrNoRecordPath = errors.New("recorder path is empty")
+	ErrNoRunMeta    = errors.New("recorded run has no meta record")
+)
+
+// RecorderConfig enables recording of every Response and stats snapshots of a Generator to a newline JSON file,
+// the file is gzip compressed if Path ends with ".gz"
+type RecorderConfig struct {
+	Path string `toml:"path" yaml:"path"`
+}
+
+// RunMeta describes a recorded run
+type RunMeta struct {
+	Config     *Config           `json:"config"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	StartedAt  time.Time         `json:"started_at"`
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+}
+
+// RunStats is a stats snapshot, see Generator.StatsJSON
+type RunStats struct {
+	Time  time.Time      `json:"time"`
+	Stats map[string]any `json:"stats"`
+}
+
+// RunRecord is one line of a recorded run file, only the field matching Type is set
+type RunRecord struct {
+	Type     string    `json:"type"`
+	Meta     *RunMeta  `json:"meta,omitempty"`
+	Response *Response `json:"response,omitempty"`
+	Stats    *RunStats `json:"stats,omitempty"`
+}
+
+// RunRecorder writes run records to a file, it is safe for concurrent use
+type RunRecorder struct {
+	mu  *sync.Mutex
+	f   *os.File
+	gz  *gzip.Writer
+	buf *bufio.Writer
+	enc *json.Encoder
+	err error
+}
+
+// NewRunRecorder creates a run file, parent directories are created if needed
+func NewRunRecorder(cfg *RecorderConfig) (*RunRecorder, error) {
+	if cfg.Path == "" {
+		return nil, ErrNoRecordPath
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create recorder directory: %w", err)
+	}
+	f, err := os.Create(cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recorder file: %w", err)
+	}
+	r := &RunRecorder{mu: &sync.Mutex{}, f: f}
+	var w io.Writer = f
+	if strings.HasSuffix(cfg.Path, ".gz") {
+		r.gz = gzip.NewWriter(f)
+		w = r.gz
+	}
+	r.buf = bufio.NewWriterSize(w, 64*1024)
+	r.enc = json.NewEncoder(r.buf)
+	return r, nil
+}
+
+func (r *RunRecorder) write(rec *RunRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	r.err = r.enc.Encode(rec)
+}
+
+// RecordMeta writes the run description, it must be the first record.
+// It can be written again when the run finishes, readers use the last one
+func (r *RunRecorder) RecordMeta(m *RunMeta) {
+	r.write(&RunRecord{Type: RecordTypeMeta, Meta: m})
+}
+
+// RecordResponse writes a Response
+func (r *RunRecorder) RecordResponse(res *Response) {
+	r.write(&RunRecord{Type: RecordTypeResponse, Response: res})
+}
+
+// RecordStats writes a stats snapshot
+func (r *RunRecorder) RecordStats(stats map[string]any) {
+	r.write(&RunRecord{Type: RecordTypeStats, Stats: &RunStats{Time: time.Now(), Stats: stats}})
+}
+
+// Close flushes and closes the file, it returns the first write error if any
+func (r *RunRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	errs := []error{r.err, r.buf.Flush()}
+	if r.gz != nil {
+		errs = append(errs, r.gz.Close())
+	}
+	errs = append(errs, r.f.Close())
+	return errors.Join(errs...)
+}
+
+// RunReader reads records of a run file one by one
+type RunReader struct {
+	f   *os.File
+	gz  *gzip.Reader
+	dec *json.Decoder
+}
+
+// OpenRun opens a run file written by RunRecorder
+func OpenRun(path string) (*RunReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open run file: %w", err)
+	}
+	rr := &RunReader{f: f}
+	var rd io.Reader = bufio.NewReaderSize(f, 64*1024)
+	if strings.HasSuffix(path, ".gz") {
+		rr.gz, err = gzip.NewReader(rd)
+		if err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("failed to read gzip run file %s: %w", path, err)
+		}
+		rd = rr.gz
+	}
+	rr.dec = json.NewDecoder(rd)
+	return rr, nil
+}
+
+// Next returns the next record or io.EOF
+func (rr *RunReader) Next() (*RunRecord, error) {
+	var rec RunRecord
+	if err := rr.dec.Decode(&rec); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// Close closes the file
+func (rr *RunReader) Close() error {
+	if rr.gz != nil {
+		_ = rr.gz.Close()
+	}
+	return rr.f.Close()
+}
+
+// RecordedRun is a fully loaded run file
+type RecordedRun struct {
+	Meta      *RunMeta
+	Responses []*Response
+	Stats     []*RunStats
+}
+
+// LoadRun reads all records of a run file
+func LoadRun(path string) (*RecordedRun, error) {
+	rr, err := OpenRun(path)
+	if err != nil {
+		return nil, err
+	}
+	defer rr.Close()
+	run := &RecordedRun{}
+	for {
+		rec, err := rr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read run file %s: %w", path, err)
+		}
+		switch rec.Type {
+		case RecordTypeMeta:
+			// the final meta record has schedule segments with start and end times
+			run.Meta = rec.Meta
+		case RecordTypeResponse:
+			run.Responses = append(run.Responses, rec.Response)
+		case RecordTypeStats:
+			run.Stats = append(run.Stats, rec.Stats)
+		}
+	}
+	if run.Meta == nil || run.Meta.Config == nil {
+		return nil, ErrNoRunMeta
+	}
+	return run, nil
+}
+
+// Generator returns an offline Generator with the recorded config, responses, stats and latency,
+// it can't be run but can be passed to anything that reads generator results, ex.: benchspy.NewStandardDirectQueryExecutor
+func (run *RecordedRun) Generator() *Generator {
+	n := max(len(run.Responses), 1)
+	g := &Generator{
+		Cfg: run.Meta.Config,
+		responsesData: &ResponseData{
+			okDataMu:        &sync.Mutex{},
+			OKData:          NewSliceBuffer[any](n),
+			okResponsesMu:   &sync.Mutex{},
+			OKResponses:     NewSliceBuffer[*Response](n),
+			failResponsesMu: &sync.Mutex{},
+			FailResponses:   NewSliceBuffer[*Response](n),
+		},
+		errsMu: &sync.Mutex{},
+		errs:   NewSliceBuffer[string](n),
+		stats:  &Stats{Latency: NewLatencyStats()},
+	}
+	for _, res := range run.Responses {
+		g.stats.Latency.Record(res)
+		g.stats.recordCorrectedDuration(res)
+		switch {
+		case res.Failed || res.Timeout:
+			g.stats.RunFailed.Store(true)
+			g.stats.Failed.Add(1)
+			if res.Timeout {
+				g.stats.CallTimeout.Add(1)
+			}
+			g.errs.Append(res.Error)
+			g.responsesData.FailResponses.Append(res)
+		default:
+			g.stats.Success.Add(1)
+			g.responsesData.OKData.Append(res.Data)
+			g.responsesData.OKResponses.Append(res)
+		}
+	}
+	return g
+}
@@ -0,0 +1,91 @@
+package wasp
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSmokeRunRecorder(t *testing.T) {
+	t.Parallel()
+	for _, name := range []string{"run.jsonl", "run.jsonl.gz"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(t.TempDir(), "runs", name)
+			gen, err := NewGenerator(&Config{
+				T:                 t,
+				GenName:           "recorded",
+				LoadType:          RPS,
+				Labels:            map[string]string{"branch": "main"},
+				StatsPollInterval: 500 * time.Millisecond,
+				Schedule:          Plain(20, 2*time.Second),
+				Gun:               NewMockGun(&MockGunConfig{CallSleep: 5 * time.Millisecond, FailRatio: 20}),
+				// successful responses are not kept in memory but still recorded
+				SamplerConfig: &SamplerConfig{SuccessfulCallResultRecordRatio: 1},
+				Recorder:      &RecorderConfig{Path: path},
+			})
+			require.NoError(t, err)
+			gen.Run(true)
+			stats := gen.Stats()
+
+			run, err := LoadRun(path)
+			require.NoError(t, err)
+			require.Equal(t, "recorded", run.Meta.Config.GenName)
+			require.Equal(t, RPS, run.Meta.Config.LoadType)
+			require.Equal(t, int64(20), run.Meta.Config.Schedule[0].From)
+			require.Equal(t, "main", run.Meta.Labels["branch"])
+			require.False(t, run.Meta.Config.Schedule[0].StartTime.IsZero())
+			require.False(t, run.Meta.Config.Schedule[0].EndTime.IsZero())
+			require.NotNil(t, run.Meta.FinishedAt)
+			require.Len(t, run.Responses, int(stats.Latency.Total().Count))
+			require.Less(t, len(gen.GetData().OKResponses.Data), len(run.Responses)-int(stats.Failed.Load()))
+			require.GreaterOrEqual(t, len(run.Stats), 2)
+			last := run.Stats[len(run.Stats)-1].Stats
+			require.Equal(t, float64(stats.Failed.Load()), last["failed"])
+
+			offline := run.Generator()
+			require.Equal(t, int64(len(run.Responses)), offline.Stats().Success.Load()+offline.Stats().Failed.Load())
+			require.Equal(t, stats.Failed.Load(), offline.Stats().Failed.Load())
+			require.Len(t, offline.GetData().FailResponses.Data, int(stats.Failed.Load()))
+			require.Equal(t, stats.Latency.Total(), offline.Stats().Latency.Total())
+		})
+	}
+}
+
+func TestRunReader(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "run.jsonl")
+	r, err := NewRunRecorder(&RecorderConfig{Path: path})
+	require.NoError(t, err)
+	r.RecordMeta(&RunMeta{Config: &Config{GenName: "g"}})
+	r.RecordResponse(&Response{Group: "a", Duration: time.Millisecond})
+	r.RecordStats(map[string]any{"success": 1})
+	require.NoError(t, r.Close())
+
+	rr, err := OpenRun(path)
+	require.NoError(t, err)
+	defer rr.Close()
+	var types []string
+	for {
+		rec, err := rr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		types = append(types, rec.Type)
+	}
+	require.Equal(t, []string{RecordTypeMeta, RecordTypeResponse, RecordTypeStats}, types)
+
+	_, err = NewRunRecorder(&RecorderConfig{})
+	require.ErrorIs(t, err, ErrNoRecordPath)
+	empty := filepath.Join(t.TempDir(), "empty.jsonl")
+	r, err = NewRunRecorder(&RecorderConfig{Path: empty})
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	_, err = LoadRun(empty)
+	require.ErrorIs(t, err, ErrNoRunMeta)
+}
@@ -71,3 +71,58 @@ func CombineAndRepeat(times int, segs ...[]*Segment) []*Segment {
 	}
 	return acc
 }
+
+// Poisson creates a single Segment with an average of `rate` calls per RateLimitUnitDuration,
+// where inter-arrival times are exponentially distributed to model bursty open-model traffic.
+func Poisson(rate int64, duration time.Duration) []*Segment {
+	return []*Segment{
+		{
+			From:     rate,
+			Duration: duration,
+			Type:     SegmentType_Poisson,
+		},
+	}
+}
+
+// PoissonCurve creates a single Segment with exponentially distributed inter-arrival times
+// whose average rate follows the provided curve (non-homogeneous Poisson process).
+func PoissonCurve(curve RateCurve, duration time.Duration) []*Segment {
+	return []*Segment{
+		{
+			From:     int64(curve(0)),
+			Duration: duration,
+			Type:     SegmentType_Poisson,
+			Curve:    curve,
+		},
+	}
+}
+
+// Curve creates a single Segment where the rate follows an arbitrary function of the time elapsed since the segment start.
+// Calls are evenly spaced according to the instantaneous rate.
+func Curve(curve RateCurve, duration time.Duration) []*Segment {
+	return []*Segment{
+		{
+			From:     int64(curve(0)),
+			Duration: duration,
+			Type:     SegmentType_Curve,
+			Curve:    curve,
+		},
+	}
+}
+
+// Ramp creates a Curve segment that linearly changes the rate from `from` to `to` over `duration`.
+// Unlike Steps, the rate changes smoothly on every call.
+func Ramp(from, to int64, duration time.Duration) []*Segment {
+	return Curve(LinearCurve(from, to, duration), duration)
+}
+
+// Sine creates a Curve segment oscillating around `base` rate with `amplitude` and `period`.
+func Sine(base, amplitude int64, period, duration time.Duration) []*Segment {
+	return Curve(SineCurve(base, amplitude, period), duration)
+}
+
+// Spike creates a Curve segment holding `base` rate with a single burst of `peak` rate
+// starting at `spikeAt` and lasting `spikeDuration`.
+func Spike(base, peak int64, spikeAt, spikeDuration, duration time.Duration) []*Segment {
+	return Curve(SpikeCurve(base, peak, spikeAt, spikeDuration), duration)
+}
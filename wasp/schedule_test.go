@@ -125,3 +125,35 @@ func TestSmokeSchedules(t *testing.T) {
 		})
 	}
 }
+
+func TestSmokeArrivalCurves(t *testing.T) {
+	t.Parallel()
+	t.Run("linear ramp", func(t *testing.T) {
+		c := LinearCurve(0, 100, 10*time.Second)
+		require.Equal(t, float64(0), c(0))
+		require.Equal(t, float64(50), c(5*time.Second))
+		require.Equal(t, float64(100), c(10*time.Second))
+		require.Equal(t, float64(100), c(20*time.Second))
+	})
+	t.Run("sine never goes below zero", func(t *testing.T) {
+		c := SineCurve(10, 20, 4*time.Second)
+		require.InDelta(t, 10, c(0), 0.001)
+		require.InDelta(t, 30, c(1*time.Second), 0.001)
+		require.Equal(t, float64(0), c(3*time.Second))
+	})
+	t.Run("spike", func(t *testing.T) {
+		c := SpikeCurve(10, 100, 2*time.Second, 1*time.Second)
+		require.Equal(t, float64(10), c(1*time.Second))
+		require.Equal(t, float64(100), c(2*time.Second))
+		require.Equal(t, float64(10), c(3*time.Second))
+	})
+	t.Run("segments", func(t *testing.T) {
+		p := Poisson(100, 1*time.Second)
+		require.Equal(t, []*Segment{{From: 100, Duration: 1 * time.Second, Type: SegmentType_Poisson}}, p)
+		r := Ramp(10, 20, 1*time.Second)
+		require.Len(t, r, 1)
+		require.Equal(t, int64(10), r[0].From)
+		require.Equal(t, SegmentType_Curve, r[0].Type)
+		require.NotNil(t, r[0].Curve)
+	})
+}
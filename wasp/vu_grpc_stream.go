@@ -0,0 +1,156 @@
+package wasp
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// GRPCStreamVUConfig configures a built-in virtual user keeping a long-lived gRPC server-streaming call
+type GRPCStreamVUConfig struct {
+	// Target is a gRPC server address, ex.: "localhost:50051"
+	Target string `toml:"target" yaml:"target"`
+	// Method is a full name of a server-streaming method, ex.: "/package.Service/Subscribe"
+	Method string `toml:"method" yaml:"method"`
+	// PayloadHex is a hex encoded protobuf request message, empty message if not set
+	PayloadHex string `toml:"payload_hex" yaml:"payload_hex"`
+	// Metadata is gRPC request metadata
+	Metadata map[string]string `toml:"metadata" yaml:"metadata"`
+	// MaxGap is the longest expected time between messages, longer gaps are reported as timeouts
+	MaxGap FileDuration `toml:"max_gap" yaml:"max_gap"`
+	// ReconnectInterval is the time between reconnection attempts, defaults to DefaultStreamReconnectInterval
+	ReconnectInterval FileDuration `toml:"reconnect_interval" yaml:"reconnect_interval"`
+	// Group prefixes Response.Group of all responses of this virtual user, see StreamGroupMessage
+	Group string `toml:"group" yaml:"group"`
+	// MessageTime returns the time the raw protobuf message was sent at to measure its latency
+	MessageTime func(data []byte) (time.Time, error) `toml:"-" yaml:"-"`
+}
+
+// Validate checks required fields
+func (c *GRPCStreamVUConfig) Validate() error {
+	if c.Target == "" {
+		return fmt.Errorf("grpc stream vu target is empty")
+	}
+	if c.Method == "" {
+		return fmt.Errorf("grpc stream vu method is empty")
+	}
+	if _, err := hex.DecodeString(c.PayloadHex); err != nil {
+		return fmt.Errorf("grpc stream vu payload_hex is invalid: %w", err)
+	}
+	if c.MaxGap < 0 || c.ReconnectInterval < 0 {
+		return fmt.Errorf("grpc stream vu max_gap and reconnect_interval can't be negative")
+	}
+	return nil
+}
+
+// GRPCStreamVU is a built-in VirtualUser receiving messages of a server-streaming gRPC call without generated code,
+// it reports message latency, gaps between messages and reconnects as responses of different groups.
+// A stream ended by the server is opened again and reported as a reconnect
+type GRPCStreamVU struct {
+	*streamVU
+	cfg     *GRPCStreamVUConfig
+	payload []byte
+	conn    *grpc.ClientConn
+}
+
+// NewGRPCStreamVU creates a new GRPCStreamVU
+func NewGRPCStreamVU(cfg *GRPCStreamVUConfig) (*GRPCStreamVU, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return newGRPCStreamVU(cfg), nil
+}
+
+func newGRPCStreamVU(cfg *GRPCStreamVUConfig) *GRPCStreamVU {
+	payload, _ := hex.DecodeString(cfg.PayloadHex)
+	vu := &GRPCStreamVU{cfg: cfg, payload: payload}
+	reconnectInterval := time.Duration(cfg.ReconnectInterval)
+	if reconnectInterval == 0 {
+		reconnectInterval = DefaultStreamReconnectInterval
+	}
+	vu.streamVU = &streamVU{
+		VUControl:         NewVUControl(),
+		group:             cfg.Group,
+		maxGap:            time.Duration(cfg.MaxGap),
+		reconnectInterval: reconnectInterval,
+		messageTime:       cfg.MessageTime,
+		connect:           vu.connect,
+	}
+	return vu
+}
+
+// Clone creates a new GRPCStreamVU with the same configuration
+func (m *GRPCStreamVU) Clone(_ *Generator) VirtualUser {
+	return newGRPCStreamVU(m.cfg)
+}
+
+// Setup creates an insecure connection to the target and opens the stream
+func (m *GRPCStreamVU) Setup(l *Generator) error {
+	var err error
+	m.conn, err = grpc.NewClient(m.cfg.Target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return fmt.Errorf("failed to create grpc client: %w", err)
+	}
+	if err := m.start(); err != nil {
+		l.Log.Error().Err(err).Msg("failed to open stream from virtual user")
+		_ = m.conn.Close()
+		return err
+	}
+	return nil
+}
+
+// Teardown closes the stream and the connection
+func (m *GRPCStreamVU) Teardown(_ *Generator) error {
+	m.stop()
+	return m.conn.Close()
+}
+
+// Call reports messages received since the previous call
+func (m *GRPCStreamVU) Call(l *Generator) {
+	m.call(l)
+}
+
+type grpcStreamConn struct {
+	stream grpc.ClientStream
+	cancel context.CancelFunc
+}
+
+func (c *grpcStreamConn) recv(_ context.Context) ([]byte, error) {
+	var msg []byte
+	if err := c.stream.RecvMsg(&msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (c *grpcStreamConn) close() error {
+	c.cancel()
+	return nil
+}
+
+func (m *GRPCStreamVU) connect(ctx context.Context) (streamConn, error) {
+	streamCtx, cancel := context.WithCancel(ctx)
+	if len(m.cfg.Metadata) > 0 {
+		streamCtx = metadata.NewOutgoingContext(streamCtx, metadata.New(m.cfg.Metadata))
+	}
+	stream, err := m.conn.NewStream(streamCtx, &grpc.StreamDesc{ServerStreams: true}, m.cfg.Method, grpc.ForceCodec(rawCodec{}))
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to open stream: %w", err)
+	}
+	req := m.payload
+	if err := stream.SendMsg(&req); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	if err := stream.CloseSend(); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to close sending: %w", err)
+	}
+	return &grpcStreamConn{stream: stream, cancel: cancel}, nil
+}
@@ -0,0 +1,243 @@
+package wasp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Response groups of streaming virtual users, prefixed with the configured group, ex.: "heads_gap"
+const (
+	// StreamGroupMessage is a received message, Duration is its latency if the message time is known, otherwise the gap since the previous message
+	StreamGroupMessage = "message"
+	// StreamGroupGap is the time between two messages, it times out if it's longer than the max gap.
+	// A stream which stops delivering messages is reported as a timed out gap every max gap until the next message
+	StreamGroupGap = "gap"
+	// StreamGroupReconnect is a reconnection after the stream was broken, Duration is the time it took to connect again
+	StreamGroupReconnect = "reconnect"
+)
+
+const (
+	DefaultStreamReconnectInterval = time.Second
+	streamEventsBuffer             = 1024
+)
+
+// streamConn is a connected stream, recv blocks until the next message
+type streamConn interface {
+	recv(ctx context.Context) ([]byte, error)
+	close() error
+}
+
+// streamVU is the shared part of virtual users receiving long-lived streams. A reader goroutine receives messages
+// and reconnects when the stream breaks, calls forward responses it produced to the generator
+type streamVU struct {
+	*VUControl
+	group             string
+	maxGap            time.Duration
+	reconnectInterval time.Duration
+	messageTime       func(data []byte) (time.Time, error)
+	connect           func(ctx context.Context) (streamConn, error)
+
+	events chan *Response
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     *sync.WaitGroup
+	// mu guards lastMessage and lastStall, which are updated by the reader and checked by calls
+	mu          *sync.Mutex
+	lastMessage time.Time
+	// lastStall is when the current silence was last reported, zero if it wasn't
+	lastStall time.Time
+}
+
+func (s *streamVU) groupName(kind string) string {
+	if s.group == "" {
+		return kind
+	}
+	return s.group + "_" + kind
+}
+
+// start connects to the stream and starts receiving messages
+func (s *streamVU) start() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	conn, err := s.connect(ctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+	s.events = make(chan *Response, streamEventsBuffer)
+	s.ctx = ctx
+	s.cancel = cancel
+	s.wg = &sync.WaitGroup{}
+	s.mu = &sync.Mutex{}
+	s.lastMessage = time.Now()
+	s.wg.Add(1)
+	go s.receive(ctx, conn)
+	return nil
+}
+
+// stop stops receiving and closes the stream
+func (s *streamVU) stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+}
+
+func (s *streamVU) receive(ctx context.Context, conn streamConn) {
+	defer s.wg.Done()
+	defer func() {
+		if conn != nil {
+			_ = conn.close()
+		}
+	}()
+	for {
+		data, err := conn.recv(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			_ = conn.close()
+			conn = s.reconnect(ctx, err)
+			if conn == nil {
+				return
+			}
+			continue
+		}
+		s.onMessage(ctx, data)
+	}
+}
+
+// reconnect connects again until it succeeds or the virtual user is stopped, every attempt is reported
+func (s *streamVU) reconnect(ctx context.Context, cause error) streamConn {
+	for {
+		startedAt := time.Now()
+		conn, err := s.connect(ctx)
+		if ctx.Err() != nil {
+			if conn != nil {
+				_ = conn.close()
+			}
+			return nil
+		}
+		r := &Response{Duration: time.Since(startedAt), Group: s.groupName(StreamGroupReconnect)}
+		if err == nil {
+			r.Data = cause.Error()
+			s.emit(ctx, r)
+			return conn
+		}
+		r.Failed = true
+		r.Error = err.Error()
+		s.emit(ctx, r)
+		select {
+		case <-time.After(s.reconnectInterval):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// onMessage reports the message and the gap since the previous one, responses have no StartedAt,
+// so the generator keeps their Duration instead of measuring it when they are forwarded
+func (s *streamVU) onMessage(ctx context.Context, data []byte) {
+	receivedAt := time.Now()
+	s.mu.Lock()
+	gap := receivedAt.Sub(s.lastMessage)
+	s.lastMessage = receivedAt
+	stalled := !s.lastStall.IsZero()
+	s.lastStall = time.Time{}
+	s.mu.Unlock()
+
+	message := &Response{Duration: gap, Group: s.groupName(StreamGroupMessage), Data: string(data)}
+	if s.messageTime != nil {
+		sentAt, err := s.messageTime(data)
+		if err != nil {
+			message.Failed = true
+			message.Error = err.Error()
+		} else {
+			message.Duration = receivedAt.Sub(sentAt)
+		}
+	}
+	gapResponse := &Response{Duration: gap, Group: s.groupName(StreamGroupGap)}
+	// reported as a timeout, so the generator keeps gaps longer than the call timeout,
+	// unless the stall was already reported while waiting for this message
+	if s.maxGap > 0 && gap > s.maxGap && !stalled {
+		gapResponse.Timeout = true
+		gapResponse.Error = fmt.Sprintf("no messages for %s, max gap is %s", gap, s.maxGap)
+	}
+	s.emit(ctx, message)
+	s.emit(ctx, gapResponse)
+}
+
+func (s *streamVU) emit(ctx context.Context, r *Response) {
+	select {
+	case s.events <- r:
+	case <-ctx.Done():
+	}
+}
+
+// call forwards responses received since the last call, it waits for at least one of them, but not longer than half of the call timeout
+// or until the current silence exceeds the max gap, then the stall is reported
+func (s *streamVU) call(l *Generator) {
+	wait := l.Cfg.CallTimeout / 2
+	if s.maxGap > 0 {
+		wait = min(wait, time.Until(s.nextStallCheck()))
+	}
+	select {
+	case r := <-s.events:
+		l.ResponsesChan <- r
+	case <-time.After(wait):
+		s.reportStall(l)
+		return
+	case <-s.ctx.Done():
+		return
+	}
+	for {
+		select {
+		case r := <-s.events:
+			l.ResponsesChan <- r
+		default:
+			s.reportStall(l)
+			return
+		}
+	}
+}
+
+// nextStallCheck returns when the current silence exceeds the max gap since the last message or the last stall report
+func (s *streamVU) nextStallCheck() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.nextStallCheckLocked()
+}
+
+func (s *streamVU) nextStallCheckLocked() time.Time {
+	from := s.lastMessage
+	if s.lastStall.After(from) {
+		from = s.lastStall
+	}
+	return from.Add(s.maxGap)
+}
+
+// reportStall reports a timed out gap if no messages were received for longer than the max gap, ex.: a subscription
+// which stays connected but stops delivering, otherwise the stall wouldn't be reported until the next message.
+// Stopped streams are not reported, the generator may still be waiting for a call when the virtual user is torn down
+func (s *streamVU) reportStall(l *Generator) {
+	if s.maxGap <= 0 || s.ctx.Err() != nil {
+		return
+	}
+	now := time.Now()
+	s.mu.Lock()
+	if now.Before(s.nextStallCheckLocked()) {
+		s.mu.Unlock()
+		return
+	}
+	silence := now.Sub(s.lastMessage)
+	s.lastStall = now
+	s.mu.Unlock()
+	l.ResponsesChan <- &Response{
+		Duration: silence,
+		Group:    s.groupName(StreamGroupGap),
+		Timeout:  true,
+		Error:    fmt.Sprintf("no messages for %s, max gap is %s", silence, s.maxGap),
+	}
+}
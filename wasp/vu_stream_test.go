@@ -0,0 +1,234 @@
+package wasp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// newHeadsServer replies to eth_subscribe and sends a head every interval, the first connection is closed after closeAfter heads
+func newHeadsServer(t *testing.T, interval time.Duration, closeAfter int, pause time.Duration) *httptest.Server {
+	var connections atomic.Int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		// nolint
+		defer c.Close(websocket.StatusNormalClosure, "")
+		first := connections.Add(1) == 1
+		ctx := r.Context()
+		if _, msg, err := c.Read(ctx); err != nil || !strings.Contains(string(msg), "eth_subscribe") {
+			return
+		}
+		if err := c.Write(ctx, websocket.MessageText, []byte(`{"jsonrpc":"2.0","id":1,"result":"0xabc"}`)); err != nil {
+			return
+		}
+		for i := 0; ; i++ {
+			if first && i == closeAfter {
+				return
+			}
+			if i == 5 {
+				time.Sleep(pause)
+			}
+			time.Sleep(interval)
+			head := fmt.Sprintf(`{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":"0xabc","result":{"timestamp":"0x%x"}}}`, time.Now().Unix())
+			if err := c.Write(ctx, websocket.MessageText, []byte(head)); err != nil {
+				return
+			}
+		}
+	}))
+}
+
+func TestSmokeWSSubscriptionVU(t *testing.T) {
+	t.Parallel()
+	srv := newHeadsServer(t, 20*time.Millisecond, 20, 300*time.Millisecond)
+	t.Cleanup(srv.Close)
+
+	vu, err := NewWSSubscriptionVU(&WSSubscriptionVUConfig{
+		URL:               strings.Replace(srv.URL, "http", "ws", 1),
+		SubscribeMessage:  `{"jsonrpc":"2.0","id":1,"method":"eth_subscribe","params":["newHeads"]}`,
+		SubscribeReply:    true,
+		MaxGap:            FileDuration(200 * time.Millisecond),
+		ReconnectInterval: FileDuration(50 * time.Millisecond),
+		Group:             "heads",
+		MessageTime:       EthHeadTime,
+	})
+	require.NoError(t, err)
+	gen, err := NewGenerator(&Config{
+		T:        t,
+		LoadType: VU,
+		Schedule: Plain(1, 2*time.Second),
+		VU:       vu,
+	})
+	require.NoError(t, err)
+	gen.Run(true)
+
+	latency := gen.Stats().Latency
+	require.GreaterOrEqual(t, latency.Group("heads_message").Count, int64(40))
+	// both pauses are reported as stalls while waiting, then as gaps when the 6th head arrives
+	require.Equal(t, latency.Group("heads_message").Count+2, latency.Group("heads_gap").Count)
+	// heads have a precision of one second
+	require.LessOrEqual(t, latency.Group("heads_message").Max, 1100*time.Millisecond)
+	require.Equal(t, int64(1), latency.Group("heads_reconnect").Count)
+	// both connections pause before the 6th head, every pause times out once
+	require.Equal(t, int64(2), gen.Stats().CallTimeout.Load())
+	for _, e := range gen.Errors() {
+		require.Contains(t, e, "max gap is 200ms")
+	}
+}
+
+func TestSmokeWSSubscriptionVUStalled(t *testing.T) {
+	t.Parallel()
+	// the subscription stays connected but stops delivering heads after the first 3
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		// nolint
+		defer c.Close(websocket.StatusNormalClosure, "")
+		ctx := r.Context()
+		if _, _, err := c.Read(ctx); err != nil {
+			return
+		}
+		for i := 0; i < 3; i++ {
+			time.Sleep(20 * time.Millisecond)
+			if err := c.Write(ctx, websocket.MessageText, []byte(`{"head":1}`)); err != nil {
+				return
+			}
+		}
+		// blocks until the client disconnects
+		_, _, _ = c.Read(ctx)
+	}))
+	t.Cleanup(srv.Close)
+
+	vu, err := NewWSSubscriptionVU(&WSSubscriptionVUConfig{
+		URL:              strings.Replace(srv.URL, "http", "ws", 1),
+		SubscribeMessage: `{"jsonrpc":"2.0","id":1,"method":"eth_subscribe","params":["newHeads"]}`,
+		MaxGap:           FileDuration(200 * time.Millisecond),
+		Group:            "heads",
+	})
+	require.NoError(t, err)
+	gen, err := NewGenerator(&Config{
+		T:        t,
+		LoadType: VU,
+		Schedule: Plain(1, 1*time.Second),
+		VU:       vu,
+	})
+	require.NoError(t, err)
+	gen.Run(true)
+
+	latency := gen.Stats().Latency
+	require.Equal(t, int64(3), latency.Group("heads_message").Count)
+	require.Equal(t, int64(0), latency.Group("heads_reconnect").Count)
+	// reported every max gap while no heads arrive
+	require.GreaterOrEqual(t, gen.Stats().CallTimeout.Load(), int64(3))
+	require.NotEmpty(t, gen.Errors())
+	for _, e := range gen.Errors() {
+		require.Contains(t, e, "no messages for")
+		require.Contains(t, e, "max gap is 200ms")
+	}
+}
+
+// streamHandler sends messages messages every interval and ends the stream
+func streamHandler(messages int, interval time.Duration) grpc.StreamHandler {
+	return func(_ any, stream grpc.ServerStream) error {
+		var req []byte
+		if err := stream.RecvMsg(&req); err != nil {
+			return err
+		}
+		for i := 0; i < messages; i++ {
+			time.Sleep(interval)
+			msg := []byte{byte(i)}
+			if err := stream.SendMsg(&msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestSmokeGRPCStreamVU(t *testing.T) {
+	t.Parallel()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer(grpc.ForceServerCodec(rawCodec{}), grpc.UnknownServiceHandler(streamHandler(10, 20*time.Millisecond)))
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+
+	vu, err := NewGRPCStreamVU(&GRPCStreamVUConfig{
+		Target:            lis.Addr().String(),
+		Method:            "/test.Stream/Subscribe",
+		ReconnectInterval: FileDuration(10 * time.Millisecond),
+		Group:             "stream",
+	})
+	require.NoError(t, err)
+	gen, err := NewGenerator(&Config{
+		T:        t,
+		LoadType: VU,
+		Schedule: Plain(2, time.Second),
+		VU:       vu,
+	})
+	require.NoError(t, err)
+	gen.Run(true)
+
+	latency := gen.Stats().Latency
+	require.GreaterOrEqual(t, latency.Group("stream_message").Count, int64(60))
+	// streams end after 10 messages and are opened again
+	require.GreaterOrEqual(t, latency.Group("stream_reconnect").Count, int64(4))
+	require.Equal(t, int64(0), gen.Stats().Failed.Load())
+}
+
+func TestStreamVUValidation(t *testing.T) {
+	t.Parallel()
+	_, err := NewWSSubscriptionVU(&WSSubscriptionVUConfig{})
+	require.ErrorContains(t, err, "ws subscription vu url is empty")
+	_, err = NewGRPCStreamVU(&GRPCStreamVUConfig{Target: "localhost:1"})
+	require.ErrorContains(t, err, "grpc stream vu method is empty")
+	_, err = NewGRPCStreamVU(&GRPCStreamVUConfig{Target: "localhost:1", Method: "/a/b", MaxGap: -1})
+	require.ErrorContains(t, err, "can't be negative")
+
+	ts, err := EthHeadTime([]byte(`{"params":{"result":{"timestamp":"0x65f0a1b0"}}}`))
+	require.NoError(t, err)
+	require.Equal(t, int64(0x65f0a1b0), ts.Unix())
+	_, err = EthHeadTime([]byte(`{"jsonrpc":"2.0","id":1,"result":"0xabc"}`))
+	require.ErrorContains(t, err, "no timestamp")
+}
+
+func TestStreamVUReconnectsUntilStopped(t *testing.T) {
+	t.Parallel()
+	var attempts atomic.Int32
+	s := &streamVU{
+		VUControl:         NewVUControl(),
+		reconnectInterval: 10 * time.Millisecond,
+		connect: func(_ context.Context) (streamConn, error) {
+			if attempts.Add(1) == 1 {
+				return &failingStreamConn{}, nil
+			}
+			return nil, fmt.Errorf("refused")
+		},
+	}
+	require.NoError(t, s.start())
+	require.Eventually(t, func() bool { return attempts.Load() > 3 }, 5*time.Second, 10*time.Millisecond)
+	s.stop()
+	r := <-s.events
+	require.Equal(t, StreamGroupReconnect, r.Group)
+	require.True(t, r.Failed)
+	require.Equal(t, "refused", r.Error)
+}
+
+type failingStreamConn struct{}
+
+func (failingStreamConn) recv(_ context.Context) ([]byte, error) { return nil, fmt.Errorf("broken") }
+func (failingStreamConn) close() error                           { return nil }
@@ -0,0 +1,89 @@
+package wasp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+// WSVUConfig configures a built-in WebSocket virtual user
+type WSVUConfig struct {
+	// URL is a WebSocket endpoint, ex.: "ws://localhost:8546"
+	URL string `toml:"url" yaml:"url"`
+	// Headers are handshake request headers
+	Headers map[string]string `toml:"headers" yaml:"headers"`
+	// Message is sent on every call, if empty the call only waits for the next message
+	Message string `toml:"message" yaml:"message"`
+	// Group is a Response.Group for all calls of this virtual user
+	Group string `toml:"group" yaml:"group"`
+}
+
+// Validate checks required fields
+func (c *WSVUConfig) Validate() error {
+	if c.URL == "" {
+		return fmt.Errorf("ws vu url is empty")
+	}
+	return nil
+}
+
+// WSVU is a built-in VirtualUser keeping one WebSocket connection, every call sends a message and waits for a reply
+type WSVU struct {
+	*VUControl
+	cfg  *WSVUConfig
+	conn *websocket.Conn
+}
+
+// NewWSVU creates a new WSVU
+func NewWSVU(cfg *WSVUConfig) (*WSVU, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &WSVU{VUControl: NewVUControl(), cfg: cfg}, nil
+}
+
+// Clone creates a new WSVU with the same configuration
+func (m *WSVU) Clone(_ *Generator) VirtualUser {
+	return &WSVU{VUControl: NewVUControl(), cfg: m.cfg}
+}
+
+// Setup connects to the WebSocket endpoint
+func (m *WSVU) Setup(l *Generator) error {
+	h := http.Header{}
+	for k, v := range m.cfg.Headers {
+		h.Set(k, v)
+	}
+	var err error
+	m.conn, _, err = websocket.Dial(context.Background(), m.cfg.URL, &websocket.DialOptions{HTTPHeader: h})
+	if err != nil {
+		l.Log.Error().Err(err).Msg("failed to connect from virtual user")
+		return err
+	}
+	return nil
+}
+
+// Teardown closes the WebSocket connection
+func (m *WSVU) Teardown(_ *Generator) error {
+	return m.conn.Close(websocket.StatusNormalClosure, "")
+}
+
+// Call sends the configured message and waits for the next message from the server
+func (m *WSVU) Call(l *Generator) {
+	startedAt := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), l.Cfg.CallTimeout)
+	defer cancel()
+	if m.cfg.Message != "" {
+		if err := m.conn.Write(ctx, websocket.MessageText, []byte(m.cfg.Message)); err != nil {
+			l.ResponsesChan <- &Response{StartedAt: &startedAt, Group: m.cfg.Group, Failed: true, Error: err.Error()}
+			return
+		}
+	}
+	_, data, err := m.conn.Read(ctx)
+	if err != nil {
+		l.ResponsesChan <- &Response{StartedAt: &startedAt, Group: m.cfg.Group, Failed: true, Error: err.Error()}
+		return
+	}
+	l.ResponsesChan <- &Response{StartedAt: &startedAt, Group: m.cfg.Group, Data: string(data)}
+}
@@ -0,0 +1,163 @@
+package wasp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+// WSSubscriptionVUConfig configures a built-in virtual user keeping a long-lived WebSocket subscription
+type WSSubscriptionVUConfig struct {
+	// URL is a WebSocket endpoint, ex.: "ws://localhost:8546"
+	URL string `toml:"url" yaml:"url"`
+	// Headers are handshake request headers
+	Headers map[string]string `toml:"headers" yaml:"headers"`
+	// SubscribeMessage is sent after every connect, ex.: {"jsonrpc":"2.0","id":1,"method":"eth_subscribe","params":["newHeads"]}
+	SubscribeMessage string `toml:"subscribe_message" yaml:"subscribe_message"`
+	// SubscribeReply skips the first message after subscribing, ex.: a JSON-RPC reply with the subscription ID
+	SubscribeReply bool `toml:"subscribe_reply" yaml:"subscribe_reply"`
+	// MaxGap is the longest expected time between messages, longer gaps are reported as timeouts
+	MaxGap FileDuration `toml:"max_gap" yaml:"max_gap"`
+	// ReconnectInterval is the time between reconnection attempts, defaults to DefaultStreamReconnectInterval
+	ReconnectInterval FileDuration `toml:"reconnect_interval" yaml:"reconnect_interval"`
+	// Group prefixes Response.Group of all responses of this virtual user, see StreamGroupMessage
+	Group string `toml:"group" yaml:"group"`
+	// MessageTime returns the time the message was sent at to measure its latency, ex.: EthHeadTime
+	MessageTime func(data []byte) (time.Time, error) `toml:"-" yaml:"-"`
+}
+
+// Validate checks required fields
+func (c *WSSubscriptionVUConfig) Validate() error {
+	if c.URL == "" {
+		return fmt.Errorf("ws subscription vu url is empty")
+	}
+	if c.MaxGap < 0 || c.ReconnectInterval < 0 {
+		return fmt.Errorf("ws subscription vu max_gap and reconnect_interval can't be negative")
+	}
+	return nil
+}
+
+// WSSubscriptionVU is a built-in VirtualUser receiving messages of a WebSocket subscription, it reports message latency,
+// gaps between messages and reconnects as responses of different groups
+type WSSubscriptionVU struct {
+	*streamVU
+	cfg *WSSubscriptionVUConfig
+}
+
+// NewWSSubscriptionVU creates a new WSSubscriptionVU
+func NewWSSubscriptionVU(cfg *WSSubscriptionVUConfig) (*WSSubscriptionVU, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return newWSSubscriptionVU(cfg), nil
+}
+
+func newWSSubscriptionVU(cfg *WSSubscriptionVUConfig) *WSSubscriptionVU {
+	vu := &WSSubscriptionVU{cfg: cfg}
+	reconnectInterval := time.Duration(cfg.ReconnectInterval)
+	if reconnectInterval == 0 {
+		reconnectInterval = DefaultStreamReconnectInterval
+	}
+	vu.streamVU = &streamVU{
+		VUControl:         NewVUControl(),
+		group:             cfg.Group,
+		maxGap:            time.Duration(cfg.MaxGap),
+		reconnectInterval: reconnectInterval,
+		messageTime:       cfg.MessageTime,
+		connect:           vu.connect,
+	}
+	return vu
+}
+
+// Clone creates a new WSSubscriptionVU with the same configuration
+func (m *WSSubscriptionVU) Clone(_ *Generator) VirtualUser {
+	return newWSSubscriptionVU(m.cfg)
+}
+
+// Setup connects and subscribes
+func (m *WSSubscriptionVU) Setup(l *Generator) error {
+	if err := m.start(); err != nil {
+		l.Log.Error().Err(err).Msg("failed to subscribe from virtual user")
+		return err
+	}
+	return nil
+}
+
+// Teardown closes the subscription
+func (m *WSSubscriptionVU) Teardown(_ *Generator) error {
+	m.stop()
+	return nil
+}
+
+// Call reports messages received since the previous call
+func (m *WSSubscriptionVU) Call(l *Generator) {
+	m.call(l)
+}
+
+type wsStreamConn struct {
+	conn *websocket.Conn
+}
+
+func (c *wsStreamConn) recv(ctx context.Context) ([]byte, error) {
+	_, data, err := c.conn.Read(ctx)
+	return data, err
+}
+
+func (c *wsStreamConn) close() error {
+	return c.conn.Close(websocket.StatusNormalClosure, "")
+}
+
+func (m *WSSubscriptionVU) connect(ctx context.Context) (streamConn, error) {
+	h := http.Header{}
+	for k, v := range m.cfg.Headers {
+		h.Set(k, v)
+	}
+	conn, _, err := websocket.Dial(ctx, m.cfg.URL, &websocket.DialOptions{HTTPHeader: h})
+	if err != nil {
+		return nil, err
+	}
+	// subscriptions can be verbose, ex.: full blocks
+	conn.SetReadLimit(-1)
+	if m.cfg.SubscribeMessage != "" {
+		if err := conn.Write(ctx, websocket.MessageText, []byte(m.cfg.SubscribeMessage)); err != nil {
+			_ = conn.Close(websocket.StatusInternalError, "")
+			return nil, fmt.Errorf("failed to subscribe: %w", err)
+		}
+	}
+	if m.cfg.SubscribeReply {
+		if _, _, err := conn.Read(ctx); err != nil {
+			_ = conn.Close(websocket.StatusInternalError, "")
+			return nil, fmt.Errorf("failed to read subscription reply: %w", err)
+		}
+	}
+	return &wsStreamConn{conn: conn}, nil
+}
+
+// EthHeadTime returns the timestamp of a block header of an eth_subscribe newHeads notification, it has a precision of one second
+func EthHeadTime(data []byte) (time.Time, error) {
+	var notification struct {
+		Params struct {
+			Result struct {
+				Timestamp string `json:"timestamp"`
+			} `json:"result"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(data, &notification); err != nil {
+		return time.Time{}, fmt.Errorf("failed to decode newHeads notification: %w", err)
+	}
+	ts := notification.Params.Result.Timestamp
+	if ts == "" {
+		return time.Time{}, fmt.Errorf("newHeads notification has no timestamp")
+	}
+	sec, err := strconv.ParseInt(strings.TrimPrefix(ts, "0x"), 16, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid block timestamp %s: %w", ts, err)
+	}
+	return time.Unix(sec, 0), nil
+}
@@ -48,6 +48,8 @@ var (
 	ErrNoGun                  = errors.New("rps load scheduleSegments selected but gun implementation is nil")
 	ErrNoVU                   = errors.New("vu load scheduleSegments selected but vu implementation is nil")
 	ErrInvalidLabels          = errors.New("invalid Loki labels, labels should be [a-z][A-Z][0-9] and _")
+	ErrMissingSegmentCurve    = errors.New("Segment Curve must be set for curve segments")
+	ErrArrivalSegmentNotRPS   = errors.New("poisson and curve segments can only be used with wasp.RPS load type")
 )
 
 // Gun is basic interface for some synthetic load test implementation
@@ -102,6 +104,10 @@ type Response struct {
 	Group      string        `json:"group"`
 	Data       interface{}   `json:"data,omitempty"`
 	Error      string        `json:"error,omitempty"`
+	// IntendedStartedAt is the time the call was scheduled to start, set only when Config.CorrectCoordinatedOmission is enabled
+	IntendedStartedAt *time.Time `json:"intended_started_at,omitempty"`
+	// CorrectedDuration is the duration measured from IntendedStartedAt, it includes the queueing delay hidden from Duration
+	CorrectedDuration time.Duration `json:"corrected_duration,omitempty"`
 }
 
 type ScheduleType string
@@ -116,6 +122,10 @@ type SegmentType string
 const (
 	SegmentType_Plain SegmentType = "plain"
 	SegmentType_Steps SegmentType = "steps"
+	// SegmentType_Poisson paces calls with exponentially distributed inter-arrival times, RPS schedules only
+	SegmentType_Poisson SegmentType = "poisson"
+	// SegmentType_Curve paces calls following a RateCurve, RPS schedules only
+	SegmentType_Curve SegmentType = "curve"
 )
 
 // Segment load test schedule segment
@@ -125,6 +135,8 @@ type Segment struct {
 	Type      SegmentType   `json:"type"`
 	StartTime time.Time     `json:"time_start"`
 	EndTime   time.Time     `json:"time_end"`
+	// Curve defines the rate over time for SegmentType_Curve, optional for SegmentType_Poisson
+	Curve RateCurve `json:"-"`
 }
 
 // Validate checks that the Segment has a valid starting point and duration.
@@ -140,10 +152,18 @@ func (ls *Segment) Validate() error {
 	if ls.Type == "" {
 		return ErrMissingSegmentType
 	}
+	if ls.Type == SegmentType_Curve && ls.Curve == nil {
+		return ErrMissingSegmentCurve
+	}
 
 	return nil
 }
 
+// isArrival returns true if the segment is paced by an arrival curve instead of a fixed rate limiter
+func (ls *Segment) isArrival() bool {
+	return ls.Type == SegmentType_Poisson || ls.Type == SegmentType_Curve
+}
+
 // Config is for shared load test data and configuration
 type Config struct {
 	T                     *testing.T        `json:"-"`
@@ -152,6 +172,7 @@ type Config struct {
 	Labels                map[string]string `json:"-"`
 	LokiConfig            *LokiConfig       `json:"-"`
 	OTELConfig            *OTELConfig       `json:"-"`
+	PrometheusConfig      *PrometheusConfig `json:"-"`
 	Schedule              []*Segment        `json:"schedule"`
 	RateLimitUnitDuration time.Duration     `json:"rate_limit_unit_duration"`
 	CallResultBufLen      int               `json:"-"`
@@ -165,6 +186,13 @@ type Config struct {
 	Logger                zerolog.Logger    `json:"-"`
 	SharedData            any               `json:"-"`
 	SamplerConfig         *SamplerConfig    `json:"-"`
+	// CorrectCoordinatedOmission records the intended start time of every scheduled call in RPS mode
+	// and reports latency corrected for coordinated omission in Response.CorrectedDuration and Stats
+	CorrectCoordinatedOmission bool `json:"correct_coordinated_omission"`
+	// Recorder persists every Response and stats snapshots to a file, see LoadRun
+	Recorder *RecorderConfig `json:"-"`
+	// Adaptive replaces Schedule with a closed-loop search of the max sustainable RPS, see AdaptiveConfig
+	Adaptive *AdaptiveConfig `json:"adaptive,omitempty"`
 	// calculated fields
 	duration time.Duration
 	// only available in cluster mode
@@ -196,7 +224,17 @@ func (lgc *Config) Validate() error {
 	if lgc.Gun == nil && lgc.VU == nil {
 		return ErrNoImpl
 	}
-	if lgc.Schedule == nil {
+	if lgc.Adaptive != nil {
+		if lgc.LoadType != RPS {
+			return ErrAdaptiveNotRPS
+		}
+		if lgc.Schedule != nil {
+			return ErrAdaptiveWithSchedule
+		}
+		if err := lgc.Adaptive.Validate(); err != nil {
+			return err
+		}
+	} else if lgc.Schedule == nil {
 		return ErrNoSchedule
 	}
 	if lgc.LoadType != RPS && lgc.LoadType != VU {
@@ -231,6 +269,14 @@ type Stats struct {
 	Failed          atomic.Int64 `json:"failed"`
 	CallTimeout     atomic.Int64 `json:"callTimeout"`
 	Duration        int64        `json:"load_duration"`
+	// coordinated omission corrected latency, only recorded when Config.CorrectCoordinatedOmission is enabled
+	CorrectedCalls       atomic.Int64 `json:"corrected_calls"`
+	CorrectedDurationSum atomic.Int64 `json:"corrected_duration_sum"`
+	MaxCorrectedDuration atomic.Int64 `json:"max_corrected_duration"`
+	// MaxSustainableRPS is the highest rate that met the SLO, only set when Config.Adaptive is used
+	MaxSustainableRPS atomic.Int64 `json:"max_sustainable_rps"`
+	// Latency holds streaming latency histograms for the whole run and the last StatsPollInterval window
+	Latency *LatencyStats `json:"-"`
 }
 
 // ResponseData includes any request/response data that a gun might store
@@ -274,6 +320,12 @@ type Generator struct {
 	loki                 *LokiClient
 	backendResponsesChan chan *Response
 	otel                 *OTELClient
+	intended             *intendedSchedule
+	prom                 *PrometheusExporter
+	adaptive             *adaptiveRun
+	recorder             *RunRecorder
+	recorderOnce         *sync.Once
+	runMeta              *RunMeta
 }
 
 // NewGenerator initializes a Generator with the provided configuration.
@@ -290,10 +342,16 @@ func NewGenerator(cfg *Config) (*Generator, error) {
 		if err := s.Validate(); err != nil {
 			return nil, err
 		}
+		if s.isArrival() && cfg.LoadType != RPS {
+			return nil, ErrArrivalSegmentNotRPS
+		}
 	}
 	for _, s := range cfg.Schedule {
 		cfg.duration += s.Duration
 	}
+	if cfg.Adaptive != nil {
+		cfg.duration = cfg.Adaptive.MaxDuration
+	}
 	l := GetLogger(cfg.T, cfg.GenName)
 
 	ls := LabelsMapToModel(cfg.Labels)
@@ -329,6 +387,7 @@ func NewGenerator(cfg *Config) (*Generator, error) {
 		gun:                cfg.Gun,
 		vu:                 cfg.VU,
 		rpsLoopOnce:        &sync.Once{},
+		recorderOnce:       &sync.Once{},
 		Responses:          NewResponses(rch),
 		ResponsesChan:      rch,
 		labels:             ls,
@@ -343,9 +402,13 @@ func NewGenerator(cfg *Config) (*Generator, error) {
 		},
 		errsMu:               &sync.Mutex{},
 		errs:                 NewSliceBuffer[string](cfg.CallResultBufLen),
-		stats:                &Stats{},
+		stats:                &Stats{Latency: NewLatencyStats()},
 		Log:                  l,
 		backendResponsesChan: make(chan *Response, 50000),
+		intended:             &intendedSchedule{},
+	}
+	if cfg.Adaptive != nil {
+		g.adaptive = newAdaptiveRun()
 	}
 	var err error
 	if cfg.LokiConfig != nil {
@@ -360,6 +423,18 @@ func NewGenerator(cfg *Config) (*Generator, error) {
 			return nil, err
 		}
 	}
+	if cfg.PrometheusConfig != nil {
+		g.prom, err = NewPrometheusExporter(cfg.PrometheusConfig, ls)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Recorder != nil {
+		g.recorder, err = NewRunRecorder(cfg.Recorder)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return g, nil
 }
 
@@ -477,6 +552,7 @@ func (g *Generator) runVU(vu VirtualUser) {
 // It returns true when all segments have been handled, signaling the scheduler to terminate.
 func (g *Generator) processSegment() bool {
 	defer func() {
+		g.observePrometheusStats()
 		g.Log.Info().
 			Int64("Segment", g.stats.CurrentSegment.Load()).
 			Int64("VUs", g.stats.CurrentVUs.Load()).
@@ -490,7 +566,10 @@ func (g *Generator) processSegment() bool {
 	g.currentSegment = g.scheduleSegments[g.stats.CurrentSegment.Load()]
 	g.currentSegmentMu.Unlock()
 	g.stats.CurrentSegment.Add(1)
-	if g.currentSegment.From == 0 {
+	// arrival curves control their own rate, including periods of zero rate
+	if g.currentSegment.isArrival() {
+		g.Resume()
+	} else if g.currentSegment.From == 0 {
 		g.currentSegment.From = 1
 		g.Pause()
 	} else {
@@ -499,7 +578,17 @@ func (g *Generator) processSegment() bool {
 	g.currentSegment.StartTime = time.Now()
 	switch g.Cfg.LoadType {
 	case RPS:
-		newRateLimit := ratelimit.New(int(g.currentSegment.From), ratelimit.Per(g.Cfg.RateLimitUnitDuration), ratelimit.WithoutSlack)
+		if old := g.rl.Load(); old != nil {
+			if al, ok := (*old).(*arrivalLimiter); ok {
+				al.stop()
+			}
+		}
+		var newRateLimit ratelimit.Limiter
+		if g.currentSegment.isArrival() {
+			newRateLimit = newArrivalLimiter(g.ResponsesCtx, g.currentSegment, g.Cfg.RateLimitUnitDuration, &g.stats.CurrentRPS)
+		} else {
+			newRateLimit = ratelimit.New(int(g.currentSegment.From), ratelimit.Per(g.Cfg.RateLimitUnitDuration), ratelimit.WithoutSlack)
+		}
 		g.rl.Store(&newRateLimit)
 		g.stats.CurrentRPS.Store(g.currentSegment.From)
 		// start Gun loop once, in next segments we control it using g.rl ratelimiter
@@ -565,7 +654,21 @@ func (g *Generator) storeResponses(res *Response) {
 	if g.Cfg.CallTimeout > 0 && res.Duration > g.Cfg.CallTimeout && !res.Timeout {
 		return
 	}
+	g.stats.recordCorrectedDuration(res)
+	g.stats.Latency.Record(res)
+	if g.recorder != nil {
+		g.recorder.RecordResponse(res)
+	}
+	if g.adaptive != nil {
+		g.adaptive.record(res)
+	}
+	if g.prom != nil {
+		g.prom.ObserveResponse(res)
+	}
 	if !g.sampler.ShouldRecord(res, g.stats) {
+		if g.prom != nil {
+			g.prom.ObserveSkipped()
+		}
 		return
 	}
 	if g.hasLogBackend() {
@@ -641,6 +744,10 @@ func (g *Generator) pacedCall() {
 	result := make(chan *Response)
 	requestCtx, cancel := context.WithTimeout(context.Background(), g.Cfg.CallTimeout)
 	callStartTS := time.Now()
+	var intendedStartTS time.Time
+	if g.Cfg.CorrectCoordinatedOmission {
+		intendedStartTS = g.intendedStartTime(callStartTS)
+	}
 	go func() {
 		result <- g.gun.Call(g)
 	}()
@@ -651,12 +758,18 @@ func (g *Generator) pacedCall() {
 		case <-requestCtx.Done():
 			ts := time.Now()
 			cr := &Response{Duration: time.Since(callStartTS), FinishedAt: &ts, Timeout: true, Error: ErrCallTimeout.Error()}
+			if g.Cfg.CorrectCoordinatedOmission {
+				correctResponse(cr, intendedStartTS)
+			}
 			g.storeResponses(cr)
 		case res := <-result:
 			defer close(result)
 			res.Duration = time.Since(callStartTS)
 			ts := time.Now()
 			res.FinishedAt = &ts
+			if g.Cfg.CorrectCoordinatedOmission {
+				correctResponse(res, intendedStartTS)
+			}
 			g.storeResponses(res)
 		}
 		cancel()
@@ -668,12 +781,20 @@ func (g *Generator) pacedCall() {
 // Use Run to execute generator tasks either synchronously or asynchronously.
 func (g *Generator) Run(wait bool) (interface{}, bool) {
 	g.Log.Info().Msg("Load generator started")
+	if g.recorder != nil {
+		g.runMeta = &RunMeta{Config: g.Cfg, Labels: g.Cfg.Labels, StartedAt: time.Now()}
+		g.recorder.RecordMeta(g.runMeta)
+	}
 	g.printStatsLoop()
 	if g.hasLogBackend() {
 		g.sendResponsesToLogBackend()
 		g.sendStatsToLogBackend()
 	}
-	g.runScheduleLoop()
+	if g.Cfg.Adaptive != nil {
+		g.runAdaptiveLoop()
+	} else {
+		g.runScheduleLoop()
+	}
 	g.collectVUResults()
 	if wait {
 		return g.Wait()
@@ -714,9 +835,11 @@ func (g *Generator) Wait() (interface{}, bool) {
 	g.ResponsesWaitGroup.Wait()
 	g.stats.Duration = g.Cfg.duration.Nanoseconds()
 	g.stats.CurrentTimeUnit = g.Cfg.RateLimitUnitDuration.Nanoseconds()
+	g.observePrometheusStats()
 	g.dataCancel()
 	g.dataWaitGroup.Wait()
 	g.stopLogStream()
+	g.stopRecorder()
 	return g.GetData(), g.stats.RunFailed.Load()
 }
 
@@ -787,6 +910,25 @@ func (g *Generator) handleStatsPayload() {
 	}
 }
 
+// stopRecorder writes the final stats snapshot, the final meta with executed segment times and closes the run file,
+// Wait can be called more than once
+func (g *Generator) stopRecorder() {
+	if g.recorder == nil {
+		return
+	}
+	g.recorderOnce.Do(func() {
+		g.recorder.RecordStats(g.StatsJSON())
+		if g.runMeta != nil {
+			finishedAt := time.Now()
+			g.runMeta.FinishedAt = &finishedAt
+			g.recorder.RecordMeta(g.runMeta)
+		}
+		if err := g.recorder.Close(); err != nil {
+			g.Log.Error().Err(err).Msg("Failed to write run file")
+		}
+	})
+}
+
 // stopLogStream gracefully terminates whichever log backend is configured.
 func (g *Generator) stopLogStream() {
 	if g.loki != nil {
@@ -903,25 +1045,56 @@ func (g *Generator) handleOTELStatsPayload() {
 	}
 }
 
+/* Prometheus exporter methods */
+
+// observePrometheusStats updates Prometheus gauges if the exporter is configured
+func (g *Generator) observePrometheusStats() {
+	if g.prom == nil {
+		return
+	}
+	g.prom.ObserveStats(g.stats)
+}
+
 /* Local logging methods */
 
 // StatsJSON returns the generator's current statistics as a JSON-compatible map.
 // It is used to capture and transmit real-time metrics for monitoring and analysis.
 func (g *Generator) StatsJSON() map[string]interface{} {
+	groupLatency, windowGroupLatency := g.groupLatency()
 	return map[string]interface{}{
-		"node_id":           g.Cfg.nodeID,
-		"current_rps":       g.stats.CurrentRPS.Load(),
-		"current_instances": g.stats.CurrentVUs.Load(),
-		"samples_recorded":  g.stats.SamplesRecorded.Load(),
-		"samples_skipped":   g.stats.SamplesSkipped.Load(),
-		"run_stopped":       g.stats.RunStopped.Load(),
-		"run_failed":        g.stats.RunFailed.Load(),
-		"failed":            g.stats.Failed.Load(),
-		"success":           g.stats.Success.Load(),
-		"callTimeout":       g.stats.CallTimeout.Load(),
-		"load_duration":     g.stats.Duration,
-		"current_time_unit": g.stats.CurrentTimeUnit,
+		"node_id":                g.Cfg.nodeID,
+		"current_rps":            g.stats.CurrentRPS.Load(),
+		"current_instances":      g.stats.CurrentVUs.Load(),
+		"samples_recorded":       g.stats.SamplesRecorded.Load(),
+		"samples_skipped":        g.stats.SamplesSkipped.Load(),
+		"run_stopped":            g.stats.RunStopped.Load(),
+		"run_failed":             g.stats.RunFailed.Load(),
+		"failed":                 g.stats.Failed.Load(),
+		"success":                g.stats.Success.Load(),
+		"callTimeout":            g.stats.CallTimeout.Load(),
+		"load_duration":          g.stats.Duration,
+		"current_time_unit":      g.stats.CurrentTimeUnit,
+		"corrected_calls":        g.stats.CorrectedCalls.Load(),
+		"avg_corrected_duration": g.stats.AvgCorrectedDuration().Nanoseconds(),
+		"max_corrected_duration": g.stats.MaxCorrectedDuration.Load(),
+		"latency":                g.stats.Latency.Total(),
+		"window_latency":         g.stats.Latency.Window(),
+		"group_latency":          groupLatency,
+		"window_group_latency":   windowGroupLatency,
+		"max_sustainable_rps":    g.stats.MaxSustainableRPS.Load(),
+	}
+}
+
+// groupLatency returns latency percentiles of every Response.Group for the whole run and the last window
+func (g *Generator) groupLatency() (map[string]LatencySnapshot, map[string]LatencySnapshot) {
+	groups := g.stats.Latency.Groups()
+	total := make(map[string]LatencySnapshot, len(groups))
+	window := make(map[string]LatencySnapshot, len(groups))
+	for _, group := range groups {
+		total[group] = g.stats.Latency.Group(group)
+		window[group] = g.stats.Latency.GroupWindow(group)
 	}
+	return total, window
 }
 
 // printStatsLoop starts a background loop that periodically logs generator statistics.
@@ -938,11 +1111,32 @@ func (g *Generator) printStatsLoop() {
 				return
 			default:
 				time.Sleep(g.Cfg.StatsPollInterval)
+				g.observePrometheusStats()
+				g.stats.Latency.Rotate()
+				if g.recorder != nil {
+					g.recorder.RecordStats(g.StatsJSON())
+				}
+				w := g.stats.Latency.Window()
 				g.Log.Info().
 					Int64("Success", g.stats.Success.Load()).
 					Int64("Failed", g.stats.Failed.Load()).
 					Int64("CallTimeout", g.stats.CallTimeout.Load()).
+					Dur("P50", w.P50).
+					Dur("P95", w.P95).
+					Dur("P99", w.P99).
+					Dur("Max", w.Max).
 					Msg("Load stats")
+				for _, group := range g.stats.Latency.Groups() {
+					gw := g.stats.Latency.GroupWindow(group)
+					g.Log.Info().
+						Str("Group", group).
+						Int64("Count", gw.Count).
+						Dur("P50", gw.P50).
+						Dur("P95", gw.P95).
+						Dur("P99", gw.P99).
+						Dur("Max", gw.Max).
+						Msg("Group latency stats")
+				}
 			}
 		}
 	}()
@@ -1,11 +1,13 @@
 package wasp
 
 import (
+	"errors"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
+	"go.uber.org/ratelimit"
 )
 
 func TestMain(m *testing.M) {
@@ -846,6 +848,78 @@ func TestProfiles(t *testing.T) {
 		require.Empty(t, failResponses)
 		require.Empty(t, g1.Errors())
 	})
+
+	t.Run("hooks run along with the profile and record windows", func(t *testing.T) {
+		t.Parallel()
+		hook := &testProfileHook{}
+		p, err := NewProfile().
+			WithHooks(hook).
+			Add(NewGenerator(&Config{
+				T:        t,
+				LoadType: RPS,
+				GenName:  "A",
+				Schedule: Plain(5, 2*time.Second),
+				Gun: NewMockGun(&MockGunConfig{
+					CallSleep: 10 * time.Millisecond,
+				}),
+			})).
+			Run(true)
+		require.NoError(t, err)
+		require.Equal(t, p.StartTime(), hook.started)
+		require.Len(t, p.Windows(), 1)
+		require.Equal(t, "window", p.Windows()[0].Name)
+		require.True(t, p.Windows()[0].Contains(p.StartTime()))
+
+		// hooks are stopped once
+		p.Wait()
+		require.Len(t, p.Windows(), 1)
+		require.Equal(t, 1, hook.stopped)
+	})
+
+	t.Run("hook errors are returned", func(t *testing.T) {
+		t.Parallel()
+		_, err := NewProfile().
+			WithHooks(&testProfileHook{startErr: errors.New("start failed")}).
+			Add(NewGenerator(&Config{
+				T:        t,
+				LoadType: RPS,
+				GenName:  "A",
+				Schedule: Plain(5, time.Second),
+				Gun:      NewMockGun(&MockGunConfig{}),
+			})).
+			Run(true)
+		require.ErrorContains(t, err, "start failed")
+
+		p, err := NewProfile().
+			WithHooks(&testProfileHook{stopErr: errors.New("stop failed")}).
+			Add(NewGenerator(&Config{
+				T:        t,
+				LoadType: RPS,
+				GenName:  "A",
+				Schedule: Plain(5, time.Second),
+				Gun:      NewMockGun(&MockGunConfig{}),
+			})).
+			Run(true)
+		require.ErrorContains(t, err, "stop failed")
+		require.ErrorContains(t, p.HooksErr(), "stop failed")
+	})
+}
+
+type testProfileHook struct {
+	startErr error
+	stopErr  error
+	started  time.Time
+	stopped  int
+}
+
+func (h *testProfileHook) Start(p *Profile) error {
+	h.started = p.StartTime()
+	return h.startErr
+}
+
+func (h *testProfileHook) Stop(_ *Profile) ([]TimeWindow, error) {
+	h.stopped++
+	return []TimeWindow{{Name: "window", Start: h.started, End: time.Now()}}, h.stopErr
 }
 
 func TestSamplerStoresFailedResults(t *testing.T) {
@@ -1058,3 +1132,160 @@ func TestZeroSchedule(t *testing.T) {
 	require.Equal(t, false, failed)
 	require.GreaterOrEqual(t, gen.Stats().Success.Load(), int64(5))
 }
+
+func TestSmokePoissonRPSSchedule(t *testing.T) {
+	t.Parallel()
+	gen, err := NewGenerator(&Config{
+		T:        t,
+		LoadType: RPS,
+		Schedule: Poisson(200, 5*time.Second),
+		Gun: NewMockGun(&MockGunConfig{
+			CallSleep: 10 * time.Millisecond,
+		}),
+	})
+	require.NoError(t, err)
+	_, failed := gen.Run(true)
+	require.Equal(t, false, failed)
+	// expected 1000 arrivals, std dev is ~32
+	require.GreaterOrEqual(t, gen.Stats().Success.Load(), int64(850))
+	require.LessOrEqual(t, gen.Stats().Success.Load(), int64(1150))
+}
+
+func TestSmokeRampRPSSchedule(t *testing.T) {
+	t.Parallel()
+	gen, err := NewGenerator(&Config{
+		T:        t,
+		LoadType: RPS,
+		Schedule: Ramp(0, 200, 4*time.Second),
+		Gun: NewMockGun(&MockGunConfig{
+			CallSleep: 10 * time.Millisecond,
+		}),
+	})
+	require.NoError(t, err)
+	_, failed := gen.Run(true)
+	require.Equal(t, false, failed)
+	// area under the ramp is 400 calls
+	require.GreaterOrEqual(t, gen.Stats().Success.Load(), int64(340))
+	require.LessOrEqual(t, gen.Stats().Success.Load(), int64(420))
+	require.InDelta(t, 200, gen.Stats().CurrentRPS.Load(), 10)
+}
+
+func TestSmokeArrivalSegmentsValidation(t *testing.T) {
+	t.Parallel()
+	_, err := NewGenerator(&Config{
+		T:        t,
+		LoadType: VU,
+		Schedule: Poisson(10, 1*time.Second),
+		VU:       NewMockVU(&MockVirtualUserConfig{}),
+	})
+	require.Equal(t, ErrArrivalSegmentNotRPS, err)
+	_, err = NewGenerator(&Config{
+		T:        t,
+		LoadType: RPS,
+		Schedule: []*Segment{{From: 1, Duration: time.Second, Type: SegmentType_Curve}},
+		Gun:      NewMockGun(&MockGunConfig{}),
+	})
+	require.Equal(t, ErrMissingSegmentCurve, err)
+}
+
+func TestSmokeCoordinatedOmissionCorrection(t *testing.T) {
+	t.Parallel()
+	gen, err := NewGenerator(&Config{
+		T:                          t,
+		LoadType:                   RPS,
+		Schedule:                   Plain(100, 2*time.Second),
+		CorrectCoordinatedOmission: true,
+		Gun: NewMockGun(&MockGunConfig{
+			CallSleep: 10 * time.Millisecond,
+		}),
+	})
+	require.NoError(t, err)
+	_, failed := gen.Run(true)
+	require.Equal(t, false, failed)
+	_, okResponses, _ := convertResponsesData(gen)
+	require.NotEmpty(t, okResponses)
+	for _, r := range okResponses {
+		require.NotNil(t, r.IntendedStartedAt)
+		require.GreaterOrEqual(t, r.CorrectedDuration, r.Duration)
+	}
+	stats := gen.Stats()
+	require.Equal(t, stats.Success.Load(), stats.CorrectedCalls.Load())
+	require.GreaterOrEqual(t, stats.AvgCorrectedDuration(), 10*time.Millisecond)
+	require.GreaterOrEqual(t, stats.MaxCorrectedDuration.Load(), int64(10*time.Millisecond))
+}
+
+func TestCoordinatedOmissionIntendedStartTime(t *testing.T) {
+	t.Parallel()
+	gen, err := NewGenerator(&Config{
+		T:        t,
+		LoadType: RPS,
+		Schedule: Plain(10, 1*time.Second),
+		Gun:      NewMockGun(&MockGunConfig{}),
+	})
+	require.NoError(t, err)
+	// simulate a generator which is 1 second behind the schedule
+	now := time.Now()
+	seg := gen.scheduleSegments[0]
+	seg.StartTime = now.Add(-1 * time.Second)
+	gen.currentSegment = seg
+	var rl ratelimit.Limiter = ratelimit.New(10)
+	gen.rl.Store(&rl)
+	require.Equal(t, seg.StartTime, gen.intendedStartTime(now))
+	require.Equal(t, seg.StartTime.Add(100*time.Millisecond), gen.intendedStartTime(now))
+	require.Equal(t, seg.StartTime.Add(200*time.Millisecond), gen.intendedStartTime(now))
+	// intended start can't be in the future
+	for i := 0; i < 20; i++ {
+		require.False(t, gen.intendedStartTime(now).After(now))
+	}
+	// repeated schedule reuses the segment with a new start time, intended start is reset
+	seg.StartTime = now.Add(-500 * time.Millisecond)
+	require.Equal(t, seg.StartTime, gen.intendedStartTime(now))
+	require.Equal(t, seg.StartTime.Add(100*time.Millisecond), gen.intendedStartTime(now))
+}
+
+func TestSmokeLatencyStats(t *testing.T) {
+	t.Parallel()
+	gen, err := NewGenerator(&Config{
+		T:                 t,
+		LoadType:          RPS,
+		StatsPollInterval: 1 * time.Second,
+		Schedule:          Plain(50, 3*time.Second),
+		Gun: NewMockGun(&MockGunConfig{
+			CallSleep: 20 * time.Millisecond,
+		}),
+	})
+	require.NoError(t, err)
+	_, failed := gen.Run(true)
+	require.Equal(t, false, failed)
+	total := gen.Stats().Latency.Total()
+	require.Equal(t, gen.Stats().Success.Load(), total.Count)
+	require.GreaterOrEqual(t, total.P50, 20*time.Millisecond)
+	require.GreaterOrEqual(t, total.Max, total.P99)
+	require.Greater(t, gen.Stats().Latency.Window().Count, int64(0))
+	require.Equal(t, total, gen.StatsJSON()["latency"])
+}
+
+func TestStatsJSONGroupLatency(t *testing.T) {
+	t.Parallel()
+	gen, err := NewGenerator(&Config{
+		T:        t,
+		LoadType: RPS,
+		Schedule: Plain(1, 1*time.Second),
+		Gun:      NewMockGun(&MockGunConfig{}),
+	})
+	require.NoError(t, err)
+	gen.stats.Latency.Record(&Response{Duration: 10 * time.Millisecond, Group: "a"})
+	gen.stats.Latency.Record(&Response{Duration: 20 * time.Millisecond, Group: "b"})
+	gen.stats.Latency.Rotate()
+	gen.stats.Latency.Record(&Response{Duration: 30 * time.Millisecond, Group: "a"})
+
+	stats := gen.StatsJSON()
+	groups := stats["group_latency"].(map[string]LatencySnapshot)
+	require.Len(t, groups, 2)
+	require.Equal(t, gen.stats.Latency.Group("a"), groups["a"])
+	require.Equal(t, int64(2), groups["a"].Count)
+	require.Equal(t, int64(1), groups["b"].Count)
+	windows := stats["window_group_latency"].(map[string]LatencySnapshot)
+	require.Equal(t, int64(1), windows["a"].Count)
+	require.Equal(t, int64(1), windows["b"].Count)
+}